@@ -0,0 +1,172 @@
+// Command admin manages the API keys of the aggregator. It reads the same
+// environment as the server, so keys are issued to the store the server uses.
+//
+//	admin issue -owner alice -scopes quote,token:write -ttl 720h
+//	admin revoke <id>
+//	admin list
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/auth"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/config"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/storage"
+)
+
+const usage = `Usage: admin <command> [arguments]
+
+Commands:
+  issue -owner <owner> -scopes <scopes> [-ttl <duration>]  Issue a key, printing it once
+  revoke <id>                                              Revoke a key
+  list                                                     List issued keys
+
+Scopes: %s
+`
+
+func main() {
+	log.SetFlags(0)
+	flag.Usage = func() { fmt.Fprintf(os.Stderr, usage, strings.Join(auth.Scopes, ", ")) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.InitConfig()
+	if cfg.StorageBackend != storage.BackendRedis {
+		log.Fatalf("API keys need the %s storage backend, STORAGE_BACKEND is %s", storage.BackendRedis, cfg.StorageBackend)
+	}
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create %s store: %v", cfg.StorageBackend, err)
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	command, args := flag.Arg(0), flag.Args()[1:]
+	switch command {
+	case "issue":
+		err = issue(ctx, store, args)
+	case "revoke":
+		err = revoke(ctx, store, args)
+	case "list":
+		err = list(ctx, store)
+	default:
+		err = fmt.Errorf("unknown command %q", command)
+	}
+	if err != nil {
+		store.Close()
+		log.Fatal(err)
+	}
+}
+
+// issue creates a key and prints it, it can't be shown again
+func issue(ctx context.Context, store storage.Store, args []string) error {
+	flags := flag.NewFlagSet("issue", flag.ExitOnError)
+	owner := flags.String("owner", "", "who the key is issued to")
+	scopeList := flags.String("scopes", auth.ScopeQuote, "comma-separated scopes")
+	ttl := flags.Duration("ttl", 0, "how long the key is valid for, 0 never expires")
+	flags.Parse(args)
+
+	if *owner == "" {
+		return fmt.Errorf("-owner is required")
+	}
+	if *ttl < 0 {
+		return fmt.Errorf("-ttl can't be negative")
+	}
+	scopes, err := auth.ParseScopes(*scopeList)
+	if err != nil {
+		return err
+	}
+
+	plaintext, key, err := auth.Generate(*owner, scopes, *ttl)
+	if err != nil {
+		return err
+	}
+	existing, err := store.GetAPIKey(ctx, key.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("key ID %s is already in use, issue the key again", key.ID)
+	}
+	if err := store.SaveAPIKey(ctx, key); err != nil {
+		return err
+	}
+
+	fmt.Printf("Issued key %s to %s with scopes %s\n", key.ID, key.Owner, strings.Join(key.Scopes, ","))
+	if key.ExpiresAt != 0 {
+		fmt.Printf("Expires %s\n", formatTime(key.ExpiresAt))
+	}
+	fmt.Printf("\n%s\n\nStore the key now, it can't be shown again.\n", plaintext)
+	return nil
+}
+
+// revoke marks a key as revoked, it stays listed
+func revoke(ctx context.Context, store storage.Store, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: admin revoke <id>")
+	}
+
+	key, err := store.GetAPIKey(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return fmt.Errorf("no key with ID %s", args[0])
+	}
+	if key.RevokedAt != 0 {
+		return fmt.Errorf("key %s was already revoked %s", key.ID, formatTime(key.RevokedAt))
+	}
+
+	key.RevokedAt = time.Now().Unix()
+	if err := store.SaveAPIKey(ctx, *key); err != nil {
+		return err
+	}
+	fmt.Printf("Revoked key %s of %s\n", key.ID, key.Owner)
+	return nil
+}
+
+// list prints every key, oldest first
+func list(ctx context.Context, store storage.Store) error {
+	keys, err := store.ListAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt < keys[j].CreatedAt })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tOWNER\tSCOPES\tCREATED\tEXPIRES\tSTATUS")
+	now := time.Now()
+	for _, key := range keys {
+		status := "active"
+		switch auth.Active(&key, now) {
+		case auth.ErrRevokedKey:
+			status = "revoked"
+		case auth.ErrExpiredKey:
+			status = "expired"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Owner, strings.Join(key.Scopes, ","),
+			formatTime(key.CreatedAt), formatTime(key.ExpiresAt), status)
+	}
+	return w.Flush()
+}
+
+// formatTime formats a Unix timestamp, 0 is never
+func formatTime(unix int64) string {
+	if unix == 0 {
+		return "never"
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
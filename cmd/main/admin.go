@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/ratelimit"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// Defi godoc
+// @Summary Get the usage of an API key
+// @Description Returns an API key's requests per endpoint for each of the last days, most recent first. Requests rejected by the rate limit are counted under rate_limited. Needs the admin scope.
+// @Tags admin
+// @Produce json
+// @Param id path string true "API key ID"
+// @Param days query int false "Number of days, defaults to 7"
+// @Success 200 {string} The key and its daily usage
+// @Security ApiKeyAuth
+// @Router /admin/keys/{id}/usage [get]
+func keyUsageHandler(c *gin.Context, store storage.Store, limiter *ratelimit.Limiter) {
+	maxDays := limiter.RetentionDays()
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days < 1 || days > maxDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid days: must be between 1 and %d", maxDays)})
+		return
+	}
+
+	key, err := store.GetAPIKey(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get key: %v", err)})
+		return
+	}
+	if key == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Key not found"})
+		return
+	}
+
+	usage, err := limiter.Usage(c.Request.Context(), key.ID, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get usage: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":        key.ID,
+		"owner":     key.Owner,
+		"scopes":    key.Scopes,
+		"createdAt": key.CreatedAt,
+		"expiresAt": key.ExpiresAt,
+		"revokedAt": key.RevokedAt,
+		"usage":     usage,
+	})
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/graph"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// graphHops reads the hops query parameter, defaulting to and capped at maxHops
+func graphHops(c *gin.Context, maxHops int) (int, bool) {
+	hopsStr := c.Query("hops")
+	if hopsStr == "" {
+		return maxHops, true
+	}
+	hops, err := strconv.Atoi(hopsStr)
+	if err != nil || hops <= 0 || hops > maxHops {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid hops: must be between 1 and %d", maxHops),
+		})
+		return 0, false
+	}
+	return hops, true
+}
+
+// graphToken reads a token address query parameter, mapping the native
+// currency to its wrapped token
+func graphToken(c *gin.Context, name string) (common.Address, bool) {
+	address := c.Query(name)
+	if address == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing required parameter: " + name,
+		})
+		return common.Address{}, false
+	}
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid Ethereum address format",
+		})
+		return common.Address{}, false
+	}
+	return tokens.QuoteAddress(common.HexToAddress(address)), true
+}
+
+// graphErrorStatus is the HTTP status for a failed graph query
+func graphErrorStatus(err error) int {
+	if errors.Is(err, graph.ErrUnknownToken) || errors.Is(err, graph.ErrNoPath) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+// Defi godoc
+// @Summary List the tokens a token swaps into directly
+// @Param token query string true "Token address"
+// @Success 200 {string} Neighbouring tokens and their pools, most liquid first
+// @Security ApiKeyAuth
+// @Router /graph/neighbours [get]
+func graphNeighboursHandler(c *gin.Context, tokenGraph *graph.Graph) {
+	token, ok := graphToken(c, "token")
+	if !ok {
+		return
+	}
+
+	node, neighbours, err := tokenGraph.Neighbours(token)
+	if err != nil {
+		c.JSON(graphErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      node,
+		"total":      len(neighbours),
+		"neighbours": neighbours,
+	})
+}
+
+// Defi godoc
+// @Summary List the tokens reachable from a token
+// @Param token query string true "Token address"
+// @Param hops query int false "Maximum number of swaps"
+// @Success 200 {string} Reachable tokens, nearest first
+// @Security ApiKeyAuth
+// @Router /graph/reachable [get]
+func graphReachableHandler(c *gin.Context, tokenGraph *graph.Graph, maxHops int) {
+	token, ok := graphToken(c, "token")
+	if !ok {
+		return
+	}
+	hops, ok := graphHops(c, maxHops)
+	if !ok {
+		return
+	}
+
+	node, reached, err := tokenGraph.Reachable(token, hops)
+	if err != nil {
+		c.JSON(graphErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if reached == nil {
+		reached = []graph.Reach{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":  node,
+		"hops":   hops,
+		"total":  len(reached),
+		"tokens": reached,
+	})
+}
+
+// Defi godoc
+// @Summary Find the best path between two tokens
+// @Param tokenIn query string true "Input token address"
+// @Param tokenOut query string true "Output token address"
+// @Param hops query int false "Maximum number of swaps"
+// @Success 200 {string} The path with the fewest hops and the most liquidity
+// @Security ApiKeyAuth
+// @Router /graph/path [get]
+func graphPathHandler(c *gin.Context, tokenGraph *graph.Graph, maxHops int) {
+	tokenIn, ok := graphToken(c, "tokenIn")
+	if !ok {
+		return
+	}
+	tokenOut, ok := graphToken(c, "tokenOut")
+	if !ok {
+		return
+	}
+	hops, ok := graphHops(c, maxHops)
+	if !ok {
+		return
+	}
+
+	path, err := tokenGraph.BestPath(tokenIn, tokenOut, hops)
+	if err != nil {
+		c.JSON(graphErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, path)
+}
@@ -2,279 +2,276 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
-	"math"
-	"math/big"
-	"time"
+	"io"
+	"net/http"
+	"os"
+	"strings"
 
 	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/auth"
 	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
-	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
 	"github.com/bitcoinbrisbane/defi-aggregator/internal/config" // Import the new config package
-	// "github.com/bitcoinbrisbane/defi-aggregator/internal/t" // Import the new types package
-
-	// "github.com/bitcoinbrisbane/defi-aggregator/internal/clients/uniswap"
-	// "github.com/bitcoinbrisbane/defi-aggregator/internal/clients/curvefi"
-	// "github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/graph"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/ratelimit"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/rpc"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/storage"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/stream"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tokenservice"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tracing"
 	"github.com/ethereum/go-ethereum/common"
-
-	// "github.com/ethereum/go-ethereum/node"
-	"net/http"
-
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
-	// docs "github.com/bitcoinbrisbane/defi-aggregator/docs"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
-    
-    // _ "github.com/bitcoinbrisbane/defi-aggregator/docs"
-)
 
+	_ "github.com/bitcoinbrisbane/defi-aggregator/docs" // Generated OpenAPI served under /swagger
+)
 
-// // TokenRequest defines the structure for the token post request
-type TokenRequest struct {
-	Address string `json:"address" binding:"required"`
-}
-
-// TokenMetadata represents the ERC20 token metadata
-type TokenMetadata struct {
-	Address  string `json:"address"`
-	Name     string `json:"name"`
-	Symbol   string `json:"symbol"`
-	Decimals uint8  `json:"decimals"`
-}
-
-type Quote struct {
-	TokenIn   string
-	TokenOut  string
-	AmountIn  *big.Int
-	AmountOut *big.Int
-}
+var (
+	logger       = logging.For("main")
+	accessLogger = logging.For("http")
+)
 
 type Response struct {
 	Message string `json:"message"`
 }
 
-// API Key middleware for simple authentication
-func apiKeyAuth() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		apiKey := c.GetHeader("x-api-key")
-		expectedApiKey := config.GetEnvWithDefault("API_KEY", "default-api-key")
-		
-		if apiKey == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Missing API key",
-			})
-			return
-		}
-		
-		if apiKey != expectedApiKey {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid API key",
-			})
-			return
-		}
-		
-		c.Next()
-	}
+// fatal logs an error that stops the server and exits
+func fatal(msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
 }
 
-// connectRedis establishes a connection to Redis
-func connectRedis() (*redis.Client, error) {
-	redisURL := config.GetEnvWithDefault("REDIS_URL", "localhost:6379")
-	redisPassword := config.GetEnvWithDefault("REDIS_PASSWORD", "Test1234!")
-	redisDB := 0
-	
-	client := redis.NewClient(&redis.Options{
-		Addr:     redisURL,
-		Password: redisPassword,
-		DB:       redisDB,
-	})
-	
-	// Verify connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
-	_, err := client.Ping(ctx).Result()
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %v", err)
-	}
-	
-	return client, nil
-}
+func main() {
+	// Load the configuration
+	cfg := config.InitConfig()
 
-// saveTokenMetadata saves the token metadata to Redis
-func saveTokenMetadata(ctx context.Context, client *redis.Client, metadata TokenMetadata) error {
-	// Use token address as key
-	key := "token:" + metadata.Address
-	
-	// Marshal the metadata to JSON
-	jsonData, err := json.Marshal(metadata)
+	// Log JSON to stderr, at the configured level of each package
+	logLevels, err := logging.ParseLevels(cfg.LogLevel)
 	if err != nil {
-		return fmt.Errorf("failed to marshal token metadata: %v", err)
+		fatal("Invalid LOG_LEVEL", "error", err)
 	}
-	
-	// Save to Redis
-	err = client.Set(ctx, key, jsonData, 0).Err() // 0 = no expiration
-	if err != nil {
-		return fmt.Errorf("failed to save token metadata to Redis: %v", err)
+	if err := logging.Init(os.Stderr, cfg.LogFormat, logLevels); err != nil {
+		fatal("Invalid LOG_FORMAT", "error", err)
 	}
-	
-	return nil
-}
 
-// getTokenMetadataFromRedis retrieves token metadata from Redis
-func getTokenMetadataFromRedis(ctx context.Context, client *redis.Client, address string) (*TokenMetadata, error) {
-	key := "token:" + address
-	
-	// Get from Redis
-	data, err := client.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return nil, nil // Key does not exist
-	} else if err != nil {
-		return nil, fmt.Errorf("failed to get token metadata from Redis: %v", err)
+	// Log gin's debug output, written unless GIN_MODE is release, at debug
+	ginLogger := logging.For("gin")
+	gin.DebugPrintFunc = func(format string, values ...any) {
+		ginLogger.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)))
 	}
-	
-	// Unmarshal the data
-	var metadata TokenMetadata
-	err = json.Unmarshal([]byte(data), &metadata)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal token metadata: %v", err)
+	gin.DebugPrintRouteFunc = func(method, path, handler string, handlers int) {
+		ginLogger.Debug("Route", "method", method, "path", path, "handler", handler, "handlers", handlers)
 	}
-	
-	return &metadata, nil
-}
 
-// tokenPostHandler handles the /token POST endpoint
-func tokenPostHandler(c *gin.Context) {
-	// Use defer to recover from panics
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("Recovered from panic in tokenPostHandler: %v", r)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Internal server error occurred",
-			})
+	// Create a gin router, the middleware is added below
+	router := gin.New()
+
+	// Client IPs are only taken from the X-Forwarded-For of trusted proxies
+	var trustedProxies []string
+	for _, proxy := range strings.Split(cfg.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
 		}
-	}()
-	
-	// Parse request body
-	var request TokenRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Invalid request format: %v", err),
-		})
-		return
 	}
-	
-	// Validate Ethereum address
-	if !common.IsHexAddress(request.Address) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid Ethereum address format",
-		})
-		return
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		fatal("Invalid TRUSTED_PROXIES", "error", err)
 	}
-	
-	tokenAddress := common.HexToAddress(request.Address)
-	
-	// Connect to Redis
-	redisClient, err := connectRedis()
-	if err != nil {
-		log.Printf("Failed to connect to Redis: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to connect to database",
-		})
-		return
+
+	// Configure the chain's native currency and its wrapped token
+	tokens.SetNativeToken(tokens.NativeToken{
+		Name:     cfg.NativeName,
+		Symbol:   cfg.NativeSymbol,
+		Decimals: 18,
+		Wrapped:  common.HexToAddress(cfg.WrappedNative),
+	})
+
+	// Swaps are sent to each protocol's swap router, not the quoter
+	if err := protocols.SetSwapRouters(cfg.SwapRouters); err != nil {
+		fatal("Invalid SWAP_ROUTERS", "error", err)
 	}
-	defer redisClient.Close()
-	
-	// Check if we already have this token in Redis
-	ctx := context.Background()
-	existingMetadata, err := getTokenMetadataFromRedis(ctx, redisClient, tokenAddress.String())
+
+	// Trace requests, exporting spans over OTLP if a collector is configured
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.OTLPEndpoint)
 	if err != nil {
-		log.Printf("Error checking Redis for token: %v", err)
+		fatal("Failed to set up tracing", "error", err)
 	}
-	
-	if existingMetadata != nil {
-		// Return existing metadata if found
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Token metadata retrieved from cache",
-			"token":   existingMetadata,
-		})
-		return
+	defer shutdownTracing(context.Background())
+	if cfg.OTLPEndpoint != "" {
+		logger.Info("Exporting traces", "endpoint", cfg.OTLPEndpoint)
 	}
-	
-	// Fetch token metadata from the blockchain
-	cfg := config.GetConfig()
-	name, symbol, decimals, err := tokens.GetTokenMetadata(tokenAddress, cfg.NodeURL)
+
+	// Add middleware for request IDs, logging, recovery and tracing
+	router.Use(requestID())
+	router.Use(logRequests())
+	router.Use(gin.CustomRecoveryWithWriter(io.Discard, recoverPanic))
+	router.Use(traceRequests())
+	router.Use(requestMetrics())
+
+	// Create the storage layer shared by the handlers
+	store, err := storage.New(cfg)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Failed to fetch token metadata: %v", err),
-		})
-		return
+		fatal("Failed to create store", "backend", cfg.StorageBackend, "error", err)
 	}
-	
-	// Create metadata object
-	metadata := TokenMetadata{
-		Address:  tokenAddress.String(),
-		Name:     name,
-		Symbol:   symbol,
-		Decimals: decimals,
+	defer store.Close()
+
+	// Check API keys against the store, and against API_KEY as an admin key if it's set
+	keys := auth.NewAuthenticator(store, cfg.APIKey)
+	if cfg.APIKey == "" {
+		logger.Warn("API_KEY is not set, only keys issued with cmd/admin are accepted")
 	}
-	
-	// Save to Redis
-	err = saveTokenMetadata(ctx, redisClient, metadata)
+
+	// Rate limit clients and meter key usage in the store, shared by every instance
+	rateLimits, err := ratelimit.ParseLimits(cfg.RateLimits)
 	if err != nil {
-		log.Printf("Failed to save token metadata to Redis: %v", err)
-		// Continue even if saving fails
+		fatal("Invalid RATE_LIMITS", "error", err)
 	}
-	
-	// Return the metadata
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Token metadata fetched and saved",
-		"token":   metadata,
+	limiter := ratelimit.New(store, rateLimits, cfg.UsageRetention)
+	logger.Info("Rate limits", "targets", rateLimits.Targets())
+	router.Use(ipRateLimit(limiter))
+
+	// Create the token metadata service, caching through the store
+	tokenService := tokenservice.NewService(store, cfg.NodeURL, tokenservice.Config{
+		TTL:     cfg.TokenCacheTTL,
+		MissTTL: cfg.TokenMissTTL,
+		LRUSize: int(cfg.TokenLRUSize),
+		LRUTTL:  cfg.TokenLRUTTL,
 	})
-}
 
-func main() {
-	// Create a default gin router
-	router := gin.Default()
+	// Create aggregator service
+	aggregatorService := aggregator.NewService(cfg.NodeURL, tokenService)
 
-	// Load the configuration
-	cfg := config.InitConfig()
+	// Load the pool registry and record the pools found while quoting
+	poolRegistry := pairs.NewPairHandler(store)
+	if err := poolRegistry.Load(context.Background()); err != nil {
+		logger.Error("Failed to load pool registry", "error", err)
+	}
+	aggregatorService.SetPoolRegistry(poolRegistry)
+
+	// Keep the token graph in step with the pool registry
+	tokenGraph := graph.New(poolRegistry, cfg.NodeURL)
+	go tokenGraph.Run(context.Background(), cfg.GraphRefresh)
+	graphMaxHops := int(cfg.GraphMaxHops)
+
+	// Cache quotes per block so identical requests share one set of RPC calls
+	if cfg.QuoteCacheTTL > 0 {
+		aggregatorService.EnableQuoteCache(aggregator.QuoteCacheConfig{
+			Store:        store,
+			ChainID:      cfg.ChainID,
+			TTL:          cfg.QuoteCacheTTL,
+			AmountDigits: int(cfg.QuoteCacheDigits),
+		})
+	}
 
-	// Add middleware for logging and recovery
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	// Stream quotes every new block
+	heads := stream.NewHeads(cfg.NodeWSURL, cfg.NodeURL, cfg.StreamPollInterval)
+	go heads.Run(context.Background())
+	streamService := stream.NewService(heads, streamQuote(aggregatorService), stream.Config{
+		MaxConnections:   int(cfg.StreamMaxConnections),
+		MaxSubscriptions: int(cfg.StreamMaxSubscriptions),
+	})
 
-	// Create aggregator service
-	aggregatorService := aggregator.NewService(cfg.NodeURL)
+	// Serve the same service over gRPC for internal consumers
+	if cfg.GRPCPort != "" {
+		grpcServer := rpc.NewServer(rpc.Backend{
+			Quote: streamQuote(aggregatorService),
+			Token: rpcToken(tokenService, aggregatorService),
+			Swap:  rpcSwap(aggregatorService),
+		}, streamService, keys, limiter)
+		if cfg.GRPCReflection {
+			grpcServer.EnableReflection()
+		}
+		go func() {
+			if err := grpcServer.ListenAndServe(":" + cfg.GRPCPort); err != nil {
+				fatal("Failed to serve gRPC", "error", err)
+			}
+		}()
+	}
+
+	// Import the curated token list in the background
+	if cfg.TokenListURL != "" || cfg.TokenListPath != "" {
+		go loadTokenList(store, cfg)
+	}
+
+	// Every route but the docs and metrics needs an API key with the route's
+	// scope, and is limited per key
+	quoteScope := []gin.HandlerFunc{apiKeyAuth(keys, auth.ScopeQuote), keyRateLimit(limiter, auth.ScopeQuote)}
+	tokenWriteScope := []gin.HandlerFunc{apiKeyAuth(keys, auth.ScopeTokenWrite), keyRateLimit(limiter, auth.ScopeTokenWrite)}
+	adminScope := []gin.HandlerFunc{apiKeyAuth(keys, auth.ScopeAdmin), keyRateLimit(limiter, auth.ScopeAdmin)}
 
 	// Add routes
 	router.GET("/", helloHandler, ginSwagger.WrapHandler(swaggerfiles.Handler))
-	router.GET("/pairs", func(c *gin.Context) { pairHandler(c, aggregatorService) })
-	router.GET("/protocols", protocolsHandler)
-	router.GET("/token", tokenGetHandler, ginSwagger.WrapHandler(swaggerfiles.Handler))
-	// router.GET("/swagger/doc.json", func(c *gin.Context) {
-	// 	c.File("./docs/swagger.json")
-	// })
-
-	// Protected routes - requires API key
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	quoted := router.Group("/")
+	quoted.Use(quoteScope...)
+	{
+		quoted.GET("/pairs", func(c *gin.Context) { pairHandler(c, aggregatorService) }) // Deprecated, use /api/v1/quote
+		quoted.GET("/swap", func(c *gin.Context) { swapHandler(c, aggregatorService) })
+		quoted.GET("/protocols", protocolsHandler)
+		quoted.GET("/pools", func(c *gin.Context) { poolsHandler(c, poolRegistry, aggregatorService) })
+		quoted.GET("/depth", func(c *gin.Context) { depthHandler(c, aggregatorService) })
+		quoted.GET("/graph/neighbours", func(c *gin.Context) { graphNeighboursHandler(c, tokenGraph) })
+		quoted.GET("/graph/reachable", func(c *gin.Context) { graphReachableHandler(c, tokenGraph, graphMaxHops) })
+		quoted.GET("/graph/path", func(c *gin.Context) { graphPathHandler(c, tokenGraph, graphMaxHops) })
+		quoted.GET("/token", func(c *gin.Context) { tokenGetHandler(c, tokenService, aggregatorService) }, ginSwagger.WrapHandler(swaggerfiles.Handler))
+		quoted.GET("/tokens", func(c *gin.Context) { tokensHandler(c, store) })
+		quoted.POST("/tokens/batch", func(c *gin.Context) { tokenBatchHandler(c, tokenService, aggregatorService) })
+		quoted.POST("/quotes/batch", func(c *gin.Context) { quoteBatchHandler(c, aggregatorService) })
+	}
+
+	// Versioned API
+	v1 := router.Group("/api/v1")
+	v1.Use(quoteScope...)
+	{
+		v1.GET("/quote", func(c *gin.Context) { quoteHandler(c, aggregatorService) })
+		v1.GET("/quote/ws", func(c *gin.Context) { quoteWebSocketHandler(c, streamService) })
+		v1.GET("/quote/stream", func(c *gin.Context) { quoteSSEHandler(c, streamService) })
+	}
+
+	// Token writes
 	protected := router.Group("/")
-	protected.Use(apiKeyAuth())
+	protected.Use(tokenWriteScope...)
+	{
+		protected.POST("/token", func(c *gin.Context) { tokenPostHandler(c, tokenService, aggregatorService) })
+		protected.DELETE("/token", func(c *gin.Context) { tokenInvalidateHandler(c, tokenService, aggregatorService) })
+		protected.POST("/token/refresh", func(c *gin.Context) { tokenRefreshHandler(c, tokenService, aggregatorService) })
+	}
+
+	// Importing reads the configured token list from the network or disk
+	imports := router.Group("/")
+	imports.Use(adminScope...)
+	{
+		imports.POST("/tokens/import", func(c *gin.Context) { tokenImportHandler(c, store) })
+	}
+
+	// Administration
+	admin := router.Group("/admin")
+	admin.Use(adminScope...)
 	{
-		protected.POST("/token", tokenPostHandler)
+		admin.GET("/keys/:id/usage", func(c *gin.Context) { keyUsageHandler(c, store, limiter) })
 	}
 
 	url := ginSwagger.URL("http://localhost:8080/swagger/doc.json") // The URL pointing to API definition
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler, url))
 
 	// Start the server
-	router.Run(":" + cfg.Port)
+	if err := router.Run(":" + cfg.Port); err != nil {
+		fatal("Failed to serve HTTP", "error", err)
+	}
 }
 
-// @BasePath /api/v1
+// @title DeFi Aggregator API
+// @version 1.0
+// @description Quotes and swaps across the supported DEX protocols. Versioned endpoints are under /api/v1.
+// @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name x-api-key
 
 // Defi godoc
 // @Summary ping example
@@ -286,218 +283,3 @@ func helloHandler(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, response)
 }
-
-func protocolsHandler(c *gin.Context) {
-	protocols := protocols.GetSupportedProtocols()
-	c.JSON(http.StatusOK, gin.H{
-		"protocols": protocols,
-	})
-}
-
-func pairHandler(c *gin.Context, aggregatorService *aggregator.Service) {
-	cfg := config.GetConfig()
-
-	// Use defer to recover from panics in this handler
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("Recovered from panic in pairHandler: %v", r)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Internal server error occurred",
-			})
-		}
-	}()
-
-	// Get token addresses from query parameters
-	tokenAAddress := c.Query("tokena")
-	if tokenAAddress == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Missing required parameter: tokena",
-		})
-		return
-	}
-	
-	tokenBAddress := c.Query("tokenb")
-	if tokenBAddress == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Missing required parameter: tokenb",
-		})
-		return
-	}
-	
-	// Get amount from query parameter
-	amountStr := c.DefaultQuery("amount", "10000")
-	
-	// Convert amount string to big.Int
-	amount, success := new(big.Int).SetString(amountStr, 10)
-	if !success {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid amount parameter",
-		})
-		return
-	}
-	
-	// Get token metadata
-	tokenA := common.HexToAddress(tokenAAddress)
-	tokenB := common.HexToAddress(tokenBAddress)
-	
-	// Get token metadata
-	tokenAName, tokenASymbol, tokenADecimals, err := tokens.GetTokenMetadata(tokenA, cfg.NodeURL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Failed to get tokenA metadata: %v", err),
-		})
-		return
-	}
-	
-	tokenBName, tokenBSymbol, tokenBDecimals, err := tokens.GetTokenMetadata(tokenB, cfg.NodeURL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Failed to get tokenB metadata: %v", err),
-		})
-		return
-	}
-	
-	// Log token information
-	log.Printf("TokenA: %s (%s) - %d decimals", tokenAName, tokenASymbol, tokenADecimals)
-	log.Printf("TokenB: %s (%s) - %d decimals", tokenBName, tokenBSymbol, tokenBDecimals)
-	
-	// Option to return all routes or just the best
-	showAllRoutes := c.DefaultQuery("all", "false") == "true"
-	
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
-	defer cancel()
-	
-	// Find the best route across all protocols
-	result, err := aggregatorService.FindBestRoute(
-		ctx,
-		tokenA,
-		tokenB,
-		amount,
-		tokenADecimals,
-		tokenBDecimals,
-		tokenASymbol,
-		tokenBSymbol,
-	)
-	
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Failed to find routes: %v", err),
-		})
-		return
-	}
-	
-	// Return the result
-	if showAllRoutes {
-		// Return all routes
-		c.JSON(http.StatusOK, gin.H{
-			"bestRoute": result.BestRoute,
-			"allRoutes": result.AllRoutes,
-		})
-	} else {
-		// Return only the best route
-		c.JSON(http.StatusOK, gin.H{
-			"result": result.BestRoute,
-		})
-	}
-}
-
-// Defi godoc
-// @Summary ping example
-// @Success 200 {string} Get token metadata
-// @Router /token [get]
-func tokenGetHandler(c *gin.Context) {
-	// Use defer to recover from panics
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("Recovered from panic in tokenGetHandler: %v", r)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Internal server error occurred",
-			})
-		}
-	}()
-	
-	// Get token address from query parameter
-	address := c.Query("address")
-	if address == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Missing required parameter: address",
-		})
-		return
-	}
-	
-	// Validate Ethereum address
-	if !common.IsHexAddress(address) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid Ethereum address format",
-		})
-		return
-	}
-	
-	tokenAddress := common.HexToAddress(address)
-	
-	// Connect to Redis
-	redisClient, err := connectRedis()
-	if err != nil {
-		log.Printf("Failed to connect to Redis: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to connect to database",
-		})
-		return
-	}
-	defer redisClient.Close()
-	
-	// Check if we have this token in Redis
-	ctx := context.Background()
-	cachedMetadata, err := getTokenMetadataFromRedis(ctx, redisClient, tokenAddress.String())
-	if err != nil {
-		log.Printf("Error checking Redis for token: %v", err)
-	}
-	
-	// If found in cache, return it
-	if cachedMetadata != nil {
-		c.JSON(http.StatusOK, gin.H{
-			"source": "cache",
-			"token": cachedMetadata,
-		})
-		return
-	}
-	
-	// Not in cache, fetch from blockchain
-	cfg := config.GetConfig()
-	name, symbol, decimals, err := tokens.GetTokenMetadata(tokenAddress, cfg.NodeURL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Failed to fetch token metadata: %v", err),
-		})
-		return
-	}
-	
-	// Create metadata object
-	metadata := TokenMetadata{
-		Address:  tokenAddress.String(),
-		Name:     name,
-		Symbol:   symbol,
-		Decimals: decimals,
-	}
-	
-	// // Save to Redis for future requests
-	// err = saveTokenMetadata(ctx, redisClient, metadata)
-	// if err != nil {
-	// 	log.Printf("Failed to save token metadata to Redis: %v", err)
-	// 	// Continue even if saving fails
-	// }
-	
-	// Return the metadata
-	c.JSON(http.StatusOK, gin.H{
-		"source": "blockchain",
-		"token": metadata,
-	})
-}
-
-func distance(quote1, quote2 Quote) float64 {
-	dx := quote1.AmountIn.Int64() - quote2.AmountIn.Int64()
-	dy := quote1.AmountOut.Int64() - quote2.AmountOut.Int64()
-
-	return math.Sqrt(float64(dx*dx + dy*dy))
-}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/api"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/auth"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/metrics"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/ratelimit"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryKeyRoutes also take the API key in the apiKey query parameter, for
+// WebSocket and EventSource clients that can't set headers. Elsewhere a key in
+// the URL would end up in access logs, proxies and Referer headers.
+var queryKeyRoutes = map[string]bool{
+	"/api/v1/quote/ws":     true,
+	"/api/v1/quote/stream": true,
+}
+
+// apiKeyAuth requires an active API key with scope, sent in the x-api-key
+// header or, on queryKeyRoutes, the apiKey query parameter. The key is stored
+// in the context as "apiKey".
+func apiKeyAuth(keys *auth.Authenticator, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader("x-api-key")
+		if apiKey == "" && queryKeyRoutes[c.FullPath()] {
+			apiKey = c.Query("apiKey")
+		}
+
+		key, err := keys.Authenticate(c.Request.Context(), apiKey)
+		if err != nil {
+			if auth.Rejected(err) {
+				abortWithError(c, http.StatusUnauthorized, api.CodeUnauthorized, fmt.Sprintf("Unauthorized: %v", err))
+				return
+			}
+			logger.ErrorContext(c.Request.Context(), "Failed to check API key", "error", err)
+			abortWithError(c, http.StatusServiceUnavailable, api.CodeUnavailable, "Failed to check API key")
+			return
+		}
+
+		if !auth.Allows(key, scope) {
+			abortWithError(c, http.StatusForbidden, api.CodeForbidden, fmt.Sprintf("API key doesn't have the %s scope", scope))
+			return
+		}
+
+		c.Set("apiKey", key)
+		c.Next()
+	}
+}
+
+// requestMetrics records the latency of every request by route. Requests that
+// match no route are recorded under "unmatched".
+func requestMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Observe(time.Since(start).Seconds())
+	}
+}
+
+// traceRequests gives every request a span, continuing the caller's trace if
+// it sent a traceparent header. The trace ID is returned in the X-Trace-Id
+// header, and logged with the request through its context.
+func traceRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := tracing.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+			))
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Header("X-Trace-Id", tracing.TraceID(ctx))
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if key, ok := c.Get("apiKey"); ok {
+			span.SetAttributes(attribute.String("api_key.id", key.(*models.APIKey).ID))
+		}
+	}
+}
+
+// requestIDHeader carries the ID of a request, in the request and the response
+const requestIDHeader = "X-Request-Id"
+
+// maxRequestID is the longest request ID accepted from a caller
+const maxRequestID = 128
+
+// requestID gives every request an ID, the caller's if it sent a usable one,
+// and returns it in the X-Request-Id header. The ID is carried in the
+// request's context so everything logged for the request is tagged with it.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = logging.NewRequestID()
+		}
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID reports whether a caller's request ID is safe to log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestID {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// logRequests logs every request once it's served, server errors at the error
+// level
+func logRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("clientIP", c.ClientIP()),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+		}
+		if key, ok := c.Get("apiKey"); ok {
+			attrs = append(attrs, slog.String("key", key.(*models.APIKey).ID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		accessLogger.LogAttrs(c.Request.Context(), level, "Request served", attrs...)
+	}
+}
+
+// recoverPanic logs a panic that escaped a handler and fails the request
+func recoverPanic(c *gin.Context, err any) {
+	logger.ErrorContext(c.Request.Context(), "Recovered from panic", "panic", err, "stack", string(debug.Stack()))
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// ipRateLimit limits requests by client IP, before their key is checked
+func ipRateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := limiter.AllowIP(c.Request.Context(), c.ClientIP())
+		if !applyRateLimit(c, result, err) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// keyRateLimit limits and meters the requests of the key apiKeyAuth checked.
+// Endpoints with their own limit use it, the rest share the scope's limit.
+func keyRateLimit(limiter *ratelimit.Limiter, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.MustGet("apiKey").(*models.APIKey)
+		result, err := limiter.AllowKey(c.Request.Context(), key.ID, scope, c.FullPath())
+
+		field := c.Request.Method + " " + c.FullPath()
+		if !result.Allowed {
+			field = ratelimit.LimitedField
+		}
+		if err := limiter.Record(c.Request.Context(), key.ID, field); err != nil {
+			logger.ErrorContext(c.Request.Context(), "Failed to record usage", "key", key.ID, "error", err)
+		}
+
+		if !applyRateLimit(c, result, err) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// applyRateLimit sets the X-RateLimit headers and rejects the request with 429
+// if it's over the limit, returning whether the request may continue
+func applyRateLimit(c *gin.Context, result ratelimit.Result, err error) bool {
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Failed to check rate limit", "error", err)
+	}
+	if result.Limit > 0 {
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	}
+	if result.Allowed {
+		return true
+	}
+
+	retryAfter := ceilSeconds(result.RetryAfter)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	abortWithError(c, http.StatusTooManyRequests, api.CodeLimitExceeded, fmt.Sprintf("Rate limit exceeded, retry in %ds", retryAfter))
+	return false
+}
+
+// ceilSeconds rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// abortWithError rejects a request, with the typed error body on versioned endpoints
+func abortWithError(c *gin.Context, status int, code, msg string) {
+	if strings.HasPrefix(c.FullPath(), "/api/") {
+		c.AbortWithStatusJSON(status, api.NewErrorResponse(code, msg))
+		return
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": msg})
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/auth"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/metrics"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := storage.NewMemoryStore()
+	quoteKey, record, err := auth.Generate("test", []string{auth.ScopeQuote}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveAPIKey(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+	keys := auth.NewAuthenticator(store, "")
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router := gin.New()
+	router.GET("/pairs", apiKeyAuth(keys, auth.ScopeQuote), ok)
+	router.GET("/api/v1/quote/ws", apiKeyAuth(keys, auth.ScopeQuote), ok)
+	router.GET("/api/v1/quote/stream", apiKeyAuth(keys, auth.ScopeQuote), ok)
+	router.POST("/token", apiKeyAuth(keys, auth.ScopeTokenWrite), ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		status int
+	}{
+		{"header", http.MethodGet, "/pairs", quoteKey, http.StatusOK},
+		{"no key", http.MethodGet, "/pairs", "", http.StatusUnauthorized},
+		{"invalid key", http.MethodGet, "/pairs", "dfa_0123", http.StatusUnauthorized},
+		{"missing scope", http.MethodPost, "/token", quoteKey, http.StatusForbidden},
+		{"query on REST route", http.MethodGet, "/pairs?apiKey=" + quoteKey, "", http.StatusUnauthorized},
+		{"query on WebSocket", http.MethodGet, "/api/v1/quote/ws?apiKey=" + quoteKey, "", http.StatusOK},
+		{"query on SSE", http.MethodGet, "/api/v1/quote/stream?apiKey=" + quoteKey, "", http.StatusOK},
+		{"header wins over query", http.MethodGet, "/api/v1/quote/stream?apiKey=" + quoteKey, "dfa_0123", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+			if test.header != "" {
+				req.Header.Set("x-api-key", test.header)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != test.status {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+		})
+	}
+}
+
+func TestRequestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(requestMetrics())
+	router.GET("/admin/keys/:id/usage", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/admin/keys/abc/usage", "/admin/keys/def/usage", "/missing/route"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Series are labelled by route template, so IDs in paths don't add series
+	tests := []struct {
+		route, status string
+		recorded      bool
+	}{
+		{"/admin/keys/:id/usage", "200", true},
+		{"unmatched", "404", true},
+		{"/admin/keys/abc/usage", "200", false},
+		{"/missing/route", "404", false},
+	}
+	for _, test := range tests {
+		labels := prometheus.Labels{"method": http.MethodGet, "route": test.route, "status": test.status}
+		if recorded := metrics.HTTPRequestDuration.Delete(labels); recorded != test.recorded {
+			t.Errorf("series %s %s recorded = %v, want %v", test.route, test.status, recorded, test.recorded)
+		}
+	}
+}
+
+func TestTraceRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+
+	router := gin.New()
+	router.Use(traceRequests())
+	router.GET("/admin/keys/:id/usage", func(c *gin.Context) {
+		if c.Query("fail") != "" {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	tests := []struct {
+		name        string
+		path        string
+		traceparent string
+		failed      bool
+	}{
+		{"new trace", "/admin/keys/abc/usage", "", false},
+		{"caller's trace", "/admin/keys/abc/usage", "00-" + traceID + "-00f067aa0ba902b7-01", false},
+		{"server error", "/admin/keys/abc/usage?fail=1", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.traceparent != "" {
+				req.Header.Set("traceparent", test.traceparent)
+			}
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, req)
+
+			spans := recorder.Ended()
+			span := spans[len(spans)-1]
+			if span.Name() != "GET /admin/keys/:id/usage" {
+				t.Errorf("span name = %q, want the route template", span.Name())
+			}
+			if got := response.Header().Get("X-Trace-Id"); got != span.SpanContext().TraceID().String() {
+				t.Errorf("X-Trace-Id = %q, want the span's trace %s", got, span.SpanContext().TraceID())
+			}
+			if test.traceparent != "" && span.SpanContext().TraceID().String() != traceID {
+				t.Errorf("trace = %s, want the caller's %s", span.SpanContext().TraceID(), traceID)
+			}
+
+			attrs := make(map[attribute.Key]attribute.Value)
+			for _, attr := range span.Attributes() {
+				attrs[attr.Key] = attr.Value
+			}
+			if route := attrs["http.route"].AsString(); route != "/admin/keys/:id/usage" {
+				t.Errorf("http.route = %q, want the route template", route)
+			}
+			if status := attrs["http.response.status_code"].AsInt64(); status != int64(response.Code) {
+				t.Errorf("http.response.status_code = %d, want %d", status, response.Code)
+			}
+			if failed := span.Status().Code == codes.Error; failed != test.failed {
+				t.Errorf("span failed = %v, want %v", failed, test.failed)
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The handler echoes the ID in its context
+	router := gin.New()
+	router.Use(requestID())
+	router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, logging.RequestID(c.Request.Context())) })
+
+	tests := []struct {
+		name string
+		sent string
+		kept bool
+	}{
+		{"none", "", false},
+		{"caller's", "req-42_a.b:c", true},
+		{"unsafe", "id\nforged log line", false},
+		{"too long", strings.Repeat("a", maxRequestID+1), false},
+		{"longest", strings.Repeat("a", maxRequestID), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.sent != "" {
+				req.Header.Set(requestIDHeader, test.sent)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			id := recorder.Header().Get(requestIDHeader)
+			if id == "" || id != recorder.Body.String() {
+				t.Fatalf("X-Request-Id = %q, context ID = %q, want the same ID", id, recorder.Body)
+			}
+			if kept := id == test.sent; kept != test.kept {
+				t.Errorf("X-Request-Id = %q, kept caller's = %v, want %v", id, kept, test.kept)
+			}
+		})
+	}
+}
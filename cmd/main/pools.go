@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// Defi godoc
+// @Summary List the supported protocols
+// @Produce json
+// @Success 200 {string} Names of the supported protocols
+// @Security ApiKeyAuth
+// @Router /protocols [get]
+func protocolsHandler(c *gin.Context) {
+	protocols := protocols.GetSupportedProtocols()
+	c.JSON(http.StatusOK, gin.H{
+		"protocols": protocols,
+	})
+}
+
+// Defi godoc
+// @Summary List the known pools for a token pair
+// @Description Pools come from the registry, which is filled as pairs are quoted. A pair with no pools in the registry is looked up on every Uniswap fork and fee tier, and the pools found are added.
+// @Param tokenA query string true "First token address"
+// @Param tokenB query string true "Second token address"
+// @Success 200 {string} Pools grouped by protocol
+// @Failure 502 {string} The node couldn't be reached to discover pools
+// @Security ApiKeyAuth
+// @Router /pools [get]
+func poolsHandler(c *gin.Context, poolRegistry *pairs.PairHandler, aggregatorService *aggregator.Service) {
+	tokenAAddress := c.Query("tokenA")
+	tokenBAddress := c.Query("tokenB")
+	if tokenAAddress == "" || tokenBAddress == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing required parameters: tokenA and tokenB",
+		})
+		return
+	}
+	if !common.IsHexAddress(tokenAAddress) || !common.IsHexAddress(tokenBAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid Ethereum address format",
+		})
+		return
+	}
+
+	tokenA := common.HexToAddress(tokenAAddress)
+	tokenB := common.HexToAddress(tokenBAddress)
+
+	// The native currency trades through its wrapped token's pools
+	ctx := c.Request.Context()
+	quoteA, quoteB := tokens.QuoteAddress(tokenA), tokens.QuoteAddress(tokenB)
+	known := poolRegistry.FindProtocolsForPair(ctx, quoteA.String(), quoteB.String())
+
+	// Look up pairs that haven't been quoted
+	if len(known) == 0 && quoteA != quoteB {
+		found, err := aggregatorService.DiscoverPools(ctx, quoteA, quoteB)
+		if err != nil {
+			c.JSON(quoteErrorStatus(err), gin.H{
+				"error": fmt.Sprintf("Failed to discover pools: %v", err),
+			})
+			return
+		}
+		if found > 0 {
+			known = poolRegistry.FindProtocolsForPair(ctx, quoteA.String(), quoteB.String())
+		}
+	}
+
+	byProtocol := make(map[string][]gin.H)
+	for _, pool := range known {
+		byProtocol[pool.ProtocolName] = append(byProtocol[pool.ProtocolName], gin.H{
+			"address": pool.ContractAddress,
+			"fee":     pool.Fee,
+			"token0":  pool.Pair.Token0,
+			"token1":  pool.Pair.Token1,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokenA":    tokenA.String(),
+		"tokenB":    tokenB.String(),
+		"total":     len(known),
+		"protocols": byProtocol,
+	})
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node/nodetest"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/storage"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tokenservice"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/lmittmann/w3"
+)
+
+func TestPoolsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var (
+		tokenA  = common.HexToAddress("0x00000000000000000000000000000000000000e1")
+		tokenB  = common.HexToAddress("0x00000000000000000000000000000000000000e2")
+		lonely  = common.HexToAddress("0x00000000000000000000000000000000000000e3")
+		account = common.HexToAddress("0x00000000000000000000000000000000000000e4")
+		pool    = common.HexToAddress("0x00000000000000000000000000000000000000f1")
+	)
+
+	var uniswapV3 protocols.ProtocolConfig
+	for _, protocol := range protocols.GetUniswapForks() {
+		if protocol.Name == "Uniswap V3" {
+			uniswapV3 = protocol
+		}
+	}
+
+	node := nodetest.New(t)
+	node.Handle(nodetest.AnyContract, w3.MustNewFunc("name()", "string"), nodetest.Returns("Token"))
+	node.Handle(nodetest.AnyContract, w3.MustNewFunc("symbol()", "string"), nodetest.Returns("TKN"))
+	node.Handle(nodetest.AnyContract, w3.MustNewFunc("decimals()", "uint256"), nodetest.Returns(big.NewInt(18)))
+	node.SetNoCode(account)
+	// Only tokenA and tokenB have a pool, on Uniswap V3 at 3000
+	funcGetPool := w3.MustNewFunc("getPool(address,address,uint24)", "address")
+	node.Handle(nodetest.AnyContract, funcGetPool, nodetest.Returns(common.Address{}))
+	node.Handle(uniswapV3.FactoryAddress, funcGetPool, func(args []any) ([]any, error) {
+		pair := []common.Address{args[0].(common.Address), args[1].(common.Address)}
+		if args[2].(*big.Int).Uint64() == 3000 && slices.Contains(pair, tokenA) && slices.Contains(pair, tokenB) {
+			return []any{pool}, nil
+		}
+		return []any{common.Address{}}, nil
+	})
+
+	store := storage.NewMemoryStore()
+	tokenService := tokenservice.NewService(store, node.URL, tokenservice.Config{TTL: time.Hour, MissTTL: time.Hour})
+	aggregatorService := aggregator.NewService(node.URL, tokenService)
+	poolRegistry := pairs.NewPairHandler(store)
+	aggregatorService.SetPoolRegistry(poolRegistry)
+
+	router := gin.New()
+	router.GET("/pools", func(c *gin.Context) { poolsHandler(c, poolRegistry, aggregatorService) })
+	get := func(t *testing.T, a, b common.Address, status int) (total int, protocols map[string][]map[string]any) {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/pools?tokenA="+a.Hex()+"&tokenB="+b.Hex(), nil))
+		if recorder.Code != status {
+			t.Fatalf("status = %d, want %d: %s", recorder.Code, status, recorder.Body)
+		}
+		var response struct {
+			Total     int                         `json:"total"`
+			Protocols map[string][]map[string]any `json:"protocols"`
+		}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		return response.Total, response.Protocols
+	}
+
+	t.Run("discovered on a registry miss", func(t *testing.T) {
+		total, byProtocol := get(t, tokenA, tokenB, http.StatusOK)
+		if total != 1 || len(byProtocol["Uniswap V3"]) != 1 || byProtocol["Uniswap V3"][0]["address"] != pool.Hex() {
+			t.Fatalf("pools = %d %v, want the Uniswap V3 pool", total, byProtocol)
+		}
+		if registered := poolRegistry.GetProtocolPairs(tokenA.Hex(), tokenB.Hex()); len(registered) != 1 {
+			t.Errorf("registry = %+v, want the pool added", registered)
+		}
+	})
+
+	t.Run("registry hit", func(t *testing.T) {
+		before, _ := node.Requests()
+		if total, _ := get(t, tokenB, tokenA, http.StatusOK); total != 1 {
+			t.Fatalf("total = %d, want 1", total)
+		}
+		if after, _ := node.Requests(); after != before {
+			t.Errorf("node requests = %d, want none for a known pair", after-before)
+		}
+	})
+
+	t.Run("no pools", func(t *testing.T) {
+		if total, _ := get(t, tokenA, lonely, http.StatusOK); total != 0 {
+			t.Errorf("total = %d, want 0", total)
+		}
+	})
+
+	t.Run("not a token", func(t *testing.T) {
+		get(t, tokenA, account, http.StatusBadRequest)
+	})
+
+	t.Run("node down", func(t *testing.T) {
+		node.SetDown(true)
+		defer node.SetDown(false)
+		get(t, lonely, common.HexToAddress("0x00000000000000000000000000000000000000e5"), http.StatusBadGateway)
+	})
+}
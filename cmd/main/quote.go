@@ -0,0 +1,617 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/api"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/config"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// quoteErrorStatus is the HTTP status for a failed quote
+func quoteErrorStatus(err error) int {
+	var tokenErr *aggregator.TokenError
+	if errors.As(err, &tokenErr) {
+		return tokenErrorStatus(err)
+	}
+	if errors.Is(err, aggregator.ErrNodeUnavailable) {
+		return http.StatusBadGateway
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// routeRanking reads the rank and limit query parameters
+func routeRanking(c *gin.Context) (aggregator.Ranking, int, bool) {
+	ranking, err := aggregator.GetRanking(c.Query("rank"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid rank parameter: %v", err),
+		})
+		return nil, 0, false
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid limit parameter: must be a positive integer",
+			})
+			return nil, 0, false
+		}
+	}
+	return ranking, limit, true
+}
+
+// defaultQuoteAmount is quoted when no amount is given, in the input token's smallest unit
+const defaultQuoteAmount = "10000"
+
+// quoteError is a failed quote request and the HTTP status to return
+type quoteError struct {
+	status int
+	code   string
+	msg    string
+}
+
+func (e *quoteError) Error() string {
+	return e.msg
+}
+
+// quoteOutcome is a ranked quote and the amount that was quoted
+type quoteOutcome struct {
+	result      *aggregator.AggregatorResult
+	amount      *big.Int // Amount quoted, which may be rounded from the requested amount
+	approximate bool     // amount was rounded
+	cacheStatus string
+}
+
+// quoteParams is a validated quote request, apart from its amount
+type quoteParams struct {
+	tokenIn  common.Address
+	tokenOut common.Address
+	ranking  aggregator.Ranking
+	limit    int
+}
+
+// parseQuote validates everything of a quote request but its amount, which
+// may need the input token's decimals
+func parseQuote(req api.QuoteRequest) (*quoteParams, *quoteError) {
+	if !common.IsHexAddress(req.TokenIn) || !common.IsHexAddress(req.TokenOut) {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidRequest, "Invalid Ethereum address format"}
+	}
+
+	ranking, err := aggregator.GetRanking(req.Rank)
+	if err != nil {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidRequest, fmt.Sprintf("Invalid rank parameter: %v", err)}
+	}
+	if req.Limit < 0 {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidRequest, "Invalid limit parameter: must be a positive integer"}
+	}
+	if req.Amount != "" && req.AmountHuman != "" {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidAmount, "Use either amount or amountHuman, not both"}
+	}
+
+	return &quoteParams{
+		tokenIn:  common.HexToAddress(req.TokenIn),
+		tokenOut: common.HexToAddress(req.TokenOut),
+		ranking:  ranking,
+		limit:    req.Limit,
+	}, nil
+}
+
+// quoteAmount parses the amount of a quote request in the input token's
+// smallest unit. metadataIn is the input token's and only used for amountHuman.
+func quoteAmount(req api.QuoteRequest, metadataIn *models.TokenMetadata) (*big.Int, *quoteError) {
+	if req.AmountHuman != "" {
+		// Whole tokens can't be converted without knowing the decimals
+		if metadataIn.DecimalsInferred() {
+			return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidAmount, fmt.Sprintf("Invalid amountHuman parameter: %s doesn't report its decimals, use amount in its smallest unit", metadataIn.Address)}
+		}
+		amount, err := utils.ParseAmount(req.AmountHuman, metadataIn.Decimals)
+		if err != nil {
+			return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidAmount, fmt.Sprintf("Invalid amountHuman parameter: %v", err)}
+		}
+		return amount, nil
+	}
+
+	amountStr := req.Amount
+	if amountStr == "" {
+		amountStr = defaultQuoteAmount
+	}
+
+	amount, ok := new(big.Int).SetString(amountStr, 10)
+	if !ok {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidAmount, "Invalid amount parameter: must be an integer in the token's smallest unit, use amountHuman for whole tokens"}
+	}
+	if amount.Sign() <= 0 {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidAmount, "Invalid amount parameter: must be greater than zero"}
+	}
+	if !utils.IsUint256(amount) {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidAmount, "Invalid amount parameter: must be less than 2^256"}
+	}
+	return amount, nil
+}
+
+// runQuote validates a quote request and finds the ranked routes for it. It
+// serves both /api/v1/quote and the deprecated /pairs.
+func runQuote(ctx context.Context, aggregatorService *aggregator.Service, req api.QuoteRequest) (*quoteOutcome, *quoteError) {
+	params, failure := parseQuote(req)
+	if failure != nil {
+		return nil, failure
+	}
+
+	// Whole tokens need the input token's decimals
+	var metadataIn *models.TokenMetadata
+	if req.AmountHuman != "" {
+		var err error
+		metadataIn, _, err = aggregatorService.ResolveTokens(ctx, params.tokenIn, params.tokenOut)
+		if err != nil {
+			return nil, quoteFailure(err)
+		}
+	}
+	amount, failure := quoteAmount(req, metadataIn)
+	if failure != nil {
+		return nil, failure
+	}
+
+	// Find the best route across all protocols, resolving both tokens through
+	// the token cache and reusing quotes from the same block
+	result, cacheStatus, err := aggregatorService.CachedQuote(ctx, params.tokenIn, params.tokenOut, amount)
+	if err != nil {
+		return &quoteOutcome{cacheStatus: cacheStatus}, quoteFailure(err)
+	}
+
+	quoted := aggregatorService.QuotedAmount(amount)
+	return &quoteOutcome{
+		result:      result.Ranked(params.ranking, params.limit),
+		amount:      quoted,
+		approximate: quoted.Cmp(amount) != 0,
+		cacheStatus: cacheStatus,
+	}, nil
+}
+
+// quoteFailure converts a quote error to a quoteError
+func quoteFailure(err error) *quoteError {
+	msg := fmt.Sprintf("Failed to find routes: %v", err)
+	status := quoteErrorStatus(err)
+	switch {
+	case errors.Is(err, tokens.ErrNotToken):
+		return &quoteError{status, api.CodeNotAToken, msg}
+	case status == http.StatusBadGateway:
+		return &quoteError{status, api.CodeUpstream, msg}
+	case status == http.StatusGatewayTimeout:
+		return &quoteError{status, api.CodeTimeout, msg}
+	}
+	return &quoteError{status, api.CodeInternal, msg}
+}
+
+// Defi godoc
+// @Summary Get the best quote for a token pair
+// @Description Quotes every supported protocol and returns the routes ranked best first.
+// @Tags quote
+// @Produce json
+// @Param tokenIn query string true "Input token address, 0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE for the native currency"
+// @Param tokenOut query string true "Output token address"
+// @Param amount query string false "Amount in the input token's smallest unit, defaults to 10000"
+// @Param amountHuman query string false "Amount in whole input tokens, e.g. 1.5"
+// @Param rank query string false "Route ranking" Enums(output, gas, fee)
+// @Param limit query int false "Maximum number of routes, 0 returns all"
+// @Success 200 {object} api.QuoteResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 401 {object} api.ErrorResponse
+// @Failure 403 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Failure 502 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/quote [get]
+func quoteHandler(c *gin.Context, aggregatorService *aggregator.Service) {
+	var req api.QuoteRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, api.NewErrorResponse(api.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	outcome, failure := runQuote(ctx, aggregatorService, req)
+	if outcome != nil {
+		c.Header("Cache-Status", outcome.cacheStatus)
+	}
+	if failure != nil {
+		c.JSON(failure.status, api.NewErrorResponse(failure.code, failure.msg))
+		return
+	}
+
+	response, failure := outcome.response()
+	if failure != nil {
+		c.JSON(failure.status, api.NewErrorResponse(failure.code, failure.msg))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// response builds the API response of a quote, failing if no route was found
+func (o *quoteOutcome) response() (*api.QuoteResponse, *quoteError) {
+	result := o.result
+	if len(result.AllRoutes) == 0 {
+		return nil, &quoteError{http.StatusNotFound, api.CodeNoRoute, "No route found for this pair"}
+	}
+
+	return &api.QuoteResponse{
+		TokenIn:     api.NewToken(result.TokenIn),
+		TokenOut:    api.NewToken(result.TokenOut),
+		AmountIn:    utils.NewAmount(o.amount, result.TokenIn.Decimals),
+		Approximate: o.approximate,
+		BestRoute:   result.BestRoute,
+		Routes:      result.AllRoutes,
+		Risk:        api.Risk{TokenIn: result.TokenInRisk, TokenOut: result.TokenOutRisk},
+	}, nil
+}
+
+// Defi godoc
+// @Summary Get quotes for many pairs and amounts
+// @Description Quotes every request in one pass, sharing token metadata and pool discovery and batching RPC calls. Requests take the same fields as /api/v1/quote. Each result holds a quote or an error; requests not quoted before the batch deadline fail with a timeout error.
+// @Tags quote
+// @Accept json
+// @Produce json
+// @Param request body api.BatchQuoteRequest true "Quote requests"
+// @Success 200 {object} api.BatchQuoteResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /quotes/batch [post]
+func quoteBatchHandler(c *gin.Context, aggregatorService *aggregator.Service) {
+	var request api.BatchQuoteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, api.NewErrorResponse(api.CodeInvalidRequest, fmt.Sprintf("Invalid request format: %v", err)))
+		return
+	}
+
+	cfg := config.GetConfig()
+	if len(request.Quotes) == 0 || uint64(len(request.Quotes)) > cfg.QuoteBatchLimit {
+		c.JSON(http.StatusBadRequest, api.NewErrorResponse(api.CodeInvalidRequest, fmt.Sprintf("Invalid request: between 1 and %d quotes are required", cfg.QuoteBatchLimit)))
+		return
+	}
+
+	// One deadline covers the whole batch
+	ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.QuoteBatchTimeout)
+	defer cancel()
+
+	failures := make([]*quoteError, len(request.Quotes))
+	params := make([]*quoteParams, len(request.Quotes))
+	for i, req := range request.Quotes {
+		params[i], failures[i] = parseQuote(req)
+	}
+
+	// Whole-token amounts need the decimals of their input tokens, which are
+	// resolved together
+	var humanTokens []common.Address
+	for i, req := range request.Quotes {
+		if failures[i] == nil && req.AmountHuman != "" {
+			humanTokens = append(humanTokens, params[i].tokenIn)
+		}
+	}
+	metadataIn := make(map[common.Address]*models.TokenMetadata)
+	tokenFailures := make(map[common.Address]*quoteError)
+	if len(humanTokens) > 0 {
+		metadata, errs, err := aggregatorService.ResolveBatch(ctx, humanTokens)
+		for i, token := range humanTokens {
+			switch {
+			case err != nil:
+				tokenFailures[token] = quoteFailure(err)
+			case errs[i] != nil:
+				tokenFailures[token] = quoteFailure(&aggregator.TokenError{Side: "tokenIn", Address: token, Err: errs[i]})
+			default:
+				metadataIn[token] = metadata[i]
+			}
+		}
+	}
+
+	amounts := make([]*big.Int, len(request.Quotes))
+	var requests []aggregator.BatchRequest
+	var indexes []int
+	for i, req := range request.Quotes {
+		if failures[i] != nil {
+			continue
+		}
+		if req.AmountHuman != "" && tokenFailures[params[i].tokenIn] != nil {
+			failures[i] = tokenFailures[params[i].tokenIn]
+			continue
+		}
+		amounts[i], failures[i] = quoteAmount(req, metadataIn[params[i].tokenIn])
+		if failures[i] != nil {
+			continue
+		}
+		requests = append(requests, aggregator.BatchRequest{
+			TokenIn:  params[i].tokenIn,
+			TokenOut: params[i].tokenOut,
+			AmountIn: amounts[i],
+		})
+		indexes = append(indexes, i)
+	}
+
+	responses := make([]*api.QuoteResponse, len(request.Quotes))
+	for j, result := range aggregatorService.QuoteBatch(ctx, requests) {
+		i := indexes[j]
+		if result.Err != nil {
+			failures[i] = quoteFailure(result.Err)
+			continue
+		}
+		outcome := &quoteOutcome{result: result.Result.Ranked(params[i].ranking, params[i].limit), amount: amounts[i]}
+		responses[i], failures[i] = outcome.response()
+	}
+
+	response := api.BatchQuoteResponse{Results: make([]api.BatchQuoteResult, len(request.Quotes))}
+	for i := range request.Quotes {
+		if failures[i] != nil {
+			response.Results[i].Error = &api.Error{Code: failures[i].code, Message: failures[i].msg}
+			response.Failed++
+			continue
+		}
+		response.Results[i].Quote = responses[i]
+		response.Succeeded++
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// maxDepthSizes bounds the input sizes of one /depth request
+const maxDepthSizes = 50
+
+// Defi godoc
+// @Summary Get the liquidity curve of a pair
+// @Description Quotes a pair at a log-spaced ladder of input sizes from min to max, or at the sizes listed in amounts, and returns the output, price and price impact of the best route and of each protocol's best route at every size. Price impact is measured against the price at the smallest size.
+// @Tags quote
+// @Produce json
+// @Param tokena query string true "Input token address"
+// @Param tokenb query string true "Output token address"
+// @Param amounts query string false "Comma-separated sizes in whole input tokens, replaces the ladder"
+// @Param min query string false "Smallest size in whole input tokens, defaults to 0.01"
+// @Param max query string false "Largest size in whole input tokens, defaults to 100000"
+// @Param steps query int false "Number of sizes in the ladder, defaults to 15"
+// @Success 200 {string} Output, price and price impact per size and protocol
+// @Security ApiKeyAuth
+// @Router /depth [get]
+func depthHandler(c *gin.Context, aggregatorService *aggregator.Service) {
+	tokenAAddress := c.Query("tokena")
+	tokenBAddress := c.Query("tokenb")
+	if !common.IsHexAddress(tokenAAddress) || !common.IsHexAddress(tokenBAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing or invalid parameters: tokena and tokenb must be token addresses",
+		})
+		return
+	}
+	tokenA := common.HexToAddress(tokenAAddress)
+	tokenB := common.HexToAddress(tokenBAddress)
+
+	steps, err := strconv.Atoi(c.DefaultQuery("steps", "15"))
+	if err != nil || steps < 2 || steps > maxDepthSizes {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid steps parameter: must be between 2 and %d", maxDepthSizes),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), config.GetConfig().QuoteBatchTimeout)
+	defer cancel()
+
+	// Sizes are given in whole tokens
+	metadataIn, _, err := aggregatorService.ResolveTokens(ctx, tokenA, tokenB)
+	if err != nil {
+		c.JSON(quoteErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("Failed to find routes: %v", err),
+		})
+		return
+	}
+
+	if metadataIn.DecimalsInferred() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Token %s doesn't report its decimals, so sizes in whole tokens can't be converted", metadataIn.Address),
+		})
+		return
+	}
+
+	var amounts []*big.Int
+	if list := c.Query("amounts"); list != "" {
+		for _, size := range strings.Split(list, ",") {
+			amount, err := utils.ParseAmount(strings.TrimSpace(size), metadataIn.Decimals)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("Invalid amounts parameter: %v", err),
+				})
+				return
+			}
+			amounts = append(amounts, amount)
+		}
+		if len(amounts) > maxDepthSizes {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid amounts parameter: at most %d sizes", maxDepthSizes),
+			})
+			return
+		}
+
+		// Price impact is measured from the smallest size up
+		slices.SortFunc(amounts, func(a, b *big.Int) int { return a.Cmp(b) })
+		amounts = slices.CompactFunc(amounts, func(a, b *big.Int) bool { return a.Cmp(b) == 0 })
+	} else {
+		low, err := utils.ParseAmount(c.DefaultQuery("min", "0.01"), metadataIn.Decimals)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid min parameter: %v", err),
+			})
+			return
+		}
+		high, err := utils.ParseAmount(c.DefaultQuery("max", "100000"), metadataIn.Decimals)
+		if err != nil || high.Cmp(low) <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid max parameter: must be an amount greater than min",
+			})
+			return
+		}
+		amounts = aggregator.DepthLadder(low, high, steps)
+	}
+
+	depth, err := aggregatorService.Depth(ctx, tokenA, tokenB, amounts)
+	if err != nil {
+		c.JSON(quoteErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("Failed to find routes: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokenIn":        api.NewToken(depth.TokenIn),
+		"tokenOut":       api.NewToken(depth.TokenOut),
+		"referencePrice": depth.ReferencePrice,
+		"points":         depth.Points,
+	})
+}
+
+// Defi godoc
+// @Summary Get the best quote for a token pair
+// @Description Deprecated, use /api/v1/quote. The response shape depends on all.
+// @Tags quote
+// @Deprecated
+// @Param tokena query string true "Input token address"
+// @Param tokenb query string true "Output token address"
+// @Param amount query string false "Amount in the input token's smallest unit, defaults to 10000"
+// @Param amountHuman query string false "Amount in whole input tokens, e.g. 1.5"
+// @Param all query bool false "Return every route instead of only the best"
+// @Param rank query string false "Route ranking" Enums(output, gas, fee)
+// @Param limit query int false "Maximum number of routes returned with all"
+// @Success 200 {string} The best route and the amount quoted
+// @Security ApiKeyAuth
+// @Router /pairs [get]
+func pairHandler(c *gin.Context, aggregatorService *aggregator.Service) {
+	// Point clients at the versioned endpoint
+	c.Header("Deprecation", "true")
+	c.Header("Link", `</api/v1/quote>; rel="successor-version"`)
+
+	// Get token addresses from query parameters
+	tokenAAddress := c.Query("tokena")
+	if tokenAAddress == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing required parameter: tokena",
+		})
+		return
+	}
+	
+	tokenBAddress := c.Query("tokenb")
+	if tokenBAddress == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing required parameter: tokenb",
+		})
+		return
+	}
+	
+	// Option to return all routes or just the best
+	showAllRoutes := c.DefaultQuery("all", "false") == "true"
+	
+	req := api.QuoteRequest{
+		TokenIn:     tokenAAddress,
+		TokenOut:    tokenBAddress,
+		Amount:      c.Query("amount"),
+		AmountHuman: c.Query("amountHuman"),
+		Rank:        c.Query("rank"),
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid limit parameter: must be a positive integer",
+			})
+			return
+		}
+		req.Limit = limit
+	}
+	
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+	
+	outcome, failure := runQuote(ctx, aggregatorService, req)
+	if outcome != nil {
+		c.Header("Cache-Status", outcome.cacheStatus)
+	}
+	if failure != nil {
+		c.JSON(failure.status, gin.H{
+			"error": failure.msg,
+		})
+		return
+	}
+	result := outcome.result
+	
+	// Log token information
+	logger.DebugContext(c.Request.Context(), "Quoted pair",
+		"tokenA", result.TokenIn.Symbol,
+		"tokenADecimals", result.TokenIn.Decimals,
+		"tokenB", result.TokenOut.Symbol,
+		"tokenBDecimals", result.TokenOut.Decimals,
+	)
+	
+	// Echo the requested amount in both units
+	amountIn := utils.NewAmount(outcome.amount, result.TokenIn.Decimals)
+	
+	// Return the result
+	if showAllRoutes {
+		// Return all routes
+		c.JSON(http.StatusOK, gin.H{
+			"amount":    amountIn,
+			"bestRoute": result.BestRoute,
+			"allRoutes": result.AllRoutes,
+			"risk":      tokenRisk(result),
+			"tokens":    tokenPair(result),
+		})
+	} else {
+		// Return only the best route
+		c.JSON(http.StatusOK, gin.H{
+			"amount": amountIn,
+			"result": result.BestRoute,
+			"risk":   tokenRisk(result),
+			"tokens": tokenPair(result),
+		})
+	}
+}
+
+// tokenPair returns the metadata of both tokens of a quote, including any inferred fields
+func tokenPair(result *aggregator.AggregatorResult) gin.H {
+	// Screening is returned separately under "risk"
+	summary := func(metadata *models.TokenMetadata) models.TokenMetadata {
+		return models.TokenMetadata{
+			Address:  metadata.Address,
+			Name:     metadata.Name,
+			Symbol:   metadata.Symbol,
+			Decimals: metadata.Decimals,
+			Inferred: metadata.Inferred,
+			LogoURI:  metadata.LogoURI,
+		}
+	}
+	return gin.H{
+		"tokenIn":  summary(result.TokenIn),
+		"tokenOut": summary(result.TokenOut),
+	}
+}
+
+// tokenRisk returns the risk screening of both tokens of a quote
+func tokenRisk(result *aggregator.AggregatorResult) gin.H {
+	return gin.H{
+		"tokenIn":  result.TokenInRisk,
+		"tokenOut": result.TokenOutRisk,
+	}
+}
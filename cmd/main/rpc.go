@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/api"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/rpc"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/stream"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tokenservice"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// streamQuote quotes a streaming subscription like /api/v1/quote
+func streamQuote(aggregatorService *aggregator.Service) stream.QuoteFunc {
+	return func(ctx context.Context, req api.QuoteRequest) (*api.QuoteResponse, *api.Error) {
+		outcome, failure := runQuote(ctx, aggregatorService, req)
+		if failure == nil {
+			var response *api.QuoteResponse
+			if response, failure = outcome.response(); failure == nil {
+				return response, nil
+			}
+		}
+		return nil, &api.Error{Code: failure.code, Message: failure.msg}
+	}
+}
+
+// rpcToken looks up token metadata for the gRPC API like GET /token
+func rpcToken(tokenService *tokenservice.Service, aggregatorService *aggregator.Service) rpc.TokenFunc {
+	return func(ctx context.Context, address string) (*models.TokenMetadata, string, *api.Error) {
+		if !common.IsHexAddress(address) {
+			return nil, "", &api.Error{Code: api.CodeInvalidRequest, Message: "Invalid Ethereum address format"}
+		}
+
+		metadata, source, err := lookupToken(ctx, tokenService, aggregatorService, common.HexToAddress(address))
+		if err != nil {
+			code := api.CodeUpstream
+			if errors.Is(err, tokens.ErrNotToken) {
+				code = api.CodeNotAToken
+			}
+			return nil, "", &api.Error{Code: code, Message: fmt.Sprintf("Failed to fetch token metadata: %v", err)}
+		}
+		return metadata, source, nil
+	}
+}
+
+// rpcSwap builds swaps for the gRPC API like GET /swap
+func rpcSwap(aggregatorService *aggregator.Service) rpc.SwapFunc {
+	return func(ctx context.Context, req api.SwapRequest) (*api.SwapResponse, *api.Error) {
+		response, failure := runSwap(ctx, aggregatorService, req)
+		if failure != nil {
+			return nil, &api.Error{Code: failure.code, Message: failure.msg}
+		}
+		return response, nil
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/api"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/stream"
+	"github.com/gin-gonic/gin"
+)
+
+// Defi godoc
+// @Summary Stream quotes over a WebSocket
+// @Description Send {"type":"subscribe","id":"...","tokenIn":"...","tokenOut":"...","amount":"...","updates":"block|change"} to receive a quote message every new block, or only when the best route changes, and {"type":"unsubscribe","id":"..."} to stop.
+// @Tags quote
+// @Success 101 {object} api.StreamMessage
+// @Failure 503 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/quote/ws [get]
+func quoteWebSocketHandler(c *gin.Context, streamService *stream.Service) {
+	streamService.ServeWebSocket(c.Writer, c.Request)
+}
+
+// Defi godoc
+// @Summary Stream quotes as server-sent events
+// @Description Sends a quote event every new block, or only when the best route changes with updates=change. Failures are sent as error events.
+// @Tags quote
+// @Produce text/event-stream
+// @Param tokenIn query string true "Input token address"
+// @Param tokenOut query string true "Output token address"
+// @Param amount query string false "Amount in the input token's smallest unit, defaults to 10000"
+// @Param amountHuman query string false "Amount in whole input tokens, e.g. 1.5"
+// @Param rank query string false "Route ranking" Enums(output, gas, fee)
+// @Param limit query int false "Maximum number of routes, 0 returns all"
+// @Param updates query string false "When to send a quote" Enums(block, change)
+// @Success 200 {object} api.StreamMessage
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 503 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/quote/stream [get]
+func quoteSSEHandler(c *gin.Context, streamService *stream.Service) {
+	var req api.QuoteRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, api.NewErrorResponse(api.CodeInvalidRequest, fmt.Sprintf("Invalid request: %v", err)))
+		return
+	}
+
+	updates := c.DefaultQuery("updates", api.UpdatesBlock)
+	if updates != api.UpdatesBlock && updates != api.UpdatesChange {
+		c.JSON(http.StatusBadRequest, api.NewErrorResponse(api.CodeInvalidRequest, "Invalid updates parameter: must be block or change"))
+		return
+	}
+
+	streamService.ServeSSE(c.Writer, c.Request, req, updates)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/api"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/swap"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// swapHandler builds an unsigned transaction for the best route.
+// Native input (0xEeee...EEeE) is sent as the transaction value and native
+// output is unwrapped to the recipient by the router.
+//
+// Defi godoc
+// @Summary Build a swap transaction
+// @Description Quotes the pair and builds an unsigned transaction for the best route through a protocol with a swap router configured in SWAP_ROUTERS.
+// @Tags swap
+// @Produce json
+// @Param tokena query string true "Input token address, 0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE for the native currency"
+// @Param tokenb query string true "Output token address"
+// @Param amount query string true "Amount in the input token's smallest unit"
+// @Param recipient query string true "Address that receives the output"
+// @Param slippage query int false "Slippage tolerance in basis points, defaults to 50"
+// @Param rank query string false "Route ranking" Enums(output, gas, fee)
+// @Success 200 {string} The route, minimum output, transaction and token risk
+// @Security ApiKeyAuth
+// @Router /swap [get]
+func swapHandler(c *gin.Context, aggregatorService *aggregator.Service) {
+	// Slippage tolerance in basis points
+	slippage, err := strconv.ParseUint(c.DefaultQuery("slippage", "50"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid slippage parameter, expected basis points between 0 and 10000",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	response, failure := runSwap(ctx, aggregatorService, api.SwapRequest{
+		TokenIn:     c.Query("tokena"),
+		TokenOut:    c.Query("tokenb"),
+		Amount:      c.Query("amount"),
+		Recipient:   c.Query("recipient"),
+		SlippageBps: slippage,
+		Rank:        c.Query("rank"),
+	})
+	if failure != nil {
+		c.JSON(failure.status, gin.H{
+			"error": failure.msg,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"route":            response.Route,
+		"amountOutMinimum": response.AmountOutMinimum,
+		"transaction":      response.Transaction,
+		"risk":             response.Risk,
+	})
+}
+
+// runSwap validates a swap request and builds the transaction of the best
+// route. It serves both /swap and the gRPC API.
+func runSwap(ctx context.Context, aggregatorService *aggregator.Service, req api.SwapRequest) (*api.SwapResponse, *quoteError) {
+	if req.TokenIn == "" || req.TokenOut == "" || req.Recipient == "" {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidRequest, "Missing required parameters: tokena, tokenb and recipient"}
+	}
+	if !common.IsHexAddress(req.TokenIn) || !common.IsHexAddress(req.TokenOut) || !common.IsHexAddress(req.Recipient) {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidRequest, "Invalid Ethereum address format"}
+	}
+
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok || amount.Sign() <= 0 || !utils.IsUint256(amount) {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidAmount, "Invalid amount parameter: must be an integer between 1 and 2^256 - 1"}
+	}
+
+	ranking, err := aggregator.GetRanking(req.Rank)
+	if err != nil {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidRequest, fmt.Sprintf("Invalid rank parameter: %v", err)}
+	}
+
+	if req.SlippageBps > 10000 {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidRequest, "Invalid slippage parameter, expected basis points between 0 and 10000"}
+	}
+	slippage := new(big.Int).SetUint64(req.SlippageBps)
+
+	tokenA := common.HexToAddress(req.TokenIn)
+	tokenB := common.HexToAddress(req.TokenOut)
+
+	result, err := aggregatorService.Quote(ctx, tokenA, tokenB, amount)
+	if err != nil {
+		return nil, quoteFailure(err)
+	}
+	result = result.Ranked(ranking, 0)
+
+	if len(result.AllRoutes) == 0 {
+		return nil, &quoteError{http.StatusNotFound, api.CodeNoRoute, "No route found for this pair"}
+	}
+
+	// Swap through the best route whose protocol has a swap router
+	index := slices.IndexFunc(result.AllRoutes, func(route aggregator.RouteQuote) bool { return route.Router != "" })
+	if index < 0 {
+		return nil, &quoteError{http.StatusNotFound, api.CodeNoRoute, "No route found through a protocol with a swap router"}
+	}
+	route := result.AllRoutes[index]
+
+	// Apply slippage to the quoted output
+	amountOut := route.AmountOut.Raw()
+	amountOutMinimum := new(big.Int).Mul(amountOut, new(big.Int).Sub(big.NewInt(10000), slippage))
+	amountOutMinimum.Div(amountOutMinimum, big.NewInt(10000))
+
+	tx, err := swap.Build(swap.Request{
+		Route:            route,
+		TokenIn:          tokenA,
+		TokenOut:         tokenB,
+		AmountIn:         amount,
+		AmountOutMinimum: amountOutMinimum,
+		Recipient:        common.HexToAddress(req.Recipient),
+		Deadline:         big.NewInt(time.Now().Add(20 * time.Minute).Unix()),
+	})
+	if err != nil {
+		return nil, &quoteError{http.StatusBadRequest, api.CodeInvalidRequest, fmt.Sprintf("Failed to build swap: %v", err)}
+	}
+
+	return &api.SwapResponse{
+		Route:            route,
+		AmountOutMinimum: utils.NewAmount(amountOutMinimum, result.TokenOut.Decimals),
+		Transaction:      tx,
+		Risk:             api.Risk{TokenIn: result.TokenInRisk, TokenOut: result.TokenOutRisk},
+	}, nil
+}
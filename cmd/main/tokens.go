@@ -0,0 +1,535 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/config"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/storage"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tokenlist"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tokenservice"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// Limits for the /tokens listing
+const (
+	defaultTokenListLimit = 100
+	maxTokenListLimit     = 1000
+)
+
+// importTokenList validates a token list against the chain and stores its
+// tokens in the registry, keeping any screening already cached for them
+func importTokenList(ctx context.Context, store storage.Store, list *tokenlist.List, cfg config.Config) (int, []tokenlist.Rejection, error) {
+	entries, rejected, err := tokenlist.Verify(ctx, list, cfg.ChainID, cfg.NodeURL)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to verify token list: %v", err)
+	}
+	if len(entries) == 0 {
+		return 0, rejected, nil
+	}
+
+	// Load the existing records to keep their screening
+	addresses := make([]common.Address, len(entries))
+	for i, entry := range entries {
+		addresses[i] = entry.Address
+	}
+	existing, err := store.GetTokens(ctx, addresses)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	registry := make([]models.TokenMetadata, len(entries))
+	for i, entry := range entries {
+		registry[i] = models.TokenMetadata{
+			Address:  entry.Address.String(),
+			Name:     entry.Token.Name,
+			Symbol:   entry.Token.Symbol,
+			Decimals: entry.Token.Decimals,
+			LogoURI:  entry.Token.LogoURI,
+			Tags:     entry.Token.Tags,
+			Warnings: entry.Warnings,
+			Curated:  true,
+		}
+		if cached := existing[i]; cached != nil {
+			registry[i].Behaviour = cached.Behaviour
+			registry[i].Risk = cached.Risk
+		}
+	}
+	if err := store.SaveRegistryTokens(ctx, registry); err != nil {
+		return 0, nil, err
+	}
+
+	logger.InfoContext(ctx, "Imported token list", "list", list.Name, "version", list.Version.String(), "tokens", len(entries), "rejected", len(rejected))
+	return len(entries), rejected, nil
+}
+
+// searchTokens filters tokens by a symbol, name or address query and a tag.
+// Exact symbol matches come first, then symbol prefixes, then the rest, each
+// ordered by symbol.
+func searchTokens(registry []models.TokenMetadata, query, tag string) []models.TokenMetadata {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	rank := func(metadata models.TokenMetadata) int {
+		symbol := strings.ToLower(metadata.Symbol)
+		switch {
+		case query == "" || symbol == query || strings.ToLower(metadata.Address) == query:
+			return 0
+		case strings.HasPrefix(symbol, query):
+			return 1
+		case strings.Contains(strings.ToLower(metadata.Name), query) || strings.Contains(symbol, query):
+			return 2
+		}
+		return -1
+	}
+
+	var matches []models.TokenMetadata
+	for _, metadata := range registry {
+		if tag != "" && !slices.Contains(metadata.Tags, tag) {
+			continue
+		}
+		if rank(metadata) < 0 {
+			continue
+		}
+		matches = append(matches, metadata)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		ri, rj := rank(matches[i]), rank(matches[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return strings.ToLower(matches[i].Symbol) < strings.ToLower(matches[j].Symbol)
+	})
+
+	return matches
+}
+
+// loadTokenList imports the configured token list into the registry
+func loadTokenList(store storage.Store, cfg config.Config) {
+	ctx := context.Background()
+
+	list, err := tokenlist.Fetch(ctx, cfg.TokenListURL, cfg.TokenListPath, cfg.TokenListOffline)
+	if err != nil {
+		logger.Error("Failed to load token list", "error", err)
+		return
+	}
+
+	if _, _, err := importTokenList(ctx, store, list, cfg); err != nil {
+		logger.Error("Failed to import token list", "error", err)
+	}
+}
+
+// lookupToken gets token metadata through the token service. Cached
+// screening is shared with the quoting service, tokens read from the chain are
+// screened and cached with the result.
+func lookupToken(ctx context.Context, tokenService *tokenservice.Service, aggregatorService *aggregator.Service, tokenAddress common.Address) (*models.TokenMetadata, string, error) {
+	metadata, source, err := tokenService.Get(ctx, tokenAddress)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if source == tokenservice.SourceCache {
+		aggregatorService.SetTokenBehaviour(tokenAddress, metadata.Behaviour)
+		aggregatorService.SetTokenRisk(tokenAddress, metadata.Risk)
+		return metadata, source, nil
+	}
+
+	screenToken(ctx, aggregatorService, metadata)
+	return metadata, source, nil
+}
+
+// screenToken detects the fee-on-transfer and rebasing behaviour and risk of
+// a token. The aggregator caches the result and saves it with the token.
+func screenToken(ctx context.Context, aggregatorService *aggregator.Service, metadata *models.TokenMetadata) {
+	behaviour, report, err := aggregatorService.ScreenToken(ctx, common.HexToAddress(metadata.Address))
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to screen token", "token", metadata.Address, "error", err)
+		// Continue without screening, it will be screened again when quoted
+		return
+	}
+	metadata.Behaviour, metadata.Risk = behaviour, report
+}
+
+// tokenErrorStatus is the HTTP status for a failed token lookup
+func tokenErrorStatus(err error) int {
+	if errors.Is(err, tokens.ErrNotToken) {
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
+
+// tokenPostHandler handles the /token POST endpoint
+//
+// Defi godoc
+// @Summary Add a token
+// @Description Reads a token's metadata from the chain if it isn't cached, caches it and screens it. Needs the token:write scope.
+// @Tags token
+// @Accept json
+// @Produce json
+// @Param request body models.TokenRequest true "Token address"
+// @Success 200 {string} The token metadata and whether it was cached
+// @Security ApiKeyAuth
+// @Router /token [post]
+func tokenPostHandler(c *gin.Context, tokenService *tokenservice.Service, aggregatorService *aggregator.Service) {
+	// Parse request body
+	var request models.TokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid request format: %v", err),
+		})
+		return
+	}
+	
+	// Validate Ethereum address
+	if !common.IsHexAddress(request.Address) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid Ethereum address format",
+		})
+		return
+	}
+	
+	tokenAddress := common.HexToAddress(request.Address)
+	
+	// Read through the token cache
+	ctx := c.Request.Context()
+	metadata, source, err := lookupToken(ctx, tokenService, aggregatorService, tokenAddress)
+	if err != nil {
+		c.JSON(tokenErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("Failed to fetch token metadata: %v", err),
+		})
+		return
+	}
+	
+	if source == tokenservice.SourceCache {
+		// Return existing metadata if found
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Token metadata retrieved from cache",
+			"token":   metadata,
+		})
+		return
+	}
+	
+	// Return the metadata
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Token metadata fetched and saved",
+		"token":   metadata,
+	})
+}
+
+// Defi godoc
+// @Summary Get token metadata
+// @Tags token
+// @Produce json
+// @Param address query string true "Token address"
+// @Success 200 {string} The token metadata and where it was found
+// @Security ApiKeyAuth
+// @Router /token [get]
+func tokenGetHandler(c *gin.Context, tokenService *tokenservice.Service, aggregatorService *aggregator.Service) {
+	// Get token address from query parameter
+	address := c.Query("address")
+	if address == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing required parameter: address",
+		})
+		return
+	}
+	
+	// Validate Ethereum address
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid Ethereum address format",
+		})
+		return
+	}
+	
+	tokenAddress := common.HexToAddress(address)
+	
+	// Read through the token cache
+	ctx := c.Request.Context()
+	metadata, source, err := lookupToken(ctx, tokenService, aggregatorService, tokenAddress)
+	if err != nil {
+		c.JSON(tokenErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("Failed to fetch token metadata: %v", err),
+		})
+		return
+	}
+	
+	// Return the metadata
+	c.JSON(http.StatusOK, gin.H{
+		"source": source,
+		"token":  metadata,
+	})
+}
+
+// tokenInvalidateHandler handles the /token DELETE endpoint, removing a token
+// from the cache so the next lookup reads it from the chain
+//
+// Defi godoc
+// @Summary Remove a token from the cache
+// @Description The next lookup reads the token from the chain again. Needs the token:write scope.
+// @Tags token
+// @Produce json
+// @Param address query string true "Token address"
+// @Success 200 {string} The invalidated address
+// @Security ApiKeyAuth
+// @Router /token [delete]
+func tokenInvalidateHandler(c *gin.Context, tokenService *tokenservice.Service, aggregatorService *aggregator.Service) {
+	address := c.Query("address")
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid Ethereum address format",
+		})
+		return
+	}
+	tokenAddress := common.HexToAddress(address)
+
+	if err := tokenService.Invalidate(c.Request.Context(), tokenAddress); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Failed to invalidate token", "token", tokenAddress.Hex(), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to invalidate token",
+		})
+		return
+	}
+	aggregatorService.ForgetToken(tokenAddress)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Token metadata invalidated",
+		"address": tokenAddress.String(),
+	})
+}
+
+// tokenRefreshHandler handles the /token/refresh POST endpoint, reading a
+// token from the chain again and screening it
+//
+// Defi godoc
+// @Summary Refresh a token
+// @Description Reads a token's metadata from the chain again, keeping token list fields, and screens it again. Needs the token:write scope.
+// @Tags token
+// @Accept json
+// @Produce json
+// @Param request body models.TokenRequest true "Token address"
+// @Success 200 {string} The refreshed token metadata
+// @Security ApiKeyAuth
+// @Router /token/refresh [post]
+func tokenRefreshHandler(c *gin.Context, tokenService *tokenservice.Service, aggregatorService *aggregator.Service) {
+	// Parse request body
+	var request models.TokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid request format: %v", err),
+		})
+		return
+	}
+	if !common.IsHexAddress(request.Address) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid Ethereum address format",
+		})
+		return
+	}
+	tokenAddress := common.HexToAddress(request.Address)
+
+	ctx := c.Request.Context()
+	aggregatorService.ForgetToken(tokenAddress)
+	metadata, err := tokenService.Refresh(ctx, tokenAddress)
+	if err != nil {
+		c.JSON(tokenErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("Failed to fetch token metadata: %v", err),
+		})
+		return
+	}
+	screenToken(ctx, aggregatorService, metadata)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Token metadata refreshed",
+		"token":   metadata,
+	})
+}
+
+// Defi godoc
+// @Summary List and search the token registry
+// @Param q query string false "Symbol, name or address to search for"
+// @Param tag query string false "Only tokens with this tag"
+// @Param limit query int false "Maximum number of tokens"
+// @Success 200 {string} List of tokens
+// @Security ApiKeyAuth
+// @Router /tokens [get]
+func tokensHandler(c *gin.Context, store storage.Store) {
+	limit := defaultTokenListLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > maxTokenListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid limit: must be between 1 and %d", maxTokenListLimit),
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	registry, err := store.GetRegistryTokens(c.Request.Context())
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Failed to get token registry", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get token registry",
+		})
+		return
+	}
+
+	matches := searchTokens(registry, c.Query("q"), c.Query("tag"))
+	total := len(matches)
+	if total > limit {
+		matches = matches[:limit]
+	}
+	if matches == nil {
+		matches = []models.TokenMetadata{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":  total,
+		"tokens": matches,
+	})
+}
+
+// tokenImportHandler handles the /tokens/import POST endpoint
+//
+// Defi godoc
+// @Summary Import the configured token list
+// @Description Imports the list at TOKEN_LIST_URL or TOKEN_LIST_PATH into the token registry. Needs the admin scope.
+// @Tags token
+// @Produce json
+// @Success 200 {string} The list, the number of tokens imported and the rejected entries
+// @Failure 502 {string} The token list couldn't be loaded
+// @Security ApiKeyAuth
+// @Router /tokens/import [post]
+func tokenImportHandler(c *gin.Context, store storage.Store) {
+	cfg := config.GetConfig()
+	ctx := c.Request.Context()
+
+	// Only the configured list is imported, the error is logged rather than
+	// returned so it doesn't reveal the server's files
+	list, err := tokenlist.Fetch(ctx, cfg.TokenListURL, cfg.TokenListPath, cfg.TokenListOffline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to load token list", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": "Failed to load the configured token list",
+		})
+		return
+	}
+
+	imported, rejected, err := importTokenList(ctx, store, list, cfg)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to import token list", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to import token list",
+		})
+		return
+	}
+	if rejected == nil {
+		rejected = []tokenlist.Rejection{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  fmt.Sprintf("Imported %d tokens from %s", imported, list.Name),
+		"list":     gin.H{"name": list.Name, "version": list.Version.String(), "timestamp": list.Timestamp},
+		"imported": imported,
+		"rejected": rejected,
+	})
+}
+
+// TokenBatchRequest defines the structure for the token batch post request
+type TokenBatchRequest struct {
+	Addresses []string `json:"addresses" binding:"required"`
+}
+
+// TokenBatchError reports why the metadata of one address couldn't be returned
+type TokenBatchError struct {
+	Address string `json:"address"`
+	Error   string `json:"error"`
+}
+
+// Defi godoc
+// @Summary Get the metadata of many tokens
+// @Description Cached tokens are read with one Redis MGET and the rest from the node in one JSON-RPC batch, then cached with one pipeline. Up to TOKEN_BATCH_LIMIT addresses; addresses that fail are listed in errors.
+// @Param request body TokenBatchRequest true "Token addresses"
+// @Success 200 {string} Token metadata and per-address errors
+// @Security ApiKeyAuth
+// @Router /tokens/batch [post]
+func tokenBatchHandler(c *gin.Context, tokenService *tokenservice.Service, aggregatorService *aggregator.Service) {
+	// Parse request body
+	var request TokenBatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid request format: %v", err),
+		})
+		return
+	}
+
+	cfg := config.GetConfig()
+	if len(request.Addresses) == 0 || uint64(len(request.Addresses)) > cfg.TokenBatchLimit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid request: between 1 and %d addresses are required", cfg.TokenBatchLimit),
+		})
+		return
+	}
+
+	// Validate and de-duplicate the addresses
+	var (
+		addresses []common.Address
+		errs      = []TokenBatchError{}
+		seen      = make(map[common.Address]bool)
+	)
+	for _, address := range request.Addresses {
+		if !common.IsHexAddress(address) {
+			errs = append(errs, TokenBatchError{Address: address, Error: "Invalid Ethereum address format"})
+			continue
+		}
+		tokenAddress := common.HexToAddress(address)
+		if seen[tokenAddress] {
+			continue
+		}
+		seen[tokenAddress] = true
+		addresses = append(addresses, tokenAddress)
+	}
+
+	// Resolve cache hits in one round trip and misses from the chain in one JSON-RPC batch
+	ctx := c.Request.Context()
+	batch, sources, batchErrs, err := tokenService.GetBatch(ctx, addresses)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to fetch token metadata", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": "Failed to fetch token metadata from the node",
+		})
+		return
+	}
+
+	found := make([]*models.TokenMetadata, 0, len(batch))
+	cacheHit := 0
+	for i, metadata := range batch {
+		if batchErrs[i] != nil {
+			errs = append(errs, TokenBatchError{Address: addresses[i].String(), Error: batchErrs[i].Error()})
+			continue
+		}
+		if sources[i] == tokenservice.SourceCache {
+			cacheHit++
+			aggregatorService.SetTokenBehaviour(addresses[i], metadata.Behaviour)
+			aggregatorService.SetTokenRisk(addresses[i], metadata.Risk)
+		}
+		found = append(found, metadata)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": found,
+		"errors": errs,
+		"cached": cacheHit,
+	})
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node/nodetest"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/config"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/storage"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tokenservice"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/lmittmann/w3"
+)
+
+func TestSearchTokens(t *testing.T) {
+	registry := []models.TokenMetadata{
+		{Address: "0x0000000000000000000000000000000000000001", Symbol: "USDC", Name: "USD Coin", Tags: []string{"stablecoin"}},
+		{Address: "0x0000000000000000000000000000000000000002", Symbol: "USDT", Name: "Tether USD", Tags: []string{"stablecoin"}},
+		{Address: "0x0000000000000000000000000000000000000003", Symbol: "WETH", Name: "Wrapped Ether"},
+		{Address: "0x0000000000000000000000000000000000000004", Symbol: "sUSD", Name: "Synth USD"},
+		{Address: "0x0000000000000000000000000000000000000005", Symbol: "USD", Name: "Dollar"},
+		{Address: "0x0000000000000000000000000000000000000006", Symbol: "ETH", Name: "Ether"},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		tag   string
+		want  []string
+	}{
+		{"everything by symbol", "", "", []string{"ETH", "sUSD", "USD", "USDC", "USDT", "WETH"}},
+		{"exact, prefix, then contains", "usd", "", []string{"USD", "USDC", "USDT", "sUSD"}},
+		{"case and space", "  WeTh ", "", []string{"WETH"}},
+		{"name", "ether", "", []string{"ETH", "USDT", "WETH"}}, // Tether matches too
+		{"address", "0x0000000000000000000000000000000000000003", "", []string{"WETH"}},
+		{"tag", "", "stablecoin", []string{"USDC", "USDT"}},
+		{"query and tag", "usdt", "stablecoin", []string{"USDT"}},
+		{"unknown tag", "", "meme", nil},
+		{"no match", "btc", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, token := range searchTokens(registry, tt.query, tt.tag) {
+				got = append(got, token.Symbol)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("searchTokens(%q, %q) = %v, want %v", tt.query, tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenBatchHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limit := config.AppConfig.TokenBatchLimit
+	config.AppConfig.TokenBatchLimit = 4
+	t.Cleanup(func() { config.AppConfig.TokenBatchLimit = limit })
+
+	var (
+		cached  = common.HexToAddress("0x00000000000000000000000000000000000000c1")
+		onChain = common.HexToAddress("0x00000000000000000000000000000000000000c2")
+		account = common.HexToAddress("0x00000000000000000000000000000000000000c3")
+		failing = common.HexToAddress("0x00000000000000000000000000000000000000c4")
+	)
+
+	tests := []struct {
+		name      string
+		addresses []string
+		down      bool
+		status    int
+		tokens    []string // Symbols in order
+		errors    []string // Addresses in order
+		cached    int
+		batches   int // HTTP requests to the node
+	}{
+		{name: "no addresses", addresses: []string{}, status: http.StatusBadRequest},
+		{name: "over the limit", addresses: []string{cached.Hex(), onChain.Hex(), account.Hex(), failing.Hex(), "0x01"}, status: http.StatusBadRequest},
+		{name: "invalid address", addresses: []string{"0x1234", cached.Hex()}, status: http.StatusOK, tokens: []string{"CACHED"}, errors: []string{"0x1234"}, cached: 1},
+		{name: "duplicates", addresses: []string{cached.Hex(), strings.ToLower(cached.Hex())}, status: http.StatusOK, tokens: []string{"CACHED"}, cached: 1},
+		{
+			name:      "cached, missed and failed",
+			addresses: []string{cached.Hex(), onChain.Hex(), account.Hex(), failing.Hex()},
+			status:    http.StatusOK,
+			tokens:    []string{"CACHED", "CHAIN"},
+			errors:    []string{account.Hex(), failing.Hex()},
+			cached:    1,
+			batches:   1,
+		},
+		{name: "node down", addresses: []string{cached.Hex(), onChain.Hex()}, down: true, status: http.StatusBadGateway},
+		{name: "node down all cached", addresses: []string{cached.Hex()}, down: true, status: http.StatusOK, tokens: []string{"CACHED"}, cached: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := nodetest.New(t)
+			node.Handle(onChain, w3.MustNewFunc("name()", "string"), nodetest.Returns("Chain Token"))
+			node.Handle(onChain, w3.MustNewFunc("symbol()", "string"), nodetest.Returns("CHAIN"))
+			node.Handle(onChain, w3.MustNewFunc("decimals()", "uint256"), nodetest.Returns(big.NewInt(18)))
+			node.SetNoCode(account)
+			node.SetFailing(failing)
+			node.SetDown(test.down)
+
+			store := storage.NewMemoryStore()
+			token := models.TokenMetadata{Address: cached.Hex(), Name: "Cached Token", Symbol: "CACHED", Decimals: 6}
+			if err := store.SaveTokens(context.Background(), []models.TokenMetadata{token}, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+			tokenService := tokenservice.NewService(store, node.URL, tokenservice.Config{TTL: time.Hour, MissTTL: time.Minute})
+			aggregatorService := aggregator.NewService(node.URL, tokenService)
+
+			router := gin.New()
+			router.POST("/tokens/batch", func(c *gin.Context) { tokenBatchHandler(c, tokenService, aggregatorService) })
+
+			body, _ := json.Marshal(TokenBatchRequest{Addresses: test.addresses})
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/tokens/batch", bytes.NewReader(body)))
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+			if test.status != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Tokens []models.TokenMetadata `json:"tokens"`
+				Errors []TokenBatchError      `json:"errors"`
+				Cached int                    `json:"cached"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+
+			var symbols, failed []string
+			for _, token := range response.Tokens {
+				symbols = append(symbols, token.Symbol)
+			}
+			for _, err := range response.Errors {
+				failed = append(failed, err.Address)
+			}
+			if !slices.Equal(symbols, test.tokens) {
+				t.Errorf("tokens = %v, want %v", symbols, test.tokens)
+			}
+			if !slices.Equal(failed, test.errors) {
+				t.Errorf("errors = %v, want %v", failed, test.errors)
+			}
+			if response.Cached != test.cached {
+				t.Errorf("cached = %d, want %d", response.Cached, test.cached)
+			}
+			if _, batches := node.Requests(); batches != test.batches {
+				t.Errorf("node batches = %d, want %d", batches, test.batches)
+			}
+		})
+	}
+}
@@ -28,9 +28,859 @@ const docTemplate = `{
                 }
             }
         },
+        "/admin/keys/{id}/usage": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns an API key's requests per endpoint for each of the last days, most recent first. Requests rejected by the rate limit are counted under rate_limited. Needs the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the usage of an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of days, defaults to 7",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/quote": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Quotes every supported protocol and returns the routes ranked best first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quote"
+                ],
+                "summary": "Get the best quote for a token pair",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Input token address, 0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE for the native currency",
+                        "name": "tokenIn",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Output token address",
+                        "name": "tokenOut",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Amount in the input token's smallest unit, defaults to 10000",
+                        "name": "amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Amount in whole input tokens, e.g. 1.5",
+                        "name": "amountHuman",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "output",
+                            "gas",
+                            "fee"
+                        ],
+                        "type": "string",
+                        "description": "Route ranking",
+                        "name": "rank",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of routes, 0 returns all",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuoteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/quote/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sends a quote event every new block, or only when the best route changes with updates=change. Failures are sent as error events.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "quote"
+                ],
+                "summary": "Stream quotes as server-sent events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Input token address",
+                        "name": "tokenIn",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Output token address",
+                        "name": "tokenOut",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Amount in the input token's smallest unit, defaults to 10000",
+                        "name": "amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Amount in whole input tokens, e.g. 1.5",
+                        "name": "amountHuman",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "output",
+                            "gas",
+                            "fee"
+                        ],
+                        "type": "string",
+                        "description": "Route ranking",
+                        "name": "rank",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of routes, 0 returns all",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "block",
+                            "change"
+                        ],
+                        "type": "string",
+                        "description": "When to send a quote",
+                        "name": "updates",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StreamMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/quote/ws": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Send {\"type\":\"subscribe\",\"id\":\"...\",\"tokenIn\":\"...\",\"tokenOut\":\"...\",\"amount\":\"...\",\"updates\":\"block|change\"} to receive a quote message every new block, or only when the best route changes, and {\"type\":\"unsubscribe\",\"id\":\"...\"} to stop.",
+                "tags": [
+                    "quote"
+                ],
+                "summary": "Stream quotes over a WebSocket",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/api.StreamMessage"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/depth": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Quotes a pair at a log-spaced ladder of input sizes from min to max, or at the sizes listed in amounts, and returns the output, price and price impact of the best route and of each protocol's best route at every size. Price impact is measured against the price at the smallest size.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quote"
+                ],
+                "summary": "Get the liquidity curve of a pair",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Input token address",
+                        "name": "tokena",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Output token address",
+                        "name": "tokenb",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sizes in whole input tokens, replaces the ladder",
+                        "name": "amounts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Smallest size in whole input tokens, defaults to 0.01",
+                        "name": "min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Largest size in whole input tokens, defaults to 100000",
+                        "name": "max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of sizes in the ladder, defaults to 15",
+                        "name": "steps",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/graph/neighbours": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "summary": "List the tokens a token swaps into directly",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token address",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/graph/path": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "summary": "Find the best path between two tokens",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Input token address",
+                        "name": "tokenIn",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Output token address",
+                        "name": "tokenOut",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of swaps",
+                        "name": "hops",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/graph/reachable": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "summary": "List the tokens reachable from a token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token address",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of swaps",
+                        "name": "hops",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/pairs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deprecated, use /api/v1/quote. The response shape depends on all.",
+                "tags": [
+                    "quote"
+                ],
+                "summary": "Get the best quote for a token pair",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Input token address",
+                        "name": "tokena",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Output token address",
+                        "name": "tokenb",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Amount in the input token's smallest unit, defaults to 10000",
+                        "name": "amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Amount in whole input tokens, e.g. 1.5",
+                        "name": "amountHuman",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return every route instead of only the best",
+                        "name": "all",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "output",
+                            "gas",
+                            "fee"
+                        ],
+                        "type": "string",
+                        "description": "Route ranking",
+                        "name": "rank",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of routes returned with all",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/pools": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Pools come from the registry, which is filled as pairs are quoted. A pair with no pools in the registry is looked up on every Uniswap fork and fee tier, and the pools found are added.",
+                "summary": "List the known pools for a token pair",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First token address",
+                        "name": "tokenA",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Second token address",
+                        "name": "tokenB",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/protocols": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List the supported protocols",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Quotes every request in one pass, sharing token metadata and pool discovery and batching RPC calls. Requests take the same fields as /api/v1/quote. Each result holds a quote or an error; requests not quoted before the batch deadline fail with a timeout error.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quote"
+                ],
+                "summary": "Get quotes for many pairs and amounts",
+                "parameters": [
+                    {
+                        "description": "Quote requests",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.BatchQuoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BatchQuoteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/swap": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Quotes the pair and builds an unsigned transaction for the best route through a protocol with a swap router configured in SWAP_ROUTERS.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swap"
+                ],
+                "summary": "Build a swap transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Input token address, 0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE for the native currency",
+                        "name": "tokena",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Output token address",
+                        "name": "tokenb",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Amount in the input token's smallest unit",
+                        "name": "amount",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address that receives the output",
+                        "name": "recipient",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Slippage tolerance in basis points, defaults to 50",
+                        "name": "slippage",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "output",
+                            "gas",
+                            "fee"
+                        ],
+                        "type": "string",
+                        "description": "Route ranking",
+                        "name": "rank",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/token": {
             "get": {
-                "summary": "ping example",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Get token metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token address",
+                        "name": "address",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reads a token's metadata from the chain if it isn't cached, caches it and screens it. Needs the token:write scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Add a token",
+                "parameters": [
+                    {
+                        "description": "Token address",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "The next lookup reads the token from the chain again. Needs the token:write scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Remove a token from the cache",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token address",
+                        "name": "address",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reads a token's metadata from the chain again, keeping token list fields, and screens it again. Needs the token:write scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Refresh a token",
+                "parameters": [
+                    {
+                        "description": "Token address",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "summary": "List and search the token registry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Symbol, name or address to search for",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only tokens with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of tokens",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -40,18 +890,450 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/tokens/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cached tokens are read with one Redis MGET and the rest from the node in one JSON-RPC batch, then cached with one pipeline. Up to TOKEN_BATCH_LIMIT addresses; addresses that fail are listed in errors.",
+                "summary": "Get the metadata of many tokens",
+                "parameters": [
+                    {
+                        "description": "Token addresses",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.TokenBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Imports the list at TOKEN_LIST_URL or TOKEN_LIST_PATH into the token registry. Needs the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Import the configured token list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "aggregator.RouteQuote": {
+            "type": "object",
+            "properties": {
+                "amountIn": {
+                    "description": "Input amount",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.Amount"
+                        }
+                    ]
+                },
+                "amountOut": {
+                    "description": "Output amount",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.Amount"
+                        }
+                    ]
+                },
+                "fee": {
+                    "description": "Fee tier (e.g., 500, 3000, 10000)",
+                    "type": "integer"
+                },
+                "gas": {
+                    "description": "Estimated gas, including wrapping and unwrapping",
+                    "type": "integer"
+                },
+                "poolAddress": {
+                    "description": "Pool address",
+                    "type": "string"
+                },
+                "protocol": {
+                    "description": "Protocol name (e.g., \"Uniswap V3\")",
+                    "type": "string"
+                },
+                "router": {
+                    "description": "Swap router (or wrapped native token) the swap is sent to, empty if swaps can't be built",
+                    "type": "string"
+                },
+                "tokenIn": {
+                    "description": "Input token symbol",
+                    "type": "string"
+                },
+                "tokenOut": {
+                    "description": "Output token symbol",
+                    "type": "string"
+                },
+                "unwrapNative": {
+                    "description": "Output is wrapped native and must be unwrapped after the swap",
+                    "type": "boolean"
+                },
+                "warnings": {
+                    "description": "Token behaviour the user should know about",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "wrapNative": {
+                    "description": "Input is native and must be wrapped before the swap",
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.Amount": {
+            "type": "object",
+            "properties": {
+                "decimals": {
+                    "type": "integer",
+                    "example": 6
+                },
+                "display": {
+                    "type": "string",
+                    "example": "1.5"
+                },
+                "raw": {
+                    "type": "string",
+                    "example": "1500000"
+                }
+            }
+        },
+        "api.BatchQuoteRequest": {
+            "type": "object",
+            "required": [
+                "quotes"
+            ],
+            "properties": {
+                "quotes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.QuoteRequest"
+                    }
+                }
+            }
+        },
+        "api.BatchQuoteResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.BatchQuoteResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.BatchQuoteResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/api.Error"
+                },
+                "quote": {
+                    "$ref": "#/definitions/api.QuoteResponse"
+                }
+            }
+        },
+        "api.Error": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "invalid_amount"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "amount 1.1234567 has more than 6 decimal places"
+                }
+            }
+        },
+        "api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/api.Error"
+                }
+            }
+        },
+        "api.QuoteRequest": {
+            "type": "object",
+            "required": [
+                "tokenIn",
+                "tokenOut"
+            ],
+            "properties": {
+                "amount": {
+                    "description": "In the input token's smallest unit",
+                    "type": "string",
+                    "example": "1000000000000000000"
+                },
+                "amountHuman": {
+                    "description": "In whole input tokens",
+                    "type": "string",
+                    "example": "1.5"
+                },
+                "limit": {
+                    "description": "Maximum number of routes, 0 returns all",
+                    "type": "integer",
+                    "example": 5
+                },
+                "rank": {
+                    "type": "string",
+                    "enum": [
+                        "output",
+                        "gas",
+                        "fee"
+                    ],
+                    "example": "output"
+                },
+                "tokenIn": {
+                    "type": "string",
+                    "example": "0x760AfE86e5de5fa0Ee542fc7B7B713e1c5425701"
+                },
+                "tokenOut": {
+                    "type": "string",
+                    "example": "0xf817257fed379853cDe0fa4F97AB987181B1E5Ea"
+                }
+            }
+        },
+        "api.QuoteResponse": {
+            "type": "object",
+            "properties": {
+                "amountIn": {
+                    "description": "Amount quoted",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.Amount"
+                        }
+                    ]
+                },
+                "approximate": {
+                    "description": "AmountIn was rounded from the requested amount so nearby amounts share cached quotes",
+                    "type": "boolean"
+                },
+                "bestRoute": {
+                    "$ref": "#/definitions/aggregator.RouteQuote"
+                },
+                "risk": {
+                    "$ref": "#/definitions/api.Risk"
+                },
+                "routes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/aggregator.RouteQuote"
+                    }
+                },
+                "tokenIn": {
+                    "$ref": "#/definitions/api.Token"
+                },
+                "tokenOut": {
+                    "$ref": "#/definitions/api.Token"
+                }
+            }
+        },
+        "api.Risk": {
+            "type": "object",
+            "properties": {
+                "tokenIn": {
+                    "$ref": "#/definitions/risk.Report"
+                },
+                "tokenOut": {
+                    "$ref": "#/definitions/risk.Report"
+                }
+            }
+        },
+        "api.StreamMessage": {
+            "type": "object",
+            "properties": {
+                "block": {
+                    "type": "integer"
+                },
+                "error": {
+                    "$ref": "#/definitions/api.Error"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "quote": {
+                    "$ref": "#/definitions/api.QuoteResponse"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "subscribed",
+                        "unsubscribed",
+                        "quote",
+                        "error"
+                    ]
+                }
+            }
+        },
+        "api.Token": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "decimals": {
+                    "type": "integer"
+                },
+                "logoURI": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.TokenBatchRequest": {
+            "type": "object",
+            "required": [
+                "addresses"
+            ],
+            "properties": {
+                "addresses": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.TokenRequest": {
+            "type": "object",
+            "required": [
+                "address"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                }
+            }
+        },
+        "risk.Report": {
+            "type": "object",
+            "properties": {
+                "blacklist": {
+                    "description": "Code exposes blacklist functions",
+                    "type": "boolean"
+                },
+                "buyBlocked": {
+                    "description": "Transfers out of the pool revert",
+                    "type": "boolean"
+                },
+                "buyTaxBps": {
+                    "description": "Fee taken when buying from the pool",
+                    "type": "integer"
+                },
+                "level": {
+                    "description": "low, medium or high",
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "Current owner, if the token is ownable",
+                    "type": "string"
+                },
+                "ownerSupplyBps": {
+                    "description": "Share of total supply held by the owner",
+                    "type": "integer"
+                },
+                "pool": {
+                    "description": "Pool used for the buy and sell simulation",
+                    "type": "string"
+                },
+                "reasons": {
+                    "description": "Findings that contributed to the score",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "score": {
+                    "description": "0 (no findings) to 100 (avoid)",
+                    "type": "integer"
+                },
+                "screenedAt": {
+                    "description": "Unix timestamp of the screening",
+                    "type": "integer"
+                },
+                "sellBlocked": {
+                    "description": "Transfers into the pool revert or credit nothing",
+                    "type": "boolean"
+                },
+                "sellTaxBps": {
+                    "description": "Fee taken when selling into the pool",
+                    "type": "integer"
+                },
+                "upgradeable": {
+                    "description": "Token is behind an upgradeable proxy",
+                    "type": "boolean"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "x-api-key",
+            "in": "header"
         }
     }
 }`
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "",
+	Version:          "1.0",
 	Host:             "",
-	BasePath:         "/api/v1",
+	BasePath:         "/",
 	Schemes:          []string{},
-	Title:            "",
-	Description:      "",
+	Title:            "DeFi Aggregator API",
+	Description:      "Quotes and swaps across the supported DEX protocols. Versioned endpoints are under /api/v1.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,428 @@
+package aggregator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/uniswap"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/metrics"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/risk"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// BatchRequest is one quote of a batch
+type BatchRequest struct {
+	TokenIn  common.Address
+	TokenOut common.Address
+	AmountIn *big.Int
+}
+
+// BatchResult is the result of one quote of a batch, Err is set if it failed
+type BatchResult struct {
+	Result *AggregatorResult
+	Err    error
+}
+
+// batchPool is a pool found for a pair
+type batchPool struct {
+	protocol  protocols.ProtocolConfig
+	fee       uint64
+	address   common.Address
+	amountOut *big.Int // nil if the pool couldn't be quoted
+}
+
+// batchItem is a request of a batch being quoted
+type batchItem struct {
+	request           BatchRequest
+	tokenIn, tokenOut common.Address // Quoted through the wrapped native token
+	wrapIn, unwrapOut bool
+	metadataIn        *models.TokenMetadata
+	metadataOut       *models.TokenMetadata
+	behaviourIn       *tokens.Behaviour
+	behaviourOut      *tokens.Behaviour
+	riskIn, riskOut   *risk.Report
+	effectiveIn       *big.Int // What the pool receives after the input token's fee
+	pools             []batchPool
+	quotes            []int // Index of each pool's quote in the quote batch
+	err               error // Why the request failed, if the node didn't answer its part of the batch
+	done              bool  // A result or error has been set
+}
+
+// ResolveBatch gets the metadata of many tokens through the resolver and
+// shares any cached screening with the service. Per-token errors are in the
+// same order as addresses; the returned error is only set if the node can't
+// be reached.
+func (s *Service) ResolveBatch(ctx context.Context, addresses []common.Address) ([]*models.TokenMetadata, []error, error) {
+	metadata, _, errs, err := s.tokens.GetBatch(ctx, addresses)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get token metadata: %v: %w", err, ErrNodeUnavailable)
+	}
+
+	for i, token := range addresses {
+		if errs[i] == nil {
+			s.SetTokenBehaviour(token, metadata[i].Behaviour)
+			s.SetTokenRisk(token, metadata[i].Risk)
+		}
+	}
+	return metadata, errs, nil
+}
+
+// QuoteBatch quotes many requests at once. Token metadata is resolved once
+// per token, pools are looked up once per pair and the pool quotes of every
+// request are sent to the node in RPC batches, so a batch costs a few round
+// trips however many requests it holds. Results are in the same order as
+// requests. A request fails if the node didn't answer the RPC batch holding
+// one of its calls, e.g. because ctx was done, while the others are still
+// quoted.
+func (s *Service) QuoteBatch(ctx context.Context, requests []BatchRequest) []BatchResult {
+	results := make([]BatchResult, len(requests))
+	items := make([]*batchItem, len(requests))
+	for i, request := range requests {
+		items[i] = &batchItem{
+			request:   request,
+			tokenIn:   tokens.QuoteAddress(request.TokenIn),
+			tokenOut:  tokens.QuoteAddress(request.TokenOut),
+			wrapIn:    tokens.IsNative(request.TokenIn),
+			unwrapOut: tokens.IsNative(request.TokenOut),
+		}
+	}
+
+	// fail ends every request that is still pending, for failures that
+	// affect all of them
+	fail := func(err error) []BatchResult {
+		for i, item := range items {
+			if !item.done {
+				results[i].Err = err
+			}
+		}
+		return results
+	}
+
+	// Resolve every token once
+	var addresses []common.Address
+	index := make(map[common.Address]int)
+	for _, request := range requests {
+		for _, token := range []common.Address{request.TokenIn, request.TokenOut} {
+			if _, ok := index[token]; !ok {
+				index[token] = len(addresses)
+				addresses = append(addresses, token)
+			}
+		}
+	}
+	metadata, errs, err := s.ResolveBatch(ctx, addresses)
+	if err != nil {
+		return fail(err)
+	}
+
+	for i, item := range items {
+		request := item.request
+		if err := errs[index[request.TokenIn]]; err != nil {
+			results[i].Err = &TokenError{Side: "tokenIn", Address: request.TokenIn, Err: err}
+			item.done = true
+			continue
+		}
+		if err := errs[index[request.TokenOut]]; err != nil {
+			results[i].Err = &TokenError{Side: "tokenOut", Address: request.TokenOut, Err: err}
+			item.done = true
+			continue
+		}
+		item.metadataIn = metadata[index[request.TokenIn]]
+		item.metadataOut = metadata[index[request.TokenOut]]
+
+		// Native <-> wrapped native is a 1:1 conversion that doesn't need a pool
+		if item.tokenIn == item.tokenOut {
+			result := &AggregatorResult{}
+			if item.wrapIn != item.unwrapOut {
+				route := nativeWrapperRoute(request.AmountIn, item.metadataIn.Decimals, item.metadataIn.Symbol, item.metadataOut.Symbol, item.wrapIn)
+				result = &AggregatorResult{BestRoute: route, AllRoutes: []RouteQuote{route}}
+			}
+			result.TokenIn, result.TokenOut = item.metadataIn, item.metadataOut
+			results[i].Result = result
+			item.done = true
+		}
+	}
+
+	pending := make([]*batchItem, 0, len(items))
+	for _, item := range items {
+		if !item.done {
+			pending = append(pending, item)
+		}
+	}
+
+	if err := s.findBatchPools(ctx, pending); err != nil {
+		return fail(batchError(ctx, err))
+	}
+	pending = slices.DeleteFunc(pending, func(item *batchItem) bool { return item.err != nil })
+	s.screenBatch(ctx, pending)
+	if err := s.quoteBatchPools(ctx, pending); err != nil {
+		return fail(batchError(ctx, err))
+	}
+
+	for i, item := range items {
+		if item.done {
+			continue
+		}
+		if item.err != nil {
+			results[i].Err = item.err
+			item.done = true
+			continue
+		}
+
+		result := finishRoutes(
+			item.routes(),
+			item.request.AmountIn,
+			item.metadataIn.Decimals,
+			item.metadataOut.Decimals,
+			item.metadataIn.Symbol,
+			item.metadataOut.Symbol,
+			item.behaviourIn,
+			item.behaviourOut,
+			item.riskIn,
+			item.riskOut,
+			item.wrapIn,
+			item.unwrapOut,
+		)
+		result.TokenIn, result.TokenOut = item.metadataIn, item.metadataOut
+		warnInferredDecimals(result)
+		results[i].Result = result
+		item.done = true
+	}
+	return results
+}
+
+// findBatchPools looks up the pools of every pair in one set of RPC batches
+// and records them in the registry. Items whose lookups weren't answered fail,
+// as a missing pool could be the best one.
+func (s *Service) findBatchPools(ctx context.Context, items []*batchItem) error {
+	var lookups []uniswap.PoolLookup
+	var lookupPools []batchPool
+	lookupIndex := make(map[pairKey][]int)
+	for _, item := range items {
+		key := item.pair()
+		if _, ok := lookupIndex[key]; ok {
+			continue
+		}
+
+		for _, protocol := range protocols.GetUniswapForks() {
+			for _, feeTier := range protocol.FeeTiers {
+				lookupIndex[key] = append(lookupIndex[key], len(lookups))
+				lookups = append(lookups, uniswap.PoolLookup{
+					TokenA:  key[0],
+					TokenB:  key[1],
+					Fee:     feeTier,
+					Factory: protocol.FactoryAddress,
+				})
+				lookupPools = append(lookupPools, batchPool{protocol: protocol, fee: feeTier})
+			}
+		}
+	}
+
+	addresses, errs, err := uniswap.GetPoolAddresses(ctx, lookups, s.nodeURL)
+	if err != nil {
+		return fmt.Errorf("failed to get pool addresses: %v", err)
+	}
+	for i, err := range errs {
+		if err != nil && !errors.Is(err, uniswap.ErrBatchFailed) {
+			metrics.ProtocolQuoteErrors.WithLabelValues(lookupPools[i].protocol.Name, metrics.StagePool).Inc()
+		}
+	}
+
+	registered := make(map[int]bool)
+	for _, item := range items {
+		key := item.pair()
+		for _, i := range lookupIndex[key] {
+			if errors.Is(errs[i], uniswap.ErrBatchFailed) {
+				item.err = batchError(ctx, errs[i])
+				break
+			}
+		}
+		if item.err != nil {
+			continue
+		}
+
+		for _, i := range lookupIndex[key] {
+			// Skip if pool doesn't exist or error occurs
+			if errs[i] != nil || addresses[i] == (common.Address{}) {
+				continue
+			}
+			pool := lookupPools[i]
+			pool.address = addresses[i]
+			item.pools = append(item.pools, pool)
+
+			// Remember the pool in the registry
+			if s.pools != nil && !registered[i] {
+				registered[i] = true
+				pair := pairs.NewTokenPair(
+					pairs.NewERC20Token(item.tokenIn, item.metadataIn.Symbol, item.metadataIn.Decimals),
+					pairs.NewERC20Token(item.tokenOut, item.metadataOut.Symbol, item.metadataOut.Decimals),
+				)
+				if err := s.pools.AddProtocolPair(ctx, pool.protocol.Name, pool.address.String(), pool.fee, pair); err != nil {
+					logger.ErrorContext(ctx, "Failed to register pool", "pool", pool.address.Hex(), "error", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// screenBatch screens every token of the batch once, through the most liquid
+// pool found for it, and sets the amount each pool receives. Screening stops
+// when ctx is done, and tokens not screened by then are treated as unknown.
+func (s *Service) screenBatch(ctx context.Context, items []*batchItem) {
+	candidates := make(map[common.Address][]common.Address)
+	for _, item := range items {
+		for _, token := range []common.Address{item.tokenIn, item.tokenOut} {
+			for _, pool := range item.pools {
+				if !slices.Contains(candidates[token], pool.address) {
+					candidates[token] = append(candidates[token], pool.address)
+				}
+			}
+		}
+	}
+	screenPools := s.screeningPools(ctx, candidates)
+
+	type screening struct {
+		behaviour *tokens.Behaviour
+		report    *risk.Report
+	}
+	var mu sync.Mutex
+	screened := make(map[common.Address]screening)
+	var wg sync.WaitGroup
+	for token, pool := range screenPools {
+		wg.Add(1)
+		go func(token, pool common.Address) {
+			defer wg.Done()
+			behaviour, report := s.screenFor(ctx, token, pool)
+			mu.Lock()
+			screened[token] = screening{behaviour, report}
+			mu.Unlock()
+		}(token, pool)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(screened) < len(screenPools) {
+		logger.WarnContext(ctx, "Batch deadline reached while screening tokens", "tokens", len(screenPools), "unscreened", len(screenPools)-len(screened))
+	}
+	for _, item := range items {
+		item.behaviourIn, item.riskIn = screened[item.tokenIn].behaviour, screened[item.tokenIn].report
+		item.behaviourOut, item.riskOut = screened[item.tokenOut].behaviour, screened[item.tokenOut].report
+
+		// The pool only receives what is left after the input token's fee
+		item.effectiveIn = item.request.AmountIn
+		if item.behaviourIn != nil && item.behaviourIn.FeeOnTransfer {
+			item.effectiveIn = deductFee(item.request.AmountIn, item.behaviourIn.TransferFeeBps)
+		}
+	}
+}
+
+// quoteBatchPools quotes every pool of every request in one set of RPC
+// batches. Identical quotes are only asked for once. Items with a quote that
+// wasn't answered fail, as it could be the best route.
+func (s *Service) quoteBatchPools(ctx context.Context, items []*batchItem) error {
+	var calls []uniswap.QuoteCall
+	var callProtocols []string
+	callIndex := make(map[string]int)
+	for _, item := range items {
+		item.quotes = make([]int, len(item.pools))
+		for j, pool := range item.pools {
+			key := fmt.Sprintf("%s:%s:%s:%d:%s", pool.protocol.RouterAddress.Hex(), item.tokenIn.Hex(), item.tokenOut.Hex(), pool.fee, item.effectiveIn)
+			i, ok := callIndex[key]
+			if !ok {
+				i = len(calls)
+				callIndex[key] = i
+				callProtocols = append(callProtocols, pool.protocol.Name)
+				calls = append(calls, uniswap.QuoteCall{
+					TokenIn:  item.tokenIn,
+					TokenOut: item.tokenOut,
+					Fee:      pool.fee,
+					AmountIn: item.effectiveIn,
+					Router:   pool.protocol.RouterAddress,
+				})
+			}
+			item.quotes[j] = i
+		}
+	}
+
+	amountsOut, errs, err := uniswap.GetQuotes(ctx, calls, s.nodeURL)
+	if err != nil {
+		return fmt.Errorf("failed to get quotes: %v", err)
+	}
+	for i, err := range errs {
+		if err != nil && !errors.Is(err, uniswap.ErrBatchFailed) {
+			metrics.ProtocolQuoteErrors.WithLabelValues(callProtocols[i], metrics.StageQuote).Inc()
+		}
+	}
+
+	for _, item := range items {
+		for j, i := range item.quotes {
+			if errors.Is(errs[i], uniswap.ErrBatchFailed) {
+				item.err = batchError(ctx, errs[i])
+			}
+			item.pools[j].amountOut = amountsOut[i]
+		}
+	}
+	return nil
+}
+
+// pairKey is a pair of tokens in address order, pools are the same both ways
+type pairKey [2]common.Address
+
+// pair returns the key of the item's pair
+func (item *batchItem) pair() pairKey {
+	if bytes.Compare(item.tokenOut[:], item.tokenIn[:]) < 0 {
+		return pairKey{item.tokenOut, item.tokenIn}
+	}
+	return pairKey{item.tokenIn, item.tokenOut}
+}
+
+// routes returns a route for every pool of the item that could be quoted
+func (item *batchItem) routes() []RouteQuote {
+	var routes []RouteQuote
+	for _, pool := range item.pools {
+		if pool.amountOut == nil {
+			continue
+		}
+		routes = append(routes, RouteQuote{
+			Protocol:    pool.protocol.Name,
+			PoolAddress: pool.address.String(),
+			Fee:         pool.fee,
+			TokenIn:     item.metadataIn.Symbol,
+			TokenOut:    item.metadataOut.Symbol,
+			AmountIn:    utils.NewAmount(item.effectiveIn, item.metadataIn.Decimals),
+			AmountOut:   utils.NewAmount(pool.amountOut, item.metadataOut.Decimals),
+			Gas:         pool.protocol.SwapGas,
+			Router:      pool.protocol.SwapRouter(),
+		})
+	}
+	return routes
+}
+
+// batchError wraps a failed batch call in ctx's error if the batch ran out of
+// time, otherwise in ErrNodeUnavailable
+func batchError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("%v: %w", err, ctx.Err())
+	}
+	return fmt.Errorf("%v: %w", err, ErrNodeUnavailable)
+}
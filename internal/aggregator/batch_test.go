@@ -0,0 +1,94 @@
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node/nodetest"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/risk"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+)
+
+var funcQuoteExactInputSingle = w3.MustNewFunc("quoteExactInputSingle(address,address,uint24,uint256,uint160)", "uint256")
+
+// screenedResolver resolves every token as an 18 decimal token that was
+// screened just now, so quotes don't simulate transfers
+type screenedResolver struct{}
+
+func (screenedResolver) GetBatch(ctx context.Context, addresses []common.Address) ([]*models.TokenMetadata, []string, []error, error) {
+	metadata := make([]*models.TokenMetadata, len(addresses))
+	for i, address := range addresses {
+		now := time.Now().Unix()
+		metadata[i] = &models.TokenMetadata{
+			Address:   address.Hex(),
+			Symbol:    "TKN",
+			Decimals:  18,
+			Behaviour: &tokens.Behaviour{ProbedAt: now},
+			Risk:      &risk.Report{ScreenedAt: now},
+		}
+	}
+	return metadata, make([]string, len(addresses)), make([]error, len(addresses)), nil
+}
+
+func (screenedResolver) SaveScreening(ctx context.Context, address common.Address, behaviour *tokens.Behaviour, report *risk.Report) error {
+	return nil
+}
+
+func TestQuoteBatchPartialFailure(t *testing.T) {
+	node := nodetest.New(t)
+	// Every pair has a pool at 3000, which quotes twice the amount in
+	node.Handle(nodetest.AnyContract, funcGetPool, func(args []any) ([]any, error) {
+		if args[2].(*big.Int).Uint64() != 3000 {
+			return []any{common.Address{}}, nil
+		}
+		pool := new(big.Int).Add(args[0].(common.Address).Big(), args[1].(common.Address).Big())
+		return []any{common.BigToAddress(pool)}, nil
+	})
+	node.Handle(nodetest.AnyContract, funcQuoteExactInputSingle, func(args []any) ([]any, error) {
+		return []any{new(big.Int).Mul(args[3].(*big.Int), big.NewInt(2))}, nil
+	})
+
+	// Enough pairs that the pool lookups take two RPC batches, the pairs of
+	// the first are fully answered
+	lookups := 0
+	for _, protocol := range protocols.GetUniswapForks() {
+		lookups += len(protocol.FeeTiers)
+	}
+	answered := 500 / lookups
+	base := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	requests := make([]BatchRequest, answered+2)
+	for i := range requests {
+		requests[i] = BatchRequest{
+			TokenIn:  base,
+			TokenOut: common.BigToAddress(big.NewInt(int64(0x1000 + i))),
+			AmountIn: big.NewInt(1000),
+		}
+	}
+
+	// The second batch fails as a whole
+	node.SetUnreachable(requests[len(requests)-1].TokenOut)
+
+	results := NewService(node.URL, screenedResolver{}).QuoteBatch(context.Background(), requests)
+	if len(results) != len(requests) {
+		t.Fatalf("got %d results, want %d", len(results), len(requests))
+	}
+	for i, result := range results[:answered] {
+		if result.Err != nil {
+			t.Errorf("request %d: %v", i, result.Err)
+			continue
+		}
+		if got := result.Result.BestRoute.AmountOut.Raw(); got.Cmp(big.NewInt(2000)) != 0 {
+			t.Errorf("request %d amount out = %s, want 2000", i, got)
+		}
+	}
+	if err := results[len(results)-1].Err; !errors.Is(err, ErrNodeUnavailable) {
+		t.Errorf("request in the failed batch: error = %v, want ErrNodeUnavailable", err)
+	}
+}
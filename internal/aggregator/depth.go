@@ -0,0 +1,174 @@
+package aggregator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DepthQuote is the output of a route at one input size
+type DepthQuote struct {
+	Protocol    string       `json:"protocol"`
+	PoolAddress string       `json:"poolAddress"`
+	Fee         uint64       `json:"fee"`
+	AmountOut   utils.Amount `json:"amountOut"`
+	Price       float64      `json:"price"`       // Output tokens per input token
+	PriceImpact float64      `json:"priceImpact"` // Fraction the price is below the reference price
+}
+
+// DepthPoint is the best route and the best route of each protocol at one
+// input size. Error is set instead if the size couldn't be quoted.
+type DepthPoint struct {
+	AmountIn  utils.Amount          `json:"amountIn"`
+	Best      *DepthQuote           `json:"best,omitempty"`
+	Protocols map[string]DepthQuote `json:"protocols,omitempty"`
+	Error     string                `json:"error,omitempty"`
+}
+
+// Depth is how the output of a pair degrades as the input grows. Price impact
+// is measured against the price at the smallest size each route was quoted at.
+type Depth struct {
+	TokenIn        *models.TokenMetadata `json:"-"`
+	TokenOut       *models.TokenMetadata `json:"-"`
+	ReferencePrice float64               `json:"referencePrice"` // Best price at the smallest size
+	Points         []DepthPoint          `json:"points"`
+}
+
+// Depth quotes a pair at every amount, in the input token's smallest unit, in
+// one batch. It only fails if no amount could be quoted.
+func (s *Service) Depth(ctx context.Context, tokenIn, tokenOut common.Address, amounts []*big.Int) (*Depth, error) {
+	requests := make([]BatchRequest, len(amounts))
+	for i, amount := range amounts {
+		requests[i] = BatchRequest{TokenIn: tokenIn, TokenOut: tokenOut, AmountIn: amount}
+	}
+	results := s.QuoteBatch(ctx, requests)
+
+	depth := &Depth{Points: make([]DepthPoint, len(amounts))}
+	var firstErr error
+	references := make(map[string]float64)
+	for i, result := range results {
+		if result.Err != nil {
+			if firstErr == nil {
+				firstErr = result.Err
+			}
+			depth.Points[i].Error = result.Err.Error()
+			continue
+		}
+
+		depth.TokenIn, depth.TokenOut = result.Result.TokenIn, result.Result.TokenOut
+		point := &depth.Points[i]
+		amountIn := utils.NewAmount(amounts[i], result.Result.TokenIn.Decimals)
+		if len(result.Result.AllRoutes) == 0 {
+			continue
+		}
+
+		// Routes are ranked, so the first route of a protocol is its best
+		point.Protocols = make(map[string]DepthQuote)
+		for _, route := range result.Result.AllRoutes {
+			if _, ok := point.Protocols[route.Protocol]; ok {
+				continue
+			}
+			point.Protocols[route.Protocol] = depthQuote(route, amountIn, references, route.Protocol)
+		}
+
+		best := depthQuote(result.Result.BestRoute, amountIn, references, "")
+		point.Best = &best
+	}
+
+	if depth.TokenIn == nil {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("no amounts to quote")
+		}
+		return nil, firstErr
+	}
+	depth.ReferencePrice = references[""]
+
+	for i := range depth.Points {
+		depth.Points[i].AmountIn = utils.NewAmount(amounts[i], depth.TokenIn.Decimals)
+	}
+	return depth, nil
+}
+
+// depthQuote summarises a route at an input size. The first price seen under
+// key becomes its reference price, so amounts must be in ascending order.
+func depthQuote(route RouteQuote, amountIn utils.Amount, references map[string]float64, key string) DepthQuote {
+	price := Price(amountIn, route.AmountOut)
+	reference, ok := references[key]
+	if !ok {
+		reference = price
+		references[key] = price
+	}
+
+	impact := 0.0
+	if reference > 0 {
+		impact = 1 - price/reference
+	}
+
+	return DepthQuote{
+		Protocol:    route.Protocol,
+		PoolAddress: route.PoolAddress,
+		Fee:         route.Fee,
+		AmountOut:   route.AmountOut,
+		Price:       price,
+		PriceImpact: impact,
+	}
+}
+
+// Price returns the output tokens received per input token
+func Price(amountIn, amountOut utils.Amount) float64 {
+	if amountIn.Sign() == 0 {
+		return 0
+	}
+	in := new(big.Rat).SetFrac(amountIn.Raw(), utils.Pow10(int(amountIn.Decimals())))
+	out := new(big.Rat).SetFrac(amountOut.Raw(), utils.Pow10(int(amountOut.Decimals())))
+	price, _ := new(big.Rat).Quo(out, in).Float64()
+	return price
+}
+
+// ladderDigits is the significant digits ladder amounts are rounded to, so
+// decades land on round numbers
+const ladderDigits = 4
+
+// DepthLadder returns up to steps amounts spaced evenly on a log scale from
+// low to high, both included. Amounts are rounded to ladderDigits significant
+// digits and amounts that round to the same value are only returned once.
+func DepthLadder(low, high *big.Int, steps int) []*big.Int {
+	if steps < 2 || low.Cmp(high) >= 0 {
+		return []*big.Int{new(big.Int).Set(low)}
+	}
+
+	lowF, _ := new(big.Float).SetInt(low).Float64()
+	highF, _ := new(big.Float).SetInt(high).Float64()
+	ratio := math.Pow(highF/lowF, 1/float64(steps-1))
+
+	ladder := []*big.Int{new(big.Int).Set(low)}
+	for i := 1; i < steps-1; i++ {
+		// Round to nearest, truncating puts a decade like 10000 at 9999
+		scaled := new(big.Float).Mul(new(big.Float).SetInt(low), big.NewFloat(math.Pow(ratio, float64(i))))
+		amount, _ := scaled.Add(scaled, big.NewFloat(0.5)).Int(nil)
+		amount = roundSignificant(amount, ladderDigits)
+		if amount.Cmp(ladder[len(ladder)-1]) > 0 && amount.Cmp(high) < 0 {
+			ladder = append(ladder, amount)
+		}
+	}
+	return append(ladder, new(big.Int).Set(high))
+}
+
+// roundSignificant rounds a positive amount to the nearest number with digits
+// significant digits
+func roundSignificant(amount *big.Int, digits int) *big.Int {
+	length := len(amount.String())
+	if length <= digits {
+		return amount
+	}
+
+	unit := utils.Pow10(length - digits)
+	rounded := new(big.Int).Add(amount, new(big.Int).Rsh(unit, 1))
+	rounded.Div(rounded, unit)
+	return rounded.Mul(rounded, unit)
+}
@@ -0,0 +1,62 @@
+package aggregator
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func TestRoundSignificant(t *testing.T) {
+	tests := []struct {
+		amount string
+		digits int
+		want   string
+	}{
+		{"123456", 4, "123500"},
+		{"123449", 4, "123400"},
+		{"999950", 4, "1000000"}, // Rounds up a digit
+		{"1234", 4, "1234"},
+		{"12", 4, "12"},
+		{"15", 1, "20"},
+		{"14", 1, "10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			amount, _ := new(big.Int).SetString(tt.amount, 10)
+			if got := roundSignificant(amount, tt.digits); got.String() != tt.want {
+				t.Errorf("roundSignificant(%s, %d) = %s, want %s", tt.amount, tt.digits, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDepthLadder(t *testing.T) {
+	tests := []struct {
+		name      string
+		low, high int64
+		steps     int
+		want      []string
+	}{
+		{"decades", 1000, 1000000, 4, []string{"1000", "10000", "100000", "1000000"}},
+		{"rounded", 1000, 2000, 3, []string{"1000", "1414", "2000"}},
+		{"one step", 1000, 2000, 1, []string{"1000"}},
+		{"low above high", 2000, 1000, 5, []string{"2000"}},
+		{"equal", 1000, 1000, 5, []string{"1000"}},
+		{"duplicates dropped", 1, 3, 10, []string{"1", "2", "3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ladder := DepthLadder(big.NewInt(tt.low), big.NewInt(tt.high), tt.steps)
+
+			got := make([]string, len(ladder))
+			for i, amount := range ladder {
+				got[i] = amount.String()
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DepthLadder(%d, %d, %d) = %v, want %v", tt.low, tt.high, tt.steps, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/uniswap"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/metrics"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DiscoverPools looks up the pools of a pair on every Uniswap fork and fee
+// tier in one RPC batch and adds the ones that exist to the pool registry. It
+// returns the number of pools found.
+func (s *Service) DiscoverPools(ctx context.Context, tokenA, tokenB common.Address) (int, error) {
+	if s.pools == nil {
+		return 0, errors.New("no pool registry to add pools to")
+	}
+
+	pairTokens := []common.Address{tokenA, tokenB}
+	metadata, _, errs, err := s.tokens.GetBatch(ctx, pairTokens)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get token metadata: %v: %w", err, ErrNodeUnavailable)
+	}
+	for i, side := range []string{"tokenA", "tokenB"} {
+		if errs[i] != nil {
+			return 0, &TokenError{Side: side, Address: pairTokens[i], Err: errs[i]}
+		}
+	}
+
+	var lookups []uniswap.PoolLookup
+	var lookupPools []batchPool
+	for _, protocol := range protocols.GetUniswapForks() {
+		for _, feeTier := range protocol.FeeTiers {
+			lookups = append(lookups, uniswap.PoolLookup{
+				TokenA:  tokenA,
+				TokenB:  tokenB,
+				Fee:     feeTier,
+				Factory: protocol.FactoryAddress,
+			})
+			lookupPools = append(lookupPools, batchPool{protocol: protocol, fee: feeTier})
+		}
+	}
+
+	addresses, lookupErrs, err := uniswap.GetPoolAddresses(ctx, lookups, s.nodeURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pool addresses: %v: %w", err, ErrNodeUnavailable)
+	}
+
+	pair := pairs.NewTokenPair(
+		pairs.NewERC20Token(tokenA, metadata[0].Symbol, metadata[0].Decimals),
+		pairs.NewERC20Token(tokenB, metadata[1].Symbol, metadata[1].Decimals),
+	)
+	found := 0
+	for i, address := range addresses {
+		pool := lookupPools[i]
+		if errors.Is(lookupErrs[i], uniswap.ErrBatchFailed) {
+			return found, fmt.Errorf("failed to get pool addresses: %v: %w", lookupErrs[i], ErrNodeUnavailable)
+		}
+		if lookupErrs[i] != nil {
+			metrics.ProtocolQuoteErrors.WithLabelValues(pool.protocol.Name, metrics.StagePool).Inc()
+			continue
+		}
+		if address == (common.Address{}) {
+			continue
+		}
+		if err := s.pools.AddProtocolPair(ctx, pool.protocol.Name, address.String(), pool.fee, pair); err != nil {
+			logger.ErrorContext(ctx, "Failed to register pool", "pool", address.Hex(), "error", err)
+			continue
+		}
+		found++
+	}
+
+	logger.DebugContext(ctx, "Discovered pools", "tokenA", tokenA.Hex(), "tokenB", tokenB.Hex(), "pools", found)
+	return found, nil
+}
@@ -0,0 +1,183 @@
+package aggregator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3/module/eth"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache-Status header values (RFC 9211) describing how a quote was served
+const (
+	CacheHit       = "defi-aggregator; hit"
+	CacheCollapsed = "defi-aggregator; hit; detail=collapsed"
+	CacheMiss      = "defi-aggregator; fwd=miss; stored"
+	CacheBypass    = "defi-aggregator; fwd=bypass"
+)
+
+// blockNumberTTL is how long the latest block number is reused before asking the node again
+const blockNumberTTL = 250 * time.Millisecond
+
+// flightTimeout bounds a quote shared by concurrent requests. It doesn't run on
+// the context of the request that started it, so that request giving up
+// doesn't fail the others.
+const flightTimeout = 15 * time.Second
+
+// QuoteStore stores encoded quotes by key until they expire
+type QuoteStore interface {
+	GetQuote(ctx context.Context, key string) ([]byte, error)
+	SaveQuote(ctx context.Context, key string, data []byte, ttl time.Duration) error
+}
+
+// QuoteCacheConfig configures the quote cache
+type QuoteCacheConfig struct {
+	Store        QuoteStore
+	ChainID      uint64
+	TTL          time.Duration // How long a quote is kept, it is only reused within its block
+	AmountDigits int           // Significant digits amounts are rounded to, 0 keys on the exact amount
+}
+
+// quoteCache caches quotes per block and collapses concurrent identical requests
+type quoteCache struct {
+	QuoteCacheConfig
+	flight singleflight.Group
+
+	blockMu   sync.Mutex
+	block     uint64
+	blockTime time.Time
+}
+
+// EnableQuoteCache caches the results of CachedQuote in the store
+func (s *Service) EnableQuoteCache(cfg QuoteCacheConfig) {
+	s.quoteCache = &quoteCache{QuoteCacheConfig: cfg}
+}
+
+// QuotedAmount returns the amount CachedQuote quotes for amountIn: rounded to
+// the cache's significant digits, or amountIn if quotes aren't cached
+func (s *Service) QuotedAmount(amountIn *big.Int) *big.Int {
+	if s.quoteCache == nil {
+		return amountIn
+	}
+	return BucketAmount(amountIn, s.quoteCache.AmountDigits)
+}
+
+// CachedQuote is Quote cached per block. Identical requests within a block are
+// served from the store and concurrent ones share a single computation. The
+// amount is rounded to the cache's significant digits before quoting so nearby
+// amounts share a result, see QuotedAmount. Every route is cached, so ranking
+// is up to the caller. It also returns the Cache-Status of the response.
+func (s *Service) CachedQuote(ctx context.Context, tokenIn, tokenOut common.Address, amountIn *big.Int) (*AggregatorResult, string, error) {
+	cache := s.quoteCache
+	if cache == nil {
+		result, err := s.Quote(ctx, tokenIn, tokenOut, amountIn)
+		return result, CacheBypass, err
+	}
+
+	amountIn = s.QuotedAmount(amountIn)
+
+	block, err := cache.latestBlock(ctx, s.nodeURL)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to get block number, not caching quote", "error", err)
+		result, err := s.Quote(ctx, tokenIn, tokenOut, amountIn)
+		return result, CacheBypass, err
+	}
+
+	key := fmt.Sprintf("%d:%s:%s:%s:%d", cache.ChainID, tokenIn.Hex(), tokenOut.Hex(), amountIn.String(), block)
+
+	if data, err := cache.Store.GetQuote(ctx, key); err != nil {
+		logger.ErrorContext(ctx, "Failed to read quote cache", "error", err)
+	} else if data != nil {
+		var result AggregatorResult
+		if err := json.Unmarshal(data, &result); err == nil {
+			return &result, CacheHit, nil
+		}
+		logger.WarnContext(ctx, "Failed to decode cached quote", "error", err)
+	}
+
+	value, err, shared := cache.flight.Do(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flightTimeout)
+		defer cancel()
+
+		result, err := s.Quote(ctx, tokenIn, tokenOut, amountIn)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to encode quote", "error", err)
+			return result, nil
+		}
+		if err := cache.Store.SaveQuote(ctx, key, data, cache.TTL); err != nil {
+			logger.ErrorContext(ctx, "Failed to save quote to cache", "error", err)
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, CacheMiss, err
+	}
+
+	status := CacheMiss
+	if shared {
+		status = CacheCollapsed
+	}
+	return value.(*AggregatorResult), status, nil
+}
+
+// latestBlock returns the latest block number, asking the node at most once
+// per blockNumberTTL
+func (c *quoteCache) latestBlock(ctx context.Context, nodeURL string) (uint64, error) {
+	c.blockMu.Lock()
+	if time.Since(c.blockTime) < blockNumberTTL {
+		block := c.block
+		c.blockMu.Unlock()
+		return block, nil
+	}
+	c.blockMu.Unlock()
+
+	value, err, _ := c.flight.Do("block", func() (interface{}, error) {
+		client, err := node.Dial(nodeURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to node: %v", err)
+		}
+		defer client.Close()
+
+		var block *big.Int
+		if err := client.CallCtx(ctx, eth.BlockNumber().Returns(&block)); err != nil {
+			return nil, fmt.Errorf("failed to get block number: %v", err)
+		}
+
+		c.blockMu.Lock()
+		c.block, c.blockTime = block.Uint64(), time.Now()
+		c.blockMu.Unlock()
+		return block.Uint64(), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return value.(uint64), nil
+}
+
+// BucketAmount rounds an amount down to digits significant digits, 0 keeps it exact
+func BucketAmount(amount *big.Int, digits int) *big.Int {
+	if digits <= 0 || amount.Sign() <= 0 {
+		return amount
+	}
+
+	length := len(amount.String())
+	if length <= digits {
+		return amount
+	}
+
+	unit := utils.Pow10(length - digits)
+	bucket := new(big.Int).Div(amount, unit)
+	return bucket.Mul(bucket, unit)
+}
@@ -0,0 +1,33 @@
+package aggregator
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBucketAmount(t *testing.T) {
+	tests := []struct {
+		amount string
+		digits int
+		want   string
+	}{
+		{"123456789", 3, "123000000"},
+		{"123999999", 3, "123000000"}, // Rounds down
+		{"999", 3, "999"},
+		{"99", 3, "99"},
+		{"1000000000000000001", 1, "1000000000000000000"},
+		{"123456789", 0, "123456789"},  // 0 keeps it exact
+		{"123456789", -1, "123456789"}, // As does a negative
+		{"0", 3, "0"},
+		{"-123456", 2, "-123456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			amount, _ := new(big.Int).SetString(tt.amount, 10)
+			if got := BucketAmount(amount, tt.digits); got.String() != tt.want {
+				t.Errorf("BucketAmount(%s, %d) = %s, want %s", tt.amount, tt.digits, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package aggregator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
+)
+
+// Estimated gas of wrapping and unwrapping the native token around a swap
+const (
+	wrapGas   = 45000
+	unwrapGas = 35000
+)
+
+// RankOutput is the default ranking
+const RankOutput = "output"
+
+// Ranking compares two routes, returning a negative number if a is better
+type Ranking func(a, b *RouteQuote) int
+
+// rankings are the strategies selectable by name. Every strategy ends with
+// compareRoutes so the order is the same on every run.
+var rankings = map[string]Ranking{
+	// Most output after transfer fees, then least gas
+	RankOutput: compareRoutes,
+	// Least gas, then most output
+	"gas": func(a, b *RouteQuote) int {
+		if c := compareGas(a, b); c != 0 {
+			return c
+		}
+		return compareRoutes(a, b)
+	},
+	// Lowest pool fee, then most output
+	"fee": func(a, b *RouteQuote) int {
+		if a.Fee != b.Fee {
+			if a.Fee < b.Fee {
+				return -1
+			}
+			return 1
+		}
+		return compareRoutes(a, b)
+	},
+}
+
+// GetRanking returns a ranking by name, "" is RankOutput
+func GetRanking(name string) (Ranking, error) {
+	if name == "" {
+		name = RankOutput
+	}
+	ranking, ok := rankings[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown ranking %q, must be one of %s", name, strings.Join(RankingNames(), ", "))
+	}
+	return ranking, nil
+}
+
+// RankingNames returns the names of the available rankings
+func RankingNames() []string {
+	names := make([]string, 0, len(rankings))
+	for name := range rankings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RankRoutes sorts routes best first, keeping only the best limit routes if
+// limit is positive. Routes are sorted in place.
+func RankRoutes(routes []RouteQuote, ranking Ranking, limit int) []RouteQuote {
+	slices.SortStableFunc(routes, func(a, b RouteQuote) int {
+		return ranking(&a, &b)
+	})
+	if limit > 0 && len(routes) > limit {
+		routes = routes[:limit]
+	}
+	return routes
+}
+
+// Ranked returns a copy of the result with its routes ranked by ranking and
+// limited to the best limit routes. The result itself isn't changed since
+// cached results are shared between requests.
+func (r *AggregatorResult) Ranked(ranking Ranking, limit int) *AggregatorResult {
+	ranked := *r
+	ranked.AllRoutes = RankRoutes(slices.Clone(r.AllRoutes), ranking, limit)
+	if len(ranked.AllRoutes) > 0 {
+		ranked.BestRoute = ranked.AllRoutes[0]
+	}
+	return &ranked
+}
+
+// compareRoutes orders routes by output, highest first, then gas, protocol
+// priority, fee and pool address so no two distinct routes tie
+func compareRoutes(a, b *RouteQuote) int {
+	if c := b.AmountOut.Cmp(a.AmountOut); c != 0 {
+		return c
+	}
+	if c := compareGas(a, b); c != 0 {
+		return c
+	}
+	if c := protocolPriority(a.Protocol) - protocolPriority(b.Protocol); c != 0 {
+		return c
+	}
+	if a.Fee != b.Fee {
+		if a.Fee < b.Fee {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(strings.ToLower(a.PoolAddress), strings.ToLower(b.PoolAddress))
+}
+
+// compareGas orders routes by estimated gas, lowest first
+func compareGas(a, b *RouteQuote) int {
+	switch {
+	case a.Gas < b.Gas:
+		return -1
+	case a.Gas > b.Gas:
+		return 1
+	}
+	return 0
+}
+
+// protocolPriority returns a protocol's priority, unknown protocols come last
+// and the native wrapper, which has no pool, comes first
+func protocolPriority(name string) int {
+	if name == NativeWrapperProtocol {
+		return 0
+	}
+	if protocol, ok := protocols.GetProtocolByDisplayName(name); ok {
+		return protocol.Priority
+	}
+	return int(^uint(0) >> 1)
+}
@@ -0,0 +1,106 @@
+package aggregator
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/utils"
+)
+
+// route creates a route with an output amount, the only field every ranking reads
+func route(id string, amountOut int64, gas uint64, protocol string, fee uint64) RouteQuote {
+	return RouteQuote{
+		Protocol:    protocol,
+		PoolAddress: id,
+		Fee:         fee,
+		AmountOut:   utils.NewAmount(big.NewInt(amountOut), 18),
+		Gas:         gas,
+	}
+}
+
+func TestCompareRoutes(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b RouteQuote
+		want int
+	}{
+		{"more output first", route("a", 200, 900, "Uniswap V3", 3000), route("b", 100, 100, "Uniswap V3", 500), -1},
+		{"less output last", route("a", 100, 100, "Uniswap V3", 500), route("b", 200, 900, "Uniswap V3", 3000), 1},
+		{"less gas first", route("a", 100, 100, "Sushiswap V3", 3000), route("b", 100, 200, "Uniswap V3", 500), -1},
+		{"protocol priority", route("a", 100, 100, "Uniswap V3", 3000), route("b", 100, 100, "Sushiswap V3", 500), -1},
+		{"unknown protocol last", route("a", 100, 100, "Unknown", 500), route("b", 100, 100, "Naddotfun", 500), 1},
+		{"native wrapper first", route("a", 100, 100, NativeWrapperProtocol, 0), route("b", 100, 100, "Uniswap V3", 0), -1},
+		{"lower fee first", route("a", 100, 100, "Uniswap V3", 500), route("b", 100, 100, "Uniswap V3", 3000), -1},
+		{"pool address ignores case", route("0xAB", 100, 100, "Uniswap V3", 500), route("0xab", 100, 100, "Uniswap V3", 500), 0},
+		{"pool address", route("0xab", 100, 100, "Uniswap V3", 500), route("0xAC", 100, 100, "Uniswap V3", 500), -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compareRoutes(&tt.a, &tt.b); sign(got) != tt.want {
+				t.Errorf("compareRoutes = %d, want %d", got, tt.want)
+			}
+			if got := compareRoutes(&tt.b, &tt.a); sign(got) != -tt.want {
+				t.Errorf("compareRoutes reversed = %d, want %d", got, -tt.want)
+			}
+		})
+	}
+}
+
+func TestRankRoutes(t *testing.T) {
+	routes := []RouteQuote{
+		route("cheap", 100, 50000, "Uniswap V3", 3000),
+		route("best", 300, 150000, "Uniswap V3", 3000),
+		route("low-fee", 200, 120000, "Uniswap V3", 100),
+		route("second", 200, 100000, "Uniswap V3", 500),
+	}
+
+	tests := []struct {
+		ranking string
+		limit   int
+		want    []string
+	}{
+		{"", 0, []string{"best", "second", "low-fee", "cheap"}},
+		{RankOutput, 2, []string{"best", "second"}},
+		{"gas", 0, []string{"cheap", "second", "low-fee", "best"}},
+		{"GAS", 1, []string{"cheap"}},
+		{"fee", 0, []string{"low-fee", "second", "best", "cheap"}},
+		{"fee", 10, []string{"low-fee", "second", "best", "cheap"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ranking, func(t *testing.T) {
+			ranking, err := GetRanking(tt.ranking)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ranked := RankRoutes(slices.Clone(routes), ranking, tt.limit)
+
+			got := make([]string, len(ranked))
+			for i, route := range ranked {
+				got[i] = route.PoolAddress
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("RankRoutes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRankingUnknown(t *testing.T) {
+	if _, err := GetRanking("cheapest"); err == nil {
+		t.Error("GetRanking accepted an unknown ranking")
+	}
+}
+
+// sign reduces a comparison to -1, 0 or 1
+func sign(c int) int {
+	switch {
+	case c < 0:
+		return -1
+	case c > 0:
+		return 1
+	}
+	return 0
+}
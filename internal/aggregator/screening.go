@@ -0,0 +1,285 @@
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/uniswap"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/risk"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TokenBehaviour returns the cached transfer behaviour of a token if it is still fresh
+func (s *Service) TokenBehaviour(token common.Address) (*tokens.Behaviour, bool) {
+	s.screeningMu.RLock()
+	defer s.screeningMu.RUnlock()
+
+	behaviour, ok := s.behaviours[token]
+	if !ok || time.Since(time.Unix(behaviour.ProbedAt, 0)) > screeningTTL {
+		return nil, false
+	}
+	return behaviour, true
+}
+
+// SetTokenBehaviour caches the transfer behaviour of a token, e.g. one loaded from Redis
+func (s *Service) SetTokenBehaviour(token common.Address, behaviour *tokens.Behaviour) {
+	if behaviour == nil {
+		return
+	}
+
+	s.screeningMu.Lock()
+	defer s.screeningMu.Unlock()
+	s.behaviours[token] = behaviour
+}
+
+// TokenRisk returns the cached risk report of a token if it is still fresh
+func (s *Service) TokenRisk(token common.Address) (*risk.Report, bool) {
+	s.screeningMu.RLock()
+	defer s.screeningMu.RUnlock()
+
+	report, ok := s.risks[token]
+	if !ok || time.Since(time.Unix(report.ScreenedAt, 0)) > screeningTTL {
+		return nil, false
+	}
+	return report, true
+}
+
+// SetTokenRisk caches the risk report of a token, e.g. one loaded from Redis
+func (s *Service) SetTokenRisk(token common.Address, report *risk.Report) {
+	if report == nil {
+		return
+	}
+
+	s.screeningMu.Lock()
+	defer s.screeningMu.Unlock()
+	s.risks[token] = report
+}
+
+// cachedScreening returns the cached behaviour and risk of a token if it was
+// screened recently. A token whose buy reverted is screened with a report but
+// no behaviour, and isn't screened again until the report expires.
+func (s *Service) cachedScreening(token common.Address) (*tokens.Behaviour, *risk.Report, bool) {
+	report, hasReport := s.TokenRisk(token)
+	if !hasReport {
+		return nil, nil, false
+	}
+	if report.BuyBlocked {
+		return nil, report, true
+	}
+	behaviour, hasBehaviour := s.TokenBehaviour(token)
+	return behaviour, report, hasBehaviour
+}
+
+// ForgetToken drops the cached behaviour and risk of a token so it is screened again
+func (s *Service) ForgetToken(token common.Address) {
+	s.screeningMu.Lock()
+	defer s.screeningMu.Unlock()
+	delete(s.behaviours, token)
+	delete(s.risks, token)
+}
+
+// ScreenToken detects the transfer behaviour and risk of a token, screening
+// it through the same pool quotes would: the most liquid of its pools with the
+// wrapped native token. The result is cached and saved with the token.
+func (s *Service) ScreenToken(ctx context.Context, token common.Address) (*tokens.Behaviour, *risk.Report, error) {
+	// The native token and its wrapper are standard
+	if tokens.IsNative(token) || token == tokens.GetNativeToken().Wrapped {
+		return &tokens.Behaviour{ProbedAt: time.Now().Unix()}, nil, nil
+	}
+
+	if behaviour, report, ok := s.cachedScreening(token); ok {
+		return behaviour, report, nil
+	}
+
+	candidates, err := s.wrappedPools(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil, fmt.Errorf("no pool found to screen token %s", token.Hex())
+	}
+
+	pool := s.screeningPools(ctx, map[common.Address][]common.Address{token: candidates})[token]
+	return s.screen(ctx, token, pool)
+}
+
+// wrappedPools looks up the pools pairing token with the wrapped native token
+// in one RPC batch, in order of protocol priority
+func (s *Service) wrappedPools(ctx context.Context, token common.Address) ([]common.Address, error) {
+	forks := protocols.GetUniswapForks()
+	sort.Slice(forks, func(i, j int) bool { return forks[i].Priority < forks[j].Priority })
+
+	wrapped := tokens.GetNativeToken().Wrapped
+	var lookups []uniswap.PoolLookup
+	for _, protocol := range forks {
+		for _, feeTier := range protocol.FeeTiers {
+			lookups = append(lookups, uniswap.PoolLookup{TokenA: token, TokenB: wrapped, Fee: feeTier, Factory: protocol.FactoryAddress})
+		}
+	}
+
+	addresses, errs, err := uniswap.GetPoolAddresses(ctx, lookups, s.nodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool addresses: %v", err)
+	}
+
+	var pools []common.Address
+	for i, address := range addresses {
+		if errors.Is(errs[i], uniswap.ErrBatchFailed) {
+			return nil, fmt.Errorf("failed to get pool addresses: %v", errs[i])
+		}
+		if errs[i] == nil && address != (common.Address{}) && !slices.Contains(pools, address) {
+			pools = append(pools, address)
+		}
+	}
+	return pools, nil
+}
+
+// screeningPools picks the pool each token is screened through from its
+// candidates: the one holding the most of the token, as the simulation moves a
+// share of the pool's balance. Candidates are in order of preference, which
+// breaks ties and is used if the balances can't be read.
+func (s *Service) screeningPools(ctx context.Context, candidates map[common.Address][]common.Address) map[common.Address]common.Address {
+	var holdings []tokens.Holding
+	for token, pools := range candidates {
+		// The wrapped native token isn't screened
+		if token == tokens.GetNativeToken().Wrapped {
+			continue
+		}
+		for _, pool := range pools {
+			holdings = append(holdings, tokens.Holding{Token: token, Holder: pool})
+		}
+	}
+
+	balances, errs, err := tokens.BalanceBatch(ctx, holdings, s.nodeURL)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to read pool balances for screening", "error", err)
+		balances = nil
+	}
+
+	screenPools := make(map[common.Address]common.Address, len(candidates))
+	best := make(map[common.Address]*big.Int, len(candidates))
+	for token, pools := range candidates {
+		if len(pools) > 0 {
+			screenPools[token] = pools[0]
+		}
+	}
+	for i, holding := range holdings {
+		if balances == nil || errs[i] != nil || balances[i] == nil {
+			continue
+		}
+		if current, ok := best[holding.Token]; !ok || balances[i].Cmp(current) > 0 {
+			best[holding.Token] = balances[i]
+			screenPools[holding.Token] = holding.Holder
+		}
+	}
+	return screenPools
+}
+
+// screenFor returns the cached behaviour and risk of a token or screens it
+// through pool. Failures are logged and treated as unknown.
+func (s *Service) screenFor(ctx context.Context, token, pool common.Address) (*tokens.Behaviour, *risk.Report) {
+	if token == tokens.GetNativeToken().Wrapped {
+		return nil, nil
+	}
+
+	if behaviour, report, ok := s.cachedScreening(token); ok {
+		return behaviour, report
+	}
+
+	behaviour, report, err := s.screen(ctx, token, pool)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to screen token", "token", token.Hex(), "error", err)
+		return nil, nil
+	}
+	return behaviour, report
+}
+
+// screen screens a token through pool, caching the result and saving it with
+// the token's metadata
+func (s *Service) screen(ctx context.Context, token, pool common.Address) (*tokens.Behaviour, *risk.Report, error) {
+	logger.DebugContext(ctx, "Screening token", "token", token.Hex(), "pool", pool.Hex())
+	report, behaviour, err := risk.Screen(ctx, token, pool, s.nodeURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.SetTokenBehaviour(token, behaviour)
+	s.SetTokenRisk(token, report)
+	if err := s.tokens.SaveScreening(ctx, token, behaviour, report); err != nil {
+		logger.ErrorContext(ctx, "Failed to save token screening", "token", token.Hex(), "error", err)
+	}
+	return behaviour, report, nil
+}
+
+// applyRisk warns about tokens with a high risk score
+func applyRisk(route *RouteQuote, symbol string, report *risk.Report) {
+	if report == nil || report.Level != risk.LevelHigh {
+		return
+	}
+	route.Warnings = append(route.Warnings, fmt.Sprintf(
+		"%s has a high risk score of %d: %s", symbol, report.Score, strings.Join(report.Reasons, ", "),
+	))
+}
+
+// applyBehaviour adjusts a route for fee-on-transfer output and adds warnings
+// for the behaviour of both tokens. Input fees are handled by quoting the
+// reduced amount, so AmountIn is reset to what the user actually sends.
+func applyBehaviour(
+	route *RouteQuote,
+	amountIn *big.Int,
+	tokenInDecimals, tokenOutDecimals uint8,
+	behaviourIn, behaviourOut *tokens.Behaviour,
+) {
+	if behaviourIn != nil {
+		if behaviourIn.FeeOnTransfer {
+			route.AmountIn = utils.NewAmount(amountIn, tokenInDecimals)
+			route.Warnings = append(route.Warnings, fmt.Sprintf(
+				"%s charges a %s%% transfer fee, quoted with the amount the pool receives; routers without fee-on-transfer support may revert",
+				route.TokenIn, formatBps(behaviourIn.TransferFeeBps),
+			))
+		}
+		if behaviourIn.Rebasing {
+			route.Warnings = append(route.Warnings, fmt.Sprintf(
+				"%s is a rebasing token, the amount spent may differ slightly from the quote", route.TokenIn,
+			))
+		}
+	}
+
+	if behaviourOut != nil {
+		if behaviourOut.FeeOnTransfer {
+			route.AmountOut = utils.NewAmount(deductFee(route.AmountOut.Raw(), behaviourOut.TransferFeeBps), tokenOutDecimals)
+			route.Warnings = append(route.Warnings, fmt.Sprintf(
+				"%s charges a %s%% transfer fee, deducted from the quoted output",
+				route.TokenOut, formatBps(behaviourOut.TransferFeeBps),
+			))
+		}
+		if behaviourOut.Rebasing {
+			route.Warnings = append(route.Warnings, fmt.Sprintf(
+				"%s is a rebasing token, the received balance may drift from the quote", route.TokenOut,
+			))
+		}
+	}
+}
+
+// deductFee returns amount less a fee in basis points
+func deductFee(amount *big.Int, feeBps uint64) *big.Int {
+	if feeBps >= 10000 {
+		return big.NewInt(0)
+	}
+	net := new(big.Int).Mul(amount, big.NewInt(int64(10000-feeBps)))
+	return net.Div(net, big.NewInt(10000))
+}
+
+// formatBps formats basis points as a percentage, e.g. 250 -> "2.5"
+func formatBps(bps uint64) string {
+	return utils.FromWei(new(big.Int).SetUint64(bps), 2)
+}
@@ -0,0 +1,116 @@
+package aggregator
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node/nodetest"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/risk"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+)
+
+var (
+	funcGetPool   = w3.MustNewFunc("getPool(address,address,uint24)", "address")
+	funcBalanceOf = w3.MustNewFunc("balanceOf(address)", "uint256")
+)
+
+func TestCachedScreening(t *testing.T) {
+	now := time.Now().Unix()
+	stale := time.Now().Add(-2 * screeningTTL).Unix()
+
+	tests := []struct {
+		name      string
+		behaviour *tokens.Behaviour
+		report    *risk.Report
+		cached    bool
+	}{
+		{"unscreened", nil, nil, false},
+		{"behaviour only", &tokens.Behaviour{ProbedAt: now}, nil, false},
+		{"screened", &tokens.Behaviour{ProbedAt: now}, &risk.Report{ScreenedAt: now}, true},
+		{"honeypot", nil, &risk.Report{ScreenedAt: now, BuyBlocked: true}, true},
+		{"stale honeypot", nil, &risk.Report{ScreenedAt: stale, BuyBlocked: true}, false},
+		{"stale behaviour", &tokens.Behaviour{ProbedAt: stale}, &risk.Report{ScreenedAt: now}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewService("", nil)
+			token := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+			s.SetTokenBehaviour(token, test.behaviour)
+			s.SetTokenRisk(token, test.report)
+
+			_, report, cached := s.cachedScreening(token)
+			if cached != test.cached {
+				t.Fatalf("cached = %v, want %v", cached, test.cached)
+			}
+			if cached && report != test.report {
+				t.Errorf("report = %+v, want %+v", report, test.report)
+			}
+		})
+	}
+}
+
+func TestScreeningPoolChoice(t *testing.T) {
+	token := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	uniswapPool := common.HexToAddress("0x00000000000000000000000000000000000000b1")
+	sushiPool := common.HexToAddress("0x00000000000000000000000000000000000000b2")
+
+	factories := make(map[string]common.Address)
+	for _, protocol := range protocols.GetUniswapForks() {
+		factories[protocol.Name] = protocol.FactoryAddress
+	}
+
+	tests := []struct {
+		name     string
+		balances map[common.Address]*big.Int // nil reverts balanceOf
+		want     common.Address
+	}{
+		{"most liquid", map[common.Address]*big.Int{uniswapPool: big.NewInt(100), sushiPool: big.NewInt(500)}, sushiPool},
+		{"tie keeps priority", map[common.Address]*big.Int{uniswapPool: big.NewInt(500), sushiPool: big.NewInt(500)}, uniswapPool},
+		{"balances unreadable", nil, uniswapPool},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := nodetest.New(t)
+			// Uniswap V3 has a pool at 500, V2 shares its factory so finds it
+			// again, and Sushiswap, next in priority, has one at 3000
+			node.Handle(factories["Uniswap V3"], funcGetPool, func(args []any) ([]any, error) {
+				if fee := args[2].(*big.Int).Uint64(); fee == 500 || fee == 30 {
+					return []any{uniswapPool}, nil
+				}
+				return []any{common.Address{}}, nil
+			})
+			node.Handle(factories["Sushiswap V3"], funcGetPool, func(args []any) ([]any, error) {
+				if args[2].(*big.Int).Uint64() == 3000 {
+					return []any{sushiPool}, nil
+				}
+				return []any{common.Address{}}, nil
+			})
+			if test.balances != nil {
+				node.Handle(token, funcBalanceOf, func(args []any) ([]any, error) {
+					return []any{test.balances[args[0].(common.Address)]}, nil
+				})
+			}
+
+			s := NewService(node.URL, nil)
+			ctx := context.Background()
+			candidates, err := s.wrappedPools(ctx, token)
+			if err != nil {
+				t.Fatalf("wrappedPools: %v", err)
+			}
+			if want := []common.Address{uniswapPool, sushiPool}; !slices.Equal(candidates, want) {
+				t.Fatalf("candidates = %v, want %v", candidates, want)
+			}
+
+			pool := s.screeningPools(ctx, map[common.Address][]common.Address{token: candidates})[token]
+			if pool != test.want {
+				t.Errorf("pool = %s, want %s", pool.Hex(), test.want.Hex())
+			}
+		})
+	}
+}
@@ -2,47 +2,168 @@ package aggregator
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
 	"math/big"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/bitcoinbrisbane/defi-aggregator/internal/utils"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
 	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/uniswap"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/metrics"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
 	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/risk"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tracing"
 	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var logger = logging.For("aggregator")
+
 // RouteQuote represents a single quote from a specific protocol and pool
 type RouteQuote struct {
-	Protocol     string  `json:"protocol"`     // Protocol name (e.g., "Uniswap V3")
-	PoolAddress  string  `json:"poolAddress"`  // Pool address
-	Fee          uint64  `json:"fee"`          // Fee tier (e.g., 500, 3000, 10000)
-	TokenIn      string  `json:"tokenIn"`      // Input token symbol
-	TokenOut     string  `json:"tokenOut"`     // Output token symbol
-	AmountIn     string  `json:"amountIn"`     // Input amount (human-readable)
-	AmountOut    string  `json:"amountOut"`    // Output amount (human-readable)
-	AmountOutRaw *big.Float `json:"-"`         // Raw output amount for sorting (not serialized)
+	Protocol     string       `json:"protocol"`               // Protocol name (e.g., "Uniswap V3")
+	PoolAddress  string       `json:"poolAddress"`            // Pool address
+	Fee          uint64       `json:"fee"`                    // Fee tier (e.g., 500, 3000, 10000)
+	TokenIn      string       `json:"tokenIn"`                // Input token symbol
+	TokenOut     string       `json:"tokenOut"`               // Output token symbol
+	AmountIn     utils.Amount `json:"amountIn"`               // Input amount
+	AmountOut    utils.Amount `json:"amountOut"`              // Output amount
+	Gas          uint64       `json:"gas"`                    // Estimated gas, including wrapping and unwrapping
+	Router       string       `json:"router"`                 // Swap router (or wrapped native token) the swap is sent to, empty if swaps can't be built
+	WrapNative   bool         `json:"wrapNative,omitempty"`   // Input is native and must be wrapped before the swap
+	UnwrapNative bool         `json:"unwrapNative,omitempty"` // Output is wrapped native and must be unwrapped after the swap
+	Warnings     []string     `json:"warnings,omitempty"`     // Token behaviour the user should know about
 }
 
+// NativeWrapperProtocol is the protocol name used for direct wrap/unwrap routes
+const NativeWrapperProtocol = "Native Wrapper"
+
 // AggregatorResult contains the best routes across all protocols
 type AggregatorResult struct {
-	BestRoute RouteQuote   `json:"bestRoute"`  // Best overall route
-	AllRoutes []RouteQuote `json:"allRoutes"`  // All available routes sorted by output amount
+	BestRoute    RouteQuote            `json:"bestRoute"`              // Best overall route
+	AllRoutes    []RouteQuote          `json:"allRoutes"`              // All available routes sorted by output amount
+	TokenInRisk  *risk.Report          `json:"tokenInRisk,omitempty"`  // Risk screening of the input token
+	TokenOutRisk *risk.Report          `json:"tokenOutRisk,omitempty"` // Risk screening of the output token
+	TokenIn      *models.TokenMetadata `json:"tokenIn,omitempty"`      // Input token metadata, set by Quote
+	TokenOut     *models.TokenMetadata `json:"tokenOut,omitempty"`     // Output token metadata, set by Quote
+}
+
+// screeningTTL is how long token behaviour and risk are trusted before screening again
+const screeningTTL = time.Hour
+
+// TokenResolver looks up token metadata, e.g. through a cache, and keeps the
+// screening of tokens with it so other instances don't screen them again
+type TokenResolver interface {
+	GetBatch(ctx context.Context, addresses []common.Address) ([]*models.TokenMetadata, []string, []error, error)
+	SaveScreening(ctx context.Context, address common.Address, behaviour *tokens.Behaviour, report *risk.Report) error
+}
+
+// ErrNodeUnavailable is wrapped by errors caused by the node not being reachable
+var ErrNodeUnavailable = errors.New("node unavailable")
+
+// TokenError is returned by Quote when a token's metadata can't be resolved
+type TokenError struct {
+	Side    string // "tokenIn" or "tokenOut", "tokenA" or "tokenB" for DiscoverPools
+	Address common.Address
+	Err     error
+}
+
+func (e *TokenError) Error() string {
+	return fmt.Sprintf("failed to get %s metadata: %v", e.Side, e.Err)
+}
+
+func (e *TokenError) Unwrap() error {
+	return e.Err
+}
+
+// PoolRegistry records the pools found while quoting
+type PoolRegistry interface {
+	AddProtocolPair(ctx context.Context, protocolName, contractAddress string, fee uint64, pair pairs.TokenPair) error
 }
 
 // Service handles DEX aggregation logic
 type Service struct {
-	nodeURL string
+	nodeURL    string
+	tokens     TokenResolver
+	quoteCache *quoteCache  // nil unless EnableQuoteCache is called
+	pools      PoolRegistry // nil unless SetPoolRegistry is called
+
+	screeningMu sync.RWMutex
+	behaviours  map[common.Address]*tokens.Behaviour
+	risks       map[common.Address]*risk.Report
 }
 
 // NewService creates a new aggregator service
-func NewService(nodeURL string) *Service {
+func NewService(nodeURL string, resolver TokenResolver) *Service {
 	return &Service{
-		nodeURL: nodeURL,
+		nodeURL:    nodeURL,
+		tokens:     resolver,
+		behaviours: make(map[common.Address]*tokens.Behaviour),
+		risks:      make(map[common.Address]*risk.Report),
 	}
 }
 
+// SetPoolRegistry records every pool found while quoting in registry
+func (s *Service) SetPoolRegistry(registry PoolRegistry) {
+	s.pools = registry
+}
+
+// ResolveTokens gets the metadata of a pair of tokens through the resolver and
+// shares any cached screening with the service
+func (s *Service) ResolveTokens(ctx context.Context, tokenIn, tokenOut common.Address) (*models.TokenMetadata, *models.TokenMetadata, error) {
+	metadata, _, errs, err := s.tokens.GetBatch(ctx, []common.Address{tokenIn, tokenOut})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get token metadata: %v: %w", err, ErrNodeUnavailable)
+	}
+	if errs[0] != nil {
+		return nil, nil, &TokenError{Side: "tokenIn", Address: tokenIn, Err: errs[0]}
+	}
+	if errs[1] != nil {
+		return nil, nil, &TokenError{Side: "tokenOut", Address: tokenOut, Err: errs[1]}
+	}
+
+	for i, token := range []common.Address{tokenIn, tokenOut} {
+		s.SetTokenBehaviour(token, metadata[i].Behaviour)
+		s.SetTokenRisk(token, metadata[i].Risk)
+	}
+	return metadata[0], metadata[1], nil
+}
+
+// Quote resolves the decimals and symbols of both tokens through the cached
+// token service and finds the best route
+func (s *Service) Quote(ctx context.Context, tokenIn, tokenOut common.Address, amountIn *big.Int) (*AggregatorResult, error) {
+	metadataIn, metadataOut, err := s.ResolveTokens(ctx, tokenIn, tokenOut)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.FindBestRoute(
+		ctx,
+		tokenIn,
+		tokenOut,
+		amountIn,
+		metadataIn.Decimals,
+		metadataOut.Decimals,
+		metadataIn.Symbol,
+		metadataOut.Symbol,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result.TokenIn = metadataIn
+	result.TokenOut = metadataOut
+	warnInferredDecimals(result)
+	return result, nil
+}
+
 // FindBestRoute finds the best route for a swap across all supported DEX protocols
 func (s *Service) FindBestRoute(
 	ctx context.Context, 
@@ -51,6 +172,159 @@ func (s *Service) FindBestRoute(
 	tokenInDecimals, tokenOutDecimals uint8,
 	tokenInSymbol, tokenOutSymbol string,
 ) (*AggregatorResult, error) {
+	ctx, span := tracing.Start(ctx, "FindBestRoute")
+	defer span.End()
+
+	// The native currency is quoted through its wrapped ERC-20
+	wrapIn := tokens.IsNative(tokenIn)
+	unwrapOut := tokens.IsNative(tokenOut)
+	tokenIn = tokens.QuoteAddress(tokenIn)
+	tokenOut = tokens.QuoteAddress(tokenOut)
+
+	// Native <-> wrapped native is a 1:1 conversion that doesn't need a pool
+	if tokenIn == tokenOut {
+		if wrapIn == unwrapOut {
+			return &AggregatorResult{}, nil
+		}
+		route := nativeWrapperRoute(amountIn, tokenInDecimals, tokenInSymbol, tokenOutSymbol, wrapIn)
+		return &AggregatorResult{
+			BestRoute: route,
+			AllRoutes: []RouteQuote{route},
+		}, nil
+	}
+
+	allRoutes := s.collectQuotes(
+		ctx,
+		tokenIn,
+		tokenOut,
+		amountIn,
+		tokenInDecimals,
+		tokenOutDecimals,
+		tokenInSymbol,
+		tokenOutSymbol,
+	)
+
+	// Screen the tokens through their most liquid pool and account for transfer fees
+	var behaviourIn, behaviourOut *tokens.Behaviour
+	var riskIn, riskOut *risk.Report
+	if len(allRoutes) > 0 {
+		// Pools are considered best route first
+		ranked := RankRoutes(slices.Clone(allRoutes), compareRoutes, 0)
+		pools := make([]common.Address, len(ranked))
+		for i, route := range ranked {
+			pools[i] = common.HexToAddress(route.PoolAddress)
+		}
+		screenPools := s.screeningPools(ctx, map[common.Address][]common.Address{tokenIn: pools, tokenOut: pools})
+		behaviourIn, riskIn = s.screenFor(ctx, tokenIn, screenPools[tokenIn])
+		behaviourOut, riskOut = s.screenFor(ctx, tokenOut, screenPools[tokenOut])
+
+		// The pool only receives what is left after the input token's fee
+		if behaviourIn != nil && behaviourIn.FeeOnTransfer {
+			effectiveIn := deductFee(amountIn, behaviourIn.TransferFeeBps)
+			allRoutes = s.collectQuotes(
+				ctx,
+				tokenIn,
+				tokenOut,
+				effectiveIn,
+				tokenInDecimals,
+				tokenOutDecimals,
+				tokenInSymbol,
+				tokenOutSymbol,
+			)
+		}
+	}
+
+	return finishRoutes(
+		allRoutes,
+		amountIn,
+		tokenInDecimals,
+		tokenOutDecimals,
+		tokenInSymbol,
+		tokenOutSymbol,
+		behaviourIn,
+		behaviourOut,
+		riskIn,
+		riskOut,
+		wrapIn,
+		unwrapOut,
+	), nil
+}
+
+// finishRoutes accounts for the behaviour and risk of both tokens and the
+// wrap/unwrap steps around each route, and ranks the routes into a result
+func finishRoutes(
+	allRoutes []RouteQuote,
+	amountIn *big.Int,
+	tokenInDecimals, tokenOutDecimals uint8,
+	tokenInSymbol, tokenOutSymbol string,
+	behaviourIn, behaviourOut *tokens.Behaviour,
+	riskIn, riskOut *risk.Report,
+	wrapIn, unwrapOut bool,
+) *AggregatorResult {
+	for i := range allRoutes {
+		applyBehaviour(&allRoutes[i], amountIn, tokenInDecimals, tokenOutDecimals, behaviourIn, behaviourOut)
+		applyRisk(&allRoutes[i], tokenInSymbol, riskIn)
+		applyRisk(&allRoutes[i], tokenOutSymbol, riskOut)
+	}
+
+	// Mark the wrap/unwrap steps needed around each swap
+	for i := range allRoutes {
+		allRoutes[i].WrapNative = wrapIn
+		allRoutes[i].UnwrapNative = unwrapOut
+		if wrapIn {
+			allRoutes[i].Gas += wrapGas
+		}
+		if unwrapOut {
+			allRoutes[i].Gas += unwrapGas
+		}
+	}
+	
+	// Rank routes by output amount (highest first)
+	allRoutes = RankRoutes(allRoutes, compareRoutes, 0)
+	
+	result := &AggregatorResult{
+		AllRoutes:    allRoutes,
+		TokenInRisk:  riskIn,
+		TokenOutRisk: riskOut,
+	}
+	
+	// Set the best route if we have any
+	if len(allRoutes) > 0 {
+		result.BestRoute = allRoutes[0]
+		metrics.BestRoutes.WithLabelValues(result.BestRoute.Protocol).Inc()
+	}
+	
+	return result
+}
+
+// warnInferredDecimals warns on every route about tokens whose decimals were
+// assumed, as their display amounts may be off by orders of magnitude
+func warnInferredDecimals(result *AggregatorResult) {
+	for _, metadata := range []*models.TokenMetadata{result.TokenIn, result.TokenOut} {
+		if metadata == nil || !metadata.DecimalsInferred() {
+			continue
+		}
+		warning := fmt.Sprintf(
+			"%s doesn't report its decimals, %d are assumed so display amounts may be wrong",
+			metadata.Symbol, metadata.Decimals,
+		)
+		for i := range result.AllRoutes {
+			result.AllRoutes[i].Warnings = append(result.AllRoutes[i].Warnings, warning)
+		}
+	}
+	if len(result.AllRoutes) > 0 {
+		result.BestRoute = result.AllRoutes[0]
+	}
+}
+
+// collectQuotes queries every Uniswap-compatible fork in parallel and returns all quotes found
+func (s *Service) collectQuotes(
+	ctx context.Context,
+	tokenIn, tokenOut common.Address,
+	amountIn *big.Int,
+	tokenInDecimals, tokenOutDecimals uint8,
+	tokenInSymbol, tokenOutSymbol string,
+) []RouteQuote {
 	// Get all Uniswap-compatible forks
 	forks := protocols.GetUniswapForks()
 	
@@ -69,8 +343,12 @@ func (s *Service) FindBestRoute(
 			// Create a context with timeout for this query
 			queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 			defer cancel()
+
+			queryCtx, span := tracing.Start(queryCtx, "quote "+protocol.Name,
+				trace.WithAttributes(attribute.String("protocol", protocol.Name)))
 			
 			// Get quotes for this protocol
+			start := time.Now()
 			quotes, err := s.getProtocolQuotes(
 				queryCtx, 
 				protocol, 
@@ -82,9 +360,12 @@ func (s *Service) FindBestRoute(
 				tokenInSymbol,
 				tokenOutSymbol,
 			)
+			metrics.ProtocolQuoteDuration.WithLabelValues(protocol.Name).Observe(time.Since(start).Seconds())
+			span.SetAttributes(attribute.Int("quotes", len(quotes)))
+			tracing.End(span, err)
 			
 			if err != nil {
-				log.Printf("Error getting quotes from %s: %v", protocol.Name, err)
+				logger.ErrorContext(ctx, "Failed to get quotes", "protocol", protocol.Name, "error", err)
 				quotesChan <- []RouteQuote{} // Send empty quotes on error
 				return
 			}
@@ -106,25 +387,13 @@ func (s *Service) FindBestRoute(
 	for quotes := range quotesChan {
 		allRoutes = append(allRoutes, quotes...)
 	}
-	
-	// Sort routes by output amount (highest first)
-	sortRoutesByOutput(allRoutes)
-	
-	result := &AggregatorResult{
-		AllRoutes: allRoutes,
-	}
-	
-	// Set the best route if we have any
-	if len(allRoutes) > 0 {
-		result.BestRoute = allRoutes[0]
-	}
-	
-	return result, nil
+
+	return allRoutes
 }
 
 // getProtocolQuotes gets quotes from a specific protocol
 func (s *Service) getProtocolQuotes(
-	_ context.Context,
+	ctx context.Context,
 	protocol protocols.ProtocolConfig,
 	tokenIn, tokenOut common.Address,
 	amountIn *big.Int,
@@ -135,68 +404,137 @@ func (s *Service) getProtocolQuotes(
 	
 	// For each fee tier in the protocol
 	for _, feeTier := range protocol.FeeTiers {
-		// Get the pool address for this pair and fee tier
-		poolAddress, err := uniswap.GetPoolAddress(
-			tokenIn, 
-			tokenOut, 
-			big.NewInt(int64(feeTier)),
-			protocol.FactoryAddress,
-			s.nodeURL,
-		)
-		
-		// Skip if pool doesn't exist or error occurs
-		if err != nil || poolAddress == (common.Address{}) {
-			continue
-		}
-		
-		// Get quote for this pool
-		amountOut, err := uniswap.GetQuoteExactInputSingle(
+		route, err := s.getFeeTierQuote(
+			ctx,
+			protocol,
+			feeTier,
 			tokenIn,
 			tokenOut,
-			big.NewInt(int64(feeTier)),
 			amountIn,
-			protocol.RouterAddress,
-			s.nodeURL,
+			tokenInDecimals,
+			tokenOutDecimals,
+			tokenInSymbol,
+			tokenOutSymbol,
 		)
-		
 		if err != nil {
 			continue
 		}
 		
-		// Convert amount out to human-readable format
-		amountOutStr := utils.FromWei(amountOut, tokenOutDecimals)
-		amountInStr := utils.FromWei(amountIn, tokenInDecimals)
-		
-		// Parse the output amount as a big.Float for sorting
-		amountOutFloat, _ := new(big.Float).SetString(amountOutStr)
-		
-		// Create route quote
-		route := RouteQuote{
-			Protocol:     protocol.Name,
-			PoolAddress:  poolAddress.String(),
-			Fee:          feeTier,
-			TokenIn:      tokenInSymbol,
-			TokenOut:     tokenOutSymbol,
-			AmountIn:     amountInStr,
-			AmountOut:    amountOutStr,
-			AmountOutRaw: amountOutFloat,
-		}
-		
-		routes = append(routes, route)
+		routes = append(routes, *route)
 	}
 	
 	return routes, nil
 }
 
-// sortRoutesByOutput sorts routes by output amount (highest first)
-func sortRoutesByOutput(routes []RouteQuote) {
-	for i := 0; i < len(routes); i++ {
-		for j := i + 1; j < len(routes); j++ {
-			// Compare AmountOutRaw values (higher is better)
-			if routes[i].AmountOutRaw.Cmp(routes[j].AmountOutRaw) < 0 {
-				// Swap if j has a higher output
-				routes[i], routes[j] = routes[j], routes[i]
-			}
+// getFeeTierQuote quotes the pool of a protocol's fee tier. It returns
+// uniswap.ErrPoolNotFound if the pair has no pool at that fee.
+func (s *Service) getFeeTierQuote(
+	ctx context.Context,
+	protocol protocols.ProtocolConfig,
+	feeTier uint64,
+	tokenIn, tokenOut common.Address,
+	amountIn *big.Int,
+	tokenInDecimals, tokenOutDecimals uint8,
+	tokenInSymbol, tokenOutSymbol string,
+) (route *RouteQuote, err error) {
+	ctx, span := tracing.Start(ctx, "fee tier", trace.WithAttributes(
+		attribute.String("protocol", protocol.Name),
+		attribute.Int64("fee", int64(feeTier)),
+	))
+	defer func() {
+		// A missing pool is an answer, not a failure
+		if errors.Is(err, uniswap.ErrPoolNotFound) {
+			span.SetAttributes(attribute.Bool("pool.found", false))
+			tracing.End(span, nil)
+			return
+		}
+		tracing.End(span, err)
+	}()
+
+	// Get the pool address for this pair and fee tier
+	poolAddress, err := uniswap.GetPoolAddress(
+		ctx,
+		tokenIn, 
+		tokenOut, 
+		big.NewInt(int64(feeTier)),
+		protocol.FactoryAddress,
+		s.nodeURL,
+	)
+	
+	// Stop if the pool doesn't exist or the lookup failed
+	if err != nil {
+		if !errors.Is(err, uniswap.ErrPoolNotFound) {
+			metrics.ProtocolQuoteErrors.WithLabelValues(protocol.Name, metrics.StagePool).Inc()
 		}
+		return nil, err
 	}
-}
\ No newline at end of file
+	span.SetAttributes(attribute.String("pool", poolAddress.Hex()))
+
+	// Remember the pool in the registry
+	if s.pools != nil {
+		pair := pairs.NewTokenPair(
+			pairs.NewERC20Token(tokenIn, tokenInSymbol, tokenInDecimals),
+			pairs.NewERC20Token(tokenOut, tokenOutSymbol, tokenOutDecimals),
+		)
+		if err := s.pools.AddProtocolPair(ctx, protocol.Name, poolAddress.String(), feeTier, pair); err != nil {
+			logger.ErrorContext(ctx, "Failed to register pool", "pool", poolAddress.Hex(), "error", err)
+		}
+	}
+	
+	// Get quote for this pool
+	amountOut, err := uniswap.GetQuoteExactInputSingle(
+		ctx,
+		tokenIn,
+		tokenOut,
+		big.NewInt(int64(feeTier)),
+		amountIn,
+		protocol.RouterAddress,
+		s.nodeURL,
+	)
+
+	if err != nil {
+		metrics.ProtocolQuoteErrors.WithLabelValues(protocol.Name, metrics.StageQuote).Inc()
+		return nil, err
+	}
+
+	// Create route quote
+	return &RouteQuote{
+		Protocol:    protocol.Name,
+		PoolAddress: poolAddress.String(),
+		Fee:         feeTier,
+		TokenIn:     tokenInSymbol,
+		TokenOut:    tokenOutSymbol,
+		AmountIn:    utils.NewAmount(amountIn, tokenInDecimals),
+		AmountOut:   utils.NewAmount(amountOut, tokenOutDecimals),
+		Gas:         protocol.SwapGas,
+		Router:      protocol.SwapRouter(),
+	}, nil
+}
+
+// nativeWrapperRoute builds the route for wrapping or unwrapping the native token
+func nativeWrapperRoute(
+	amountIn *big.Int,
+	decimals uint8,
+	tokenInSymbol, tokenOutSymbol string,
+	wrap bool,
+) RouteQuote {
+	wrapped := tokens.GetNativeToken().Wrapped.String()
+	amount := utils.NewAmount(amountIn, decimals)
+	gas := uint64(unwrapGas)
+	if wrap {
+		gas = wrapGas
+	}
+
+	return RouteQuote{
+		Protocol:     NativeWrapperProtocol,
+		PoolAddress:  wrapped,
+		TokenIn:      tokenInSymbol,
+		TokenOut:     tokenOutSymbol,
+		AmountIn:     amount,
+		AmountOut:    amount,
+		Gas:          gas,
+		Router:       wrapped,
+		WrapNative:   wrap,
+		UnwrapNative: !wrap,
+	}
+}
@@ -0,0 +1,24 @@
+package aggregator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+)
+
+func TestWarnInferredDecimals(t *testing.T) {
+	result := &AggregatorResult{
+		TokenIn:   &models.TokenMetadata{Symbol: "IN", Decimals: 6},
+		TokenOut:  &models.TokenMetadata{Symbol: "OUT", Decimals: 18, Inferred: []string{tokens.FieldDecimals}},
+		AllRoutes: []RouteQuote{{Protocol: "a"}, {Protocol: "b"}},
+	}
+	warnInferredDecimals(result)
+
+	for _, route := range append(result.AllRoutes, result.BestRoute) {
+		if len(route.Warnings) != 1 || !strings.HasPrefix(route.Warnings[0], "OUT ") {
+			t.Errorf("route %s warnings = %q, want one about OUT", route.Protocol, route.Warnings)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Package api holds the request and response types of the versioned HTTP API
+package api
+
+import (
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/risk"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/swap"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/utils"
+)
+
+// Error codes returned in ErrorResponse
+const (
+	CodeInvalidRequest = "invalid_request" // A parameter is missing or malformed
+	CodeInvalidAmount  = "invalid_amount"  // The amount is malformed, not positive or too precise
+	CodeNotAToken      = "not_a_token"     // An address isn't an ERC-20 token
+	CodeNoRoute        = "no_route"        // No pool quotes the pair
+	CodeUpstream       = "upstream_error"  // The node couldn't be reached
+	CodeLimitExceeded  = "limit_exceeded"  // A per-client limit was reached
+	CodeUnavailable    = "unavailable"     // The server is at capacity
+	CodeTimeout        = "timeout"         // The request ran out of time
+	CodeUnauthorized   = "unauthorized"    // The API key is missing, invalid, expired or revoked
+	CodeForbidden      = "forbidden"       // The API key doesn't have the scope the request needs
+	CodeInternal       = "internal_error"  // Anything else
+)
+
+// Error describes why a request failed
+type Error struct {
+	Code    string `json:"code" example:"invalid_amount"`
+	Message string `json:"message" example:"amount 1.1234567 has more than 6 decimal places"`
+}
+
+// ErrorResponse is the body of every failed request
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// NewErrorResponse creates an error body
+func NewErrorResponse(code, message string) ErrorResponse {
+	return ErrorResponse{Error: Error{Code: code, Message: message}}
+}
+
+// QuoteRequest is the query of GET /api/v1/quote. Exactly one of Amount and
+// AmountHuman may be set; without either 10000 of the smallest unit is quoted.
+type QuoteRequest struct {
+	TokenIn     string `form:"tokenIn" json:"tokenIn" binding:"required" example:"0x760AfE86e5de5fa0Ee542fc7B7B713e1c5425701"`
+	TokenOut    string `form:"tokenOut" json:"tokenOut" binding:"required" example:"0xf817257fed379853cDe0fa4F97AB987181B1E5Ea"`
+	Amount      string `form:"amount" json:"amount,omitempty" example:"1000000000000000000"` // In the input token's smallest unit
+	AmountHuman string `form:"amountHuman" json:"amountHuman,omitempty" example:"1.5"`       // In whole input tokens
+	Rank        string `form:"rank" json:"rank,omitempty" enums:"output,gas,fee" example:"output"`
+	Limit       int    `form:"limit" json:"limit,omitempty" example:"5"` // Maximum number of routes, 0 returns all
+}
+
+// Token describes a token of a quote
+type Token struct {
+	Address  string `json:"address"`
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+	LogoURI  string `json:"logoURI,omitempty"`
+}
+
+// NewToken summarises token metadata, leaving out screening results
+func NewToken(metadata *models.TokenMetadata) Token {
+	return Token{
+		Address:  metadata.Address,
+		Name:     metadata.Name,
+		Symbol:   metadata.Symbol,
+		Decimals: metadata.Decimals,
+		LogoURI:  metadata.LogoURI,
+	}
+}
+
+// Risk is the risk screening of both tokens of a quote, unset if unknown
+type Risk struct {
+	TokenIn  *risk.Report `json:"tokenIn,omitempty"`
+	TokenOut *risk.Report `json:"tokenOut,omitempty"`
+}
+
+// QuoteResponse is the body of GET /api/v1/quote. Routes holds every route,
+// best first, so BestRoute is always Routes[0].
+type QuoteResponse struct {
+	TokenIn     Token                   `json:"tokenIn"`
+	TokenOut    Token                   `json:"tokenOut"`
+	AmountIn    utils.Amount            `json:"amountIn"`              // Amount quoted
+	Approximate bool                    `json:"approximate,omitempty"` // AmountIn was rounded from the requested amount so nearby amounts share cached quotes
+	BestRoute   aggregator.RouteQuote   `json:"bestRoute"`
+	Routes      []aggregator.RouteQuote `json:"routes"`
+	Risk        Risk                    `json:"risk"`
+}
+
+// BatchQuoteRequest is the body of POST /quotes/batch
+type BatchQuoteRequest struct {
+	Quotes []QuoteRequest `json:"quotes" binding:"required"`
+}
+
+// BatchQuoteResult is the result of one quote of a batch, either Quote or
+// Error is set
+type BatchQuoteResult struct {
+	Quote *QuoteResponse `json:"quote,omitempty"`
+	Error *Error         `json:"error,omitempty"`
+}
+
+// BatchQuoteResponse is the body of POST /quotes/batch. Results are in the
+// same order as the requested quotes.
+type BatchQuoteResponse struct {
+	Results   []BatchQuoteResult `json:"results"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+}
+
+// SwapRequest is a swap to build for the best route
+type SwapRequest struct {
+	TokenIn     string
+	TokenOut    string
+	Amount      string // In the input token's smallest unit
+	Recipient   string
+	SlippageBps uint64 // 0 to 10000
+	Rank        string
+}
+
+// SwapResponse is an unsigned transaction for the best route
+type SwapResponse struct {
+	Route            aggregator.RouteQuote
+	AmountOutMinimum utils.Amount
+	Transaction      *swap.Transaction
+	Risk             Risk
+}
+
+// Amount documents how utils.Amount is encoded
+type Amount struct {
+	Raw      string `json:"raw" example:"1500000"`
+	Display  string `json:"display" example:"1.5"`
+	Decimals uint8  `json:"decimals" example:"6"`
+}
+
+// Stream message types
+const (
+	StreamSubscribe    = "subscribe"    // Client: start streaming a quote
+	StreamUnsubscribe  = "unsubscribe"  // Client: stop streaming a quote
+	StreamSubscribed   = "subscribed"   // Server: the subscription was accepted
+	StreamUnsubscribed = "unsubscribed" // Server: the subscription was stopped
+	StreamQuote        = "quote"        // Server: an updated quote
+	StreamError        = "error"        // Server: a subscription or message failed
+)
+
+// Stream update modes
+const (
+	UpdatesBlock  = "block"  // Send a quote every new block
+	UpdatesChange = "change" // Send a quote only when the best route changes
+)
+
+// StreamRequest is a message sent by a client over the quote WebSocket. ID
+// names the subscription in later messages and must be unique per connection.
+type StreamRequest struct {
+	Type    string `json:"type" enums:"subscribe,unsubscribe"`
+	ID      string `json:"id" example:"usdc-mon"`
+	Updates string `json:"updates,omitempty" enums:"block,change"`
+	QuoteRequest
+}
+
+// StreamMessage is a message sent to a client over the quote WebSocket, or
+// the data of a server-sent event
+type StreamMessage struct {
+	Type  string         `json:"type" enums:"subscribed,unsubscribed,quote,error"`
+	ID    string         `json:"id,omitempty"`
+	Block uint64         `json:"block,omitempty"`
+	Quote *QuoteResponse `json:"quote,omitempty"`
+	Error *Error         `json:"error,omitempty"`
+}
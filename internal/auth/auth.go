@@ -0,0 +1,165 @@
+// Package auth issues API keys and checks them against the scope a route
+// needs. Keys are random secrets; only their SHA-256 hash is stored.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+)
+
+// Scopes a key can be issued with
+const (
+	ScopeQuote      = "quote"       // Quotes, swaps, depth and read-only token and pool lookups
+	ScopeTokenWrite = "token:write" // Adding, refreshing and invalidating tokens
+	ScopeAdmin      = "admin"       // Everything
+)
+
+// Scopes are the valid scopes
+var Scopes = []string{ScopeQuote, ScopeTokenWrite, ScopeAdmin}
+
+const (
+	keyPrefix  = "dfa_" // Marks a string as one of our keys
+	secretSize = 32     // Random bytes in a key
+	idLength   = 16     // Hex characters of the hash a key is stored under
+)
+
+// rootKeyID is the ID of the key configured with API_KEY
+const rootKeyID = "root"
+
+// Authentication failures
+var (
+	ErrMissingKey = errors.New("missing API key")
+	ErrInvalidKey = errors.New("invalid API key")
+	ErrExpiredKey = errors.New("API key has expired")
+	ErrRevokedKey = errors.New("API key has been revoked")
+)
+
+// Rejected reports whether err is why a key wasn't accepted, rather than the
+// store failing
+func Rejected(err error) bool {
+	return errors.Is(err, ErrMissingKey) || errors.Is(err, ErrInvalidKey) ||
+		errors.Is(err, ErrExpiredKey) || errors.Is(err, ErrRevokedKey)
+}
+
+// KeyStore looks up stored keys by ID, returning nil if there's no such key
+type KeyStore interface {
+	GetAPIKey(ctx context.Context, id string) (*models.APIKey, error)
+}
+
+// Generate creates a key for owner. The key is returned once and only its
+// hash is kept in the record. A ttl of 0 never expires.
+func Generate(owner string, scopes []string, ttl time.Duration) (string, models.APIKey, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", models.APIKey{}, fmt.Errorf("failed to generate API key: %v", err)
+	}
+	plaintext := keyPrefix + hex.EncodeToString(secret)
+
+	now := time.Now()
+	hash := Hash(plaintext)
+	key := models.APIKey{
+		ID:        hash[:idLength],
+		Hash:      hash,
+		Owner:     owner,
+		Scopes:    scopes,
+		CreatedAt: now.Unix(),
+	}
+	if ttl > 0 {
+		key.ExpiresAt = now.Add(ttl).Unix()
+	}
+	return plaintext, key, nil
+}
+
+// Hash returns the hex SHA-256 of a key
+func Hash(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseScopes parses a comma-separated list of scopes, rejecting unknown ones
+func ParseScopes(list string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.Split(list, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		if !slices.Contains(Scopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q, expected one of %s", scope, strings.Join(Scopes, ", "))
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	return scopes, nil
+}
+
+// Allows reports whether a key may use scope. Admin keys may use any scope.
+func Allows(key *models.APIKey, scope string) bool {
+	return slices.Contains(key.Scopes, ScopeAdmin) || slices.Contains(key.Scopes, scope)
+}
+
+// Active returns why a key can't be used at now, or nil if it can
+func Active(key *models.APIKey, now time.Time) error {
+	if key.RevokedAt != 0 {
+		return ErrRevokedKey
+	}
+	if key.ExpiresAt != 0 && now.Unix() >= key.ExpiresAt {
+		return ErrExpiredKey
+	}
+	return nil
+}
+
+// Authenticator checks keys against the store, and against a root key with
+// admin scope if one is configured
+type Authenticator struct {
+	store   KeyStore
+	rootKey string
+}
+
+// NewAuthenticator creates an authenticator. An empty rootKey disables it, so
+// only stored keys are accepted.
+func NewAuthenticator(store KeyStore, rootKey string) *Authenticator {
+	return &Authenticator{store: store, rootKey: rootKey}
+}
+
+// Authenticate returns the record of a key, or why it isn't accepted. Any
+// other error means the store couldn't be reached.
+func (a *Authenticator) Authenticate(ctx context.Context, plaintext string) (*models.APIKey, error) {
+	if plaintext == "" {
+		return nil, ErrMissingKey
+	}
+
+	if a.rootKey != "" && subtle.ConstantTimeCompare([]byte(plaintext), []byte(a.rootKey)) == 1 {
+		return &models.APIKey{ID: rootKeyID, Owner: "API_KEY", Scopes: []string{ScopeAdmin}}, nil
+	}
+
+	if !strings.HasPrefix(plaintext, keyPrefix) {
+		return nil, ErrInvalidKey
+	}
+	hash := Hash(plaintext)
+	key, err := a.store.GetAPIKey(ctx, hash[:idLength])
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || subtle.ConstantTimeCompare([]byte(hash), []byte(key.Hash)) != 1 {
+		return nil, ErrInvalidKey
+	}
+	if err := Active(key, time.Now()); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+)
+
+// keyStore is a KeyStore holding keys by ID, failing with err if set
+type keyStore struct {
+	keys map[string]models.APIKey
+	err  error
+}
+
+func (s *keyStore) GetAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+// issue generates a key, stores it after edit and returns the key to send
+func (s *keyStore) issue(t *testing.T, scopes []string, edit func(*models.APIKey)) string {
+	t.Helper()
+	plaintext, key, err := Generate("test", scopes, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edit != nil {
+		edit(&key)
+	}
+	s.keys[key.ID] = key
+	return plaintext
+}
+
+func TestAuthenticate(t *testing.T) {
+	store := &keyStore{keys: make(map[string]models.APIKey)}
+	valid := store.issue(t, []string{ScopeQuote}, nil)
+	expired := store.issue(t, []string{ScopeQuote}, func(key *models.APIKey) { key.ExpiresAt = time.Now().Add(-time.Minute).Unix() })
+	revoked := store.issue(t, []string{ScopeQuote}, func(key *models.APIKey) { key.RevokedAt = time.Now().Unix() })
+	// A key stored under the right ID with another key's hash
+	forged := store.issue(t, []string{ScopeAdmin}, func(key *models.APIKey) { key.Hash = Hash("dfa_other") })
+
+	keys := NewAuthenticator(store, "root-key")
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+		wantID  string
+	}{
+		{"valid", valid, nil, Hash(valid)[:idLength]},
+		{"root", "root-key", nil, rootKeyID},
+		{"missing", "", ErrMissingKey, ""},
+		{"no prefix", "abc", ErrInvalidKey, ""},
+		{"unknown", "dfa_0123", ErrInvalidKey, ""},
+		{"forged", forged, ErrInvalidKey, ""},
+		{"expired", expired, ErrExpiredKey, ""},
+		{"revoked", revoked, ErrRevokedKey, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := keys.Authenticate(context.Background(), tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !Rejected(err) {
+					t.Errorf("Rejected(%v) = false", err)
+				}
+				return
+			}
+			if key.ID != tt.wantID {
+				t.Errorf("key ID = %q, want %q", key.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestAuthenticateWithoutRootKey(t *testing.T) {
+	// An empty root key doesn't accept an empty key, or any other
+	keys := NewAuthenticator(&keyStore{keys: make(map[string]models.APIKey)}, "")
+	if _, err := keys.Authenticate(context.Background(), "root-key"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Authenticate error = %v, want %v", err, ErrInvalidKey)
+	}
+}
+
+func TestAuthenticateStoreError(t *testing.T) {
+	storeErr := errors.New("connection refused")
+	keys := NewAuthenticator(&keyStore{err: storeErr}, "")
+
+	_, err := keys.Authenticate(context.Background(), "dfa_0123")
+	if !errors.Is(err, storeErr) {
+		t.Fatalf("Authenticate error = %v, want %v", err, storeErr)
+	}
+	if Rejected(err) {
+		t.Errorf("Rejected(%v) = true, want false for a store failure", err)
+	}
+}
+
+func TestActive(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		key  models.APIKey
+		want error
+	}{
+		{"never expires", models.APIKey{}, nil},
+		{"expires later", models.APIKey{ExpiresAt: now.Add(time.Hour).Unix()}, nil},
+		{"expires now", models.APIKey{ExpiresAt: now.Unix()}, ErrExpiredKey},
+		{"expired", models.APIKey{ExpiresAt: now.Add(-time.Hour).Unix()}, ErrExpiredKey},
+		{"revoked", models.APIKey{RevokedAt: now.Unix()}, ErrRevokedKey},
+		{"revoked and expired", models.APIKey{RevokedAt: now.Unix(), ExpiresAt: now.Add(-time.Hour).Unix()}, ErrRevokedKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Active(&tt.key, now); err != tt.want {
+				t.Errorf("Active = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{"quote", []string{ScopeQuote}, false},
+		{"quote,token:write", []string{ScopeQuote, ScopeTokenWrite}, false},
+		{" admin , quote ", []string{ScopeAdmin, ScopeQuote}, false},
+		{"quote,,quote", []string{ScopeQuote}, false},
+		{"", nil, true},
+		{" , ", nil, true},
+		{"quote,write", nil, true},
+		{"Quote", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			scopes, err := ParseScopes(tt.list)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseScopes(%q) = %v, want an error", tt.list, scopes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseScopes(%q): %v", tt.list, err)
+			}
+			if !slices.Equal(scopes, tt.want) {
+				t.Errorf("ParseScopes(%q) = %v, want %v", tt.list, scopes, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{[]string{ScopeQuote}, ScopeQuote, true},
+		{[]string{ScopeQuote}, ScopeTokenWrite, false},
+		{[]string{ScopeQuote}, ScopeAdmin, false},
+		{[]string{ScopeTokenWrite}, ScopeQuote, false},
+		{[]string{ScopeQuote, ScopeTokenWrite}, ScopeTokenWrite, true},
+		{[]string{ScopeAdmin}, ScopeQuote, true},
+		{[]string{ScopeAdmin}, ScopeTokenWrite, true},
+		{nil, ScopeQuote, false},
+	}
+
+	for _, tt := range tests {
+		if got := Allows(&models.APIKey{Scopes: tt.scopes}, tt.scope); got != tt.want {
+			t.Errorf("Allows(%v, %s) = %v, want %v", tt.scopes, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	plaintext, key, err := Generate("ops", []string{ScopeQuote}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Hash != Hash(plaintext) || key.ID != key.Hash[:idLength] {
+		t.Errorf("key = %+v, want it stored under the hash of %s", key, plaintext)
+	}
+	if key.ExpiresAt <= time.Now().Unix() {
+		t.Errorf("ExpiresAt = %d, want an hour from now", key.ExpiresAt)
+	}
+	if other, _, _ := Generate("ops", []string{ScopeQuote}, 0); other == plaintext {
+		t.Error("Generate returned the same key twice")
+	}
+}
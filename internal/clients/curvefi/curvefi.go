@@ -3,6 +3,8 @@ package curvefi
 import (
 	"flag"
 	"fmt"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
 	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/lmittmann/w3"
@@ -11,6 +13,8 @@ import (
 	"math/big"
 )
 
+var logger = logging.For("curvefi")
+
 // ERC20Token represents an ERC20 token
 var (
 	addrUniV3Quoter = w3.A("0xb27308f9F90D607463bb33eA1BeBb41C27CE5AB6")
@@ -39,14 +43,14 @@ func Quote(tokenA, tokenB common.Address, rawUrl string) {
 	flag.TextVar(&addrTokenOut, "tokenOut", tokenB, "Token out")
 
 	flag.Usage = func() {
-		fmt.Println("curve.fi exchange rate to swap amountIn of tokenIn for tokenOut.")
+		fmt.Fprintln(flag.CommandLine.Output(), "curve.fi exchange rate to swap amountIn of tokenIn for tokenOut.")
 		flag.PrintDefaults()
 	}
 
 	flag.Parse()
 
 	// connect to RPC endpoint
-	client := w3.MustDial(rawUrl)
+	client := node.MustDial(rawUrl)
 	defer client.Close()
 
 	// fetch token details
@@ -67,7 +71,7 @@ func Quote(tokenA, tokenB common.Address, rawUrl string) {
 		eth.CallFunc(addrTokenOut, funcSymbol).Returns(&tokenOutSymbol),
 		eth.CallFunc(addrTokenOut, funcDecimals).Returns(&tokenOutDecimals),
 	); err != nil {
-		fmt.Printf("Failed to fetch token details: %v\n", err)
+		logger.Error("Failed to fetch token details", "error", err)
 		return
 	}
 
@@ -86,31 +90,34 @@ func Quote(tokenA, tokenB common.Address, rawUrl string) {
 	callErrs, ok := err.(w3.CallErrors)
 
 	if err != nil && !ok {
-		fmt.Printf("Failed to fetch quotes: %v\n", err)
+		logger.Error("Failed to fetch quotes", "error", err)
 		return
 
 	}
 
-	// print quotes
-	fmt.Printf("Exchange %q for %q\n", tokenInName, tokenOutName)
-	fmt.Printf("Amount in:\n  %s %s\n", w3.FromWei(&amountIn, tokenInDecimals), tokenInSymbol)
-	fmt.Printf("Amount out:\n")
+	// log quotes
 	for i, fee := range fees {
 		if ok && callErrs[i] != nil {
-			fmt.Printf("  Pool (fee=%5v): Pool does not exist\n", fee)
+			logger.Info("Pool does not exist", "tokenIn", tokenInName, "tokenOut", tokenOutName, "fee", fee)
 			continue
 		}
-		fmt.Printf("  Pool (fee=%5v): %s %s\n", fee, w3.FromWei(&amountsOut[i], tokenOutDecimals), tokenOutSymbol)
+		logger.Info("Quote",
+			"tokenIn", tokenInName,
+			"tokenOut", tokenOutName,
+			"fee", fee,
+			"amountIn", w3.FromWei(&amountIn, tokenInDecimals)+" "+tokenInSymbol,
+			"amountOut", w3.FromWei(&amountsOut[i], tokenOutDecimals)+" "+tokenOutSymbol,
+		)
 	}
 }
 
 func GetPoolAddress(tokenIn, tokenOut common.Address, nodeUrl string) common.Address {
-	client := w3.MustDial(nodeUrl)
+	client := node.MustDial(nodeUrl)
 	defer client.Close()
 
 	// https://etherscan.io/token/0x0c0e5f2fF0ff18a3be9b835635039256dC4B4963#readContract
 	factorAddress := "0x0c0e5f2fF0ff18a3be9b835635039256dC4B4963"
-	fmt.Println(factorAddress)
+	logger.Debug("Finding pool", "factory", factorAddress, "tokenIn", tokenIn.Hex(), "tokenOut", tokenOut.Hex())
 
 	_factoryAddress := common.HexToAddress(factorAddress)
 
@@ -121,14 +128,14 @@ func GetPoolAddress(tokenIn, tokenOut common.Address, nodeUrl string) common.Add
 	if err := client.Call(
 		eth.CallFunc(_factoryAddress, getPool, tokenIn, tokenOut).Returns(&poolAddress),
 	); err != nil {
-		fmt.Printf("Request failed: %v\n", err)
+		logger.Error("Failed to find pool", "error", err)
 	}
 
 	return common.HexToAddress(poolAddress)
 }
 
 func GetPrice(tokenIn, tokenOut common.Address, nodeUrl string) big.Int {
-	client := w3.MustDial(nodeUrl)
+	client := node.MustDial(nodeUrl)
 	defer client.Close()
 
 	// poolAddress := GetPoolAddress(tokenIn, tokenOut, nodeUrl)
@@ -140,10 +147,10 @@ func GetPrice(tokenIn, tokenOut common.Address, nodeUrl string) big.Int {
 	if err := client.Call(
 		eth.CallFunc(poolAddress, getPrice).Returns(&price),
 	); err != nil {
-		fmt.Printf("Request failed: %v\n", err)
+		logger.Error("Failed to get price", "pool", poolAddress.Hex(), "error", err)
 	}
 
-	fmt.Printf("Price: %s\n", w3.FromWei(&price, 18))
+	logger.Debug("Price", "pool", poolAddress.Hex(), "price", w3.FromWei(&price, 18))
 
 	return price
 }
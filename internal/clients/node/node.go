@@ -0,0 +1,218 @@
+// Package node dials the Ethereum node. Requests over HTTP go through a
+// transport that counts the JSON-RPC calls in them for the metrics, and tags
+// them with the ID of the request they're made for.
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/metrics"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tracing"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/lmittmann/w3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var logger = logging.For("node")
+
+// requestIDHeader sends the request ID to the node, so providers' logs can be
+// matched with ours
+const requestIDHeader = "X-Request-Id"
+
+// Dial connects to the node at rawURL, like w3.Dial
+func Dial(rawURL string) (*w3.Client, error) {
+	client, err := rpc.DialOptions(context.Background(), rawURL, rpc.WithHTTPClient(&http.Client{
+		Transport: &transport{base: http.DefaultTransport, endpoint: endpoint(rawURL)},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return w3.NewClient(client), nil
+}
+
+// MustDial is like Dial but panics if the node URL is invalid, like w3.MustDial
+func MustDial(rawURL string) *w3.Client {
+	client, err := Dial(rawURL)
+	if err != nil {
+		panic(fmt.Sprintf("w3: %s", err))
+	}
+	return client
+}
+
+// endpoint is the host of a node URL. The path is left out, providers put API
+// keys in it.
+func endpoint(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "unknown"
+	}
+	return u.Host
+}
+
+// message is the part of a JSON-RPC request or response the metrics and
+// traces need
+type message struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Error  json.RawMessage `json:"error"`
+}
+
+// callTarget returns the contract an eth_call is made to, or ""
+func (m message) callTarget() string {
+	if m.Method != "eth_call" {
+		return ""
+	}
+	var params []struct {
+		To string `json:"to"`
+	}
+	if json.Unmarshal(m.Params, &params) != nil || len(params) == 0 {
+		return ""
+	}
+	return params[0].To
+}
+
+// decodeMessages decodes a single JSON-RPC message or a batch
+func decodeMessages(body []byte) []message {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []message
+		if json.Unmarshal(body, &batch) != nil {
+			return nil
+		}
+		return batch
+	}
+
+	var single message
+	if json.Unmarshal(body, &single) != nil {
+		return nil
+	}
+	return []message{single}
+}
+
+// transport counts the calls of each request by method, and the calls that
+// failed: every call if the request failed, otherwise those answered with an
+// error or not answered at all. Requests made as part of a trace get a span.
+type transport struct {
+	base     http.RoundTripper
+	endpoint string
+}
+
+// RoundTrip sends a request to the node
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var calls []message
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			calls = decodeMessages(data)
+		}
+	}
+	for _, call := range calls {
+		metrics.RPCCalls.WithLabelValues(call.Method, t.endpoint).Inc()
+	}
+
+	ctx := req.Context()
+	if tracing.Traced(ctx) {
+		var span trace.Span
+		ctx, span = t.startSpan(ctx, calls)
+		defer span.End()
+	}
+	if id := logging.RequestID(ctx); id != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(requestIDHeader, id)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	latency := time.Since(start)
+	metrics.RPCRequestDuration.WithLabelValues(t.endpoint).Observe(latency.Seconds())
+	if err != nil {
+		t.finish(ctx, calls, nil, latency)
+		return resp, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		t.finish(ctx, calls, nil, latency)
+		return resp, nil
+	}
+
+	// Read the response to find the calls that returned an error, then hand
+	// the client a copy
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.finish(ctx, calls, nil, latency)
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	ok := make(map[string]bool)
+	for _, reply := range decodeMessages(data) {
+		if len(reply.Error) == 0 || string(reply.Error) == "null" {
+			ok[string(reply.ID)] = true
+		}
+	}
+	t.finish(ctx, calls, ok, latency)
+	return resp, nil
+}
+
+// requestName names a request after its method, or "batch"
+func requestName(calls []message) string {
+	if len(calls) == 1 {
+		return calls[0].Method
+	}
+	return "batch"
+}
+
+// startSpan starts the span of a request
+func (t *transport) startSpan(ctx context.Context, calls []message) (context.Context, trace.Span) {
+
+	attrs := []attribute.KeyValue{
+		attribute.String("rpc.system", "jsonrpc"),
+		attribute.String("server.address", t.endpoint),
+		attribute.Int("rpc.batch_size", len(calls)),
+	}
+	if len(calls) == 1 {
+		attrs = append(attrs, attribute.String("rpc.method", calls[0].Method))
+		if to := calls[0].callTarget(); to != "" {
+			attrs = append(attrs, attribute.String("rpc.call.to", to))
+		}
+	}
+	return tracing.Start(ctx, "rpc "+requestName(calls), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// finish counts the calls whose ID isn't in ok as failed, and marks the span of
+// the request failed if any did. The request is logged at debug.
+func (t *transport) finish(ctx context.Context, calls []message, ok map[string]bool, latency time.Duration) {
+	failed := 0
+	for _, call := range calls {
+		if !ok[string(call.ID)] {
+			metrics.RPCFailures.WithLabelValues(call.Method, t.endpoint).Inc()
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		span := trace.SpanFromContext(ctx)
+		span.SetAttributes(attribute.Int("rpc.failed_calls", failed))
+		span.SetStatus(codes.Error, fmt.Sprintf("%d of %d calls failed", failed, len(calls)))
+	}
+
+	logger.DebugContext(ctx, "RPC request",
+		"method", requestName(calls),
+		"calls", len(calls),
+		"failed", failed,
+		"endpoint", t.endpoint,
+		"latency", latency,
+	)
+}
@@ -0,0 +1,342 @@
+// Package nodetest runs a fake Ethereum node for tests. Contract calls are
+// answered by handlers added per contract and function, every other call
+// reverts, and every address has code unless it is marked as having none.
+package nodetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/lmittmann/w3"
+)
+
+// ErrRevert is returned by a handler to revert the call
+var ErrRevert = errors.New("execution reverted")
+
+// AnyContract adds a handler for a function on every contract without one of its own
+var AnyContract = common.Address{}
+
+// Handler answers a call with the function's return values. Args are the
+// decoded arguments of the call.
+type Handler func(args []any) ([]any, error)
+
+// Returns is a handler that always returns values
+func Returns(values ...any) Handler {
+	return func([]any) ([]any, error) { return values, nil }
+}
+
+// Reverts is a handler that always reverts
+func Reverts() Handler {
+	return func([]any) ([]any, error) { return nil, ErrRevert }
+}
+
+// handlerKey identifies the handler of a function on a contract
+type handlerKey struct {
+	contract common.Address
+	selector [4]byte
+}
+
+// handler is a function and how its calls are answered
+type handler struct {
+	fn     *w3.Func
+	handle Handler
+}
+
+// Node is a fake node serving JSON-RPC over HTTP at URL
+type Node struct {
+	URL string
+
+	mu       sync.Mutex
+	handlers map[handlerKey]handler
+	noCode   map[common.Address]bool
+	failing  map[common.Address]bool
+	cutOff   map[common.Address]bool
+	balances map[common.Address]*big.Int
+	block    uint64
+	down     bool
+	requests int
+	batches  int
+}
+
+// New starts a node that is closed when the test ends
+func New(t testing.TB) *Node {
+	n := &Node{
+		handlers: make(map[handlerKey]handler),
+		noCode:   make(map[common.Address]bool),
+		failing:  make(map[common.Address]bool),
+		cutOff:   make(map[common.Address]bool),
+		balances: make(map[common.Address]*big.Int),
+		block:    1,
+	}
+	server := httptest.NewServer(n)
+	t.Cleanup(server.Close)
+	n.URL = server.URL
+	return n
+}
+
+// Handle answers calls of fn on contract, or on any contract with AnyContract
+func (n *Node) Handle(contract common.Address, fn *w3.Func, handle Handler) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers[handlerKey{contract, fn.Selector}] = handler{fn, handle}
+}
+
+// SetNoCode marks an address as having no code, like an account
+func (n *Node) SetNoCode(address common.Address) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.noCode[address] = true
+}
+
+// SetFailing makes every request about an address fail with a node error
+// rather than a revert, the rest of a batch is still answered
+func (n *Node) SetFailing(address common.Address) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.failing[address] = true
+}
+
+// SetUnreachable makes every HTTP request holding a call that passes address
+// as an argument fail as if the node can't be reached, so the whole batch fails
+func (n *Node) SetUnreachable(address common.Address) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.cutOff[address] = true
+}
+
+// SetBalance sets the native balance of an address
+func (n *Node) SetBalance(address common.Address, balance *big.Int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.balances[address] = balance
+}
+
+// SetBlock sets the latest block number
+func (n *Node) SetBlock(block uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.block = block
+}
+
+// SetDown makes every request fail as if the node can't be reached
+func (n *Node) SetDown(down bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.down = down
+}
+
+// Requests returns the number of JSON-RPC requests served and the number of
+// HTTP requests, batches count once, they came in
+func (n *Node) Requests() (requests, batches int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.requests, n.batches
+}
+
+// request is a JSON-RPC request
+type request struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// response is a JSON-RPC response
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	down := n.down
+	n.mu.Unlock()
+	if down {
+		http.Error(w, "node is down", http.StatusServiceUnavailable)
+		return
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(raw) > 0 && raw[0] == '[' {
+		var batch []request
+		if err := json.Unmarshal(raw, &batch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if n.unreachable(batch...) {
+			http.Error(w, "node is unreachable", http.StatusServiceUnavailable)
+			return
+		}
+		responses := make([]response, len(batch))
+		for i, req := range batch {
+			responses[i] = n.serve(req)
+		}
+		n.count(len(batch))
+		json.NewEncoder(w).Encode(responses)
+		return
+	}
+
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if n.unreachable(req) {
+		http.Error(w, "node is unreachable", http.StatusServiceUnavailable)
+		return
+	}
+	n.count(1)
+	json.NewEncoder(w).Encode(n.serve(req))
+}
+
+// unreachable reports whether any of reqs is a call passing an address set
+// with SetUnreachable
+func (n *Node) unreachable(reqs ...request) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, req := range reqs {
+		if req.Method != "eth_call" || len(req.Params) == 0 {
+			continue
+		}
+		var msg struct {
+			Input hexutil.Bytes `json:"input"`
+			Data  hexutil.Bytes `json:"data"`
+		}
+		if json.Unmarshal(req.Params[0], &msg) != nil {
+			continue
+		}
+		input := append(msg.Input, msg.Data...)
+		for address := range n.cutOff {
+			if bytes.Contains(input, common.LeftPadBytes(address[:], 32)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// count records the requests of one HTTP request
+func (n *Node) count(requests int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.requests += requests
+	n.batches++
+}
+
+// serve answers one JSON-RPC request
+func (n *Node) serve(req request) response {
+	res := response{JSONRPC: "2.0", ID: req.ID}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(req.Params) > 0 {
+		var target struct {
+			To common.Address `json:"to"`
+		}
+		var address common.Address
+		if json.Unmarshal(req.Params[0], &address) != nil && json.Unmarshal(req.Params[0], &target) == nil {
+			address = target.To
+		}
+		if n.failing[address] {
+			res.Error = &rpcError{Code: -32000, Message: "internal error"}
+			return res
+		}
+	}
+
+	switch req.Method {
+	case "eth_chainId":
+		res.Result = hexutil.Uint64(1)
+	case "eth_blockNumber":
+		res.Result = hexutil.Uint64(n.block)
+	case "eth_getCode":
+		var address common.Address
+		if len(req.Params) > 0 {
+			json.Unmarshal(req.Params[0], &address)
+		}
+		if n.noCode[address] {
+			res.Result = hexutil.Bytes{}
+		} else {
+			res.Result = hexutil.Bytes{0x60, 0x80}
+		}
+	case "eth_getBalance":
+		var address common.Address
+		if len(req.Params) > 0 {
+			json.Unmarshal(req.Params[0], &address)
+		}
+		balance := new(big.Int)
+		if n.balances[address] != nil {
+			balance = n.balances[address]
+		}
+		res.Result = (*hexutil.Big)(balance)
+	case "eth_call":
+		output, err := n.call(req)
+		if err != nil {
+			res.Error = &rpcError{Code: 3, Message: err.Error(), Data: "0x"}
+		} else {
+			res.Result = hexutil.Bytes(output)
+		}
+	default:
+		res.Error = &rpcError{Code: -32601, Message: "the method " + req.Method + " does not exist"}
+	}
+	return res
+}
+
+// call answers an eth_call with the handler of the called function
+func (n *Node) call(req request) ([]byte, error) {
+	var msg struct {
+		To    common.Address `json:"to"`
+		Input hexutil.Bytes  `json:"input"`
+		Data  hexutil.Bytes  `json:"data"`
+	}
+	if len(req.Params) == 0 || json.Unmarshal(req.Params[0], &msg) != nil {
+		return nil, ErrRevert
+	}
+	input := msg.Input
+	if len(input) == 0 {
+		input = msg.Data
+	}
+	if len(input) < 4 {
+		return nil, ErrRevert
+	}
+
+	selector := [4]byte(input[:4])
+	h, ok := n.handlers[handlerKey{msg.To, selector}]
+	if !ok {
+		h, ok = n.handlers[handlerKey{AnyContract, selector}]
+	}
+	if !ok {
+		return nil, ErrRevert
+	}
+
+	args, err := h.fn.Args.Unpack(input[4:])
+	if err != nil {
+		return nil, ErrRevert
+	}
+	returns, err := h.handle(args)
+	if err != nil {
+		return nil, err
+	}
+	return h.fn.Returns.Pack(returns...)
+}
@@ -3,15 +3,18 @@ package pancake
 import (
 	"flag"
 	"fmt"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
 	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/lmittmann/w3"
 	"github.com/lmittmann/w3/module/eth"
 	"github.com/lmittmann/w3/w3types"
-	"log"
 	"math/big"
 )
 
+var logger = logging.For("pancake")
+
 var (
 	// addrQuoter = w3.A("0xB048Bbc1Ee6b733FFfCFb9e9CeF7375518e25997")
 	factorAddress = w3.A("0x0BFbCF9fa4f9C56B0F40a671Ad40E0805A091865")
@@ -40,13 +43,13 @@ func Quote(tokenA, tokenB common.Address, fee *big.Int, rawUrl string) {
 	flag.TextVar(&addrTokenOut, "tokenOut", tokenB, "Token out")
 
 	flag.Usage = func() {
-		fmt.Println("pancake prints the Pancake V3 exchange rate to swap amountIn of tokenIn for tokenOut.")
+		fmt.Fprintln(flag.CommandLine.Output(), "pancake prints the Pancake V3 exchange rate to swap amountIn of tokenIn for tokenOut.")
 		flag.PrintDefaults()
 	}
 	flag.Parse()
 
 	// connect to RPC endpoint
-	client := w3.MustDial(rawUrl)
+	client := node.MustDial(rawUrl)
 	defer client.Close()
 
 	// fetch token details
@@ -67,7 +70,7 @@ func Quote(tokenA, tokenB common.Address, fee *big.Int, rawUrl string) {
 		eth.CallFunc(addrTokenOut, funcSymbol).Returns(&tokenOutSymbol),
 		eth.CallFunc(addrTokenOut, funcDecimals).Returns(&tokenOutDecimals),
 	); err != nil {
-		fmt.Printf("Failed to fetch token details: %v\n", err)
+		logger.Error("Failed to fetch token details", "error", err)
 		return
 	}
 
@@ -103,13 +106,13 @@ func Quotes(tokenA, tokenB common.Address, rawUrl string) {
 	flag.TextVar(&addrTokenOut, "tokenOut", tokenB, "Token out")
 
 	flag.Usage = func() {
-		fmt.Println("pancake prints the Pancake V3 exchange rate to swap amountIn of tokenIn for tokenOut.")
+		fmt.Fprintln(flag.CommandLine.Output(), "pancake prints the Pancake V3 exchange rate to swap amountIn of tokenIn for tokenOut.")
 		flag.PrintDefaults()
 	}
 	flag.Parse()
 
 	// connect to RPC endpoint
-	client := w3.MustDial(rawUrl)
+	client := node.MustDial(rawUrl)
 	defer client.Close()
 
 	// fetch token details
@@ -130,7 +133,7 @@ func Quotes(tokenA, tokenB common.Address, rawUrl string) {
 		eth.CallFunc(addrTokenOut, funcSymbol).Returns(&tokenOutSymbol),
 		eth.CallFunc(addrTokenOut, funcDecimals).Returns(&tokenOutDecimals),
 	); err != nil {
-		fmt.Printf("Failed to fetch token details: %v\n", err)
+		logger.Error("Failed to fetch token details", "error", err)
 		return
 	}
 
@@ -149,31 +152,32 @@ func Quotes(tokenA, tokenB common.Address, rawUrl string) {
 	callErrs, ok := err.(w3.CallErrors)
 
 	if err != nil && !ok {
-		fmt.Printf("Failed to fetch quotes: %v\n", err)
+		logger.Error("Failed to fetch quotes", "error", err)
 		return
 	}
 
-	// print quotes
-	fmt.Printf("Exchange %q for %q\n", tokenInName, tokenOutName)
-	fmt.Printf("Amount in:\n  %s %s\n", w3.FromWei(&amountIn, tokenInDecimals), tokenInSymbol)
-	fmt.Printf("Amount out:\n")
-
+	// log quotes
 	for i, fee := range fees {
 		if ok && callErrs[i] != nil {
-			fmt.Printf("  Pool (fee=%5v): Pool does not exist\n", fee)
+			logger.Info("Pool does not exist", "tokenIn", tokenInName, "tokenOut", tokenOutName, "fee", fee)
 			continue
 		}
-		fmt.Printf("  Pool (fee=%5v): %s %s\n", fee, w3.FromWei(&amountsOut[i], tokenOutDecimals), tokenOutSymbol)
+		logger.Info("Quote",
+			"tokenIn", tokenInName,
+			"tokenOut", tokenOutName,
+			"fee", fee,
+			"amountIn", w3.FromWei(&amountIn, tokenInDecimals)+" "+tokenInSymbol,
+			"amountOut", w3.FromWei(&amountsOut[i], tokenOutDecimals)+" "+tokenOutSymbol,
+		)
 	}
 }
 
 func GetPoolAddress(tokenIn, tokenOut common.Address, nodeUrl string) common.Address {
 
-	client := w3.MustDial(nodeUrl)
+	client := node.MustDial(nodeUrl)
 	defer client.Close()
 
 	const factorAddress = "0x0BFbCF9fa4f9C56B0F40a671Ad40E0805A091865"
-	fmt.Println(factorAddress)
 
 	_factoryAddress := common.HexToAddress(factorAddress)
 
@@ -182,18 +186,18 @@ func GetPoolAddress(tokenIn, tokenOut common.Address, nodeUrl string) common.Add
 
 	getPool := w3.MustNewFunc("getPool(address,address,uint24)", "address")
 	input, err := getPool.EncodeArgs(tokenIn, tokenOut, fee)
-	fmt.Printf("getPool input: 0x%x\n", input)
-
 	if err != nil {
-		log.Fatalf("Failed to encode arguments: %v", err)
+		logger.Error("Failed to encode arguments", "error", err)
+		return common.Address{}
 	}
+	logger.Debug("Finding pool", "factory", factorAddress, "input", fmt.Sprintf("0x%x", input))
 
 	var poolAddress string
 
 	if err := client.Call(
 		eth.CallFunc(_factoryAddress, getPool, input).Returns(&poolAddress),
 	); err != nil {
-		fmt.Printf("Request failed: %v\n", err)
+		logger.Error("Failed to find pool", "error", err)
 	}
 
 	return common.HexToAddress(poolAddress)
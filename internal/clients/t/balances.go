@@ -0,0 +1,80 @@
+package tokens
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+var funcBalanceOf = w3.MustNewFunc("balanceOf(address)", "uint256")
+
+// balanceBatchSize is the number of balances read per RPC batch
+const balanceBatchSize = 500
+
+// Holding is a token balance to read
+type Holding struct {
+	Token  common.Address
+	Holder common.Address
+}
+
+// BalanceBatch reads many token balances with one RPC batch per
+// balanceBatchSize balances. The native currency is read as the holder's
+// balance. Results and per-balance errors are in the same order as holdings;
+// the returned error is only set if the node can't be reached.
+func BalanceBatch(ctx context.Context, holdings []Holding, nodeURL string) ([]*big.Int, []error, error) {
+	results := make([]*big.Int, len(holdings))
+	errs := make([]error, len(holdings))
+	if len(holdings) == 0 {
+		return results, errs, nil
+	}
+
+	// Create a client
+	client, err := node.Dial(nodeURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to node: %v", err)
+	}
+	defer client.Close()
+
+	for start := 0; start < len(holdings); start += balanceBatchSize {
+		end := start + balanceBatchSize
+		if end > len(holdings) {
+			end = len(holdings)
+		}
+
+		calls := make([]w3types.RPCCaller, 0, end-start)
+		for i := start; i < end; i++ {
+			holding := holdings[i]
+			if IsNative(holding.Token) {
+				calls = append(calls, eth.Balance(holding.Holder, nil).Returns(&results[i]))
+				continue
+			}
+			results[i] = new(big.Int)
+			calls = append(calls, eth.CallFunc(holding.Token, funcBalanceOf, holding.Holder).Returns(results[i]))
+		}
+
+		// Individual calls may revert, anything else is a connection problem
+		err := client.CallCtx(ctx, calls...)
+		callErrs, ok := err.(w3.CallErrors)
+		if err != nil && !ok {
+			logger.ErrorContext(ctx, "Failed to get token balances", "balances", len(calls), "error", err)
+			return nil, nil, fmt.Errorf("failed to get token balances: %v", err)
+		}
+
+		if ok {
+			for j, callErr := range callErrs {
+				if callErr != nil {
+					results[start+j] = nil
+					errs[start+j] = fmt.Errorf("failed to get balance of %s: %v", holdings[start+j].Token.Hex(), callErr)
+				}
+			}
+		}
+	}
+
+	return results, errs, nil
+}
@@ -0,0 +1,48 @@
+package tokens
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NativeTokenAddress is the placeholder address accepted by the API for the chain's native currency
+var NativeTokenAddress = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+
+// NativeToken describes the chain's native currency and the ERC-20 that wraps it
+type NativeToken struct {
+	Name     string
+	Symbol   string
+	Decimals uint8
+	Wrapped  common.Address
+}
+
+// native defaults to Monad testnet and is replaced at startup via SetNativeToken
+var native = NativeToken{
+	Name:     "Monad",
+	Symbol:   "MON",
+	Decimals: 18,
+	Wrapped:  common.HexToAddress("0x760AfE86e5de5fa0Ee542fc7B7B713e1c5425701"),
+}
+
+// SetNativeToken configures the native currency for the current chain
+func SetNativeToken(token NativeToken) {
+	native = token
+}
+
+// GetNativeToken returns the configured native currency
+func GetNativeToken() NativeToken {
+	return native
+}
+
+// IsNative reports whether the address is the native currency placeholder
+func IsNative(address common.Address) bool {
+	return address == NativeTokenAddress
+}
+
+// QuoteAddress returns the ERC-20 used to quote a token, mapping the native
+// placeholder to the wrapped native token
+func QuoteAddress(address common.Address) common.Address {
+	if IsNative(address) {
+		return native.Wrapped
+	}
+	return address
+}
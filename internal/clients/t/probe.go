@@ -0,0 +1,210 @@
+package tokens
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+// probeSource is the EVM assembly of the transfer probe, see probe.easm
+//
+//go:embed probe.easm
+var probeSource string
+
+// probeCode is the compiled runtime code of the transfer probe
+var probeCode = mustCompileProbe(probeSource)
+
+var (
+	funcProbe = w3.MustNewFunc(
+		"probe(address token, address recipient, uint256 divisor, bool roundTrip)",
+		"uint256 amount, uint256 sent, uint256 received, bool sellOk, uint256 sellAmount, uint256 sellSent, uint256 sellReceived",
+	)
+
+	// probeRecipient receives the simulated transfer. It is a fresh address so
+	// it is unlikely to be excluded from fees like the zero or dead address.
+	probeRecipient = common.BytesToAddress(crypto.Keccak256([]byte("defi-aggregator.probe.recipient")))
+
+	// probeDivisor sizes the simulated transfer as a fraction of the holder's balance
+	probeDivisor = big.NewInt(1000)
+
+	// rebasingSelectors are functions exposed by common share-based or
+	// elastic-supply tokens (Lido, Aave aTokens, Ampleforth)
+	rebasingSelectors = Selectors(
+		"sharesOf(address)",
+		"getSharesByPooledEth(uint256)",
+		"scaledBalanceOf(address)",
+		"rebase(uint256,int256)",
+	)
+)
+
+// roundingTolerance is the number of wei a share-based token may lose to rounding
+var roundingTolerance = big.NewInt(2)
+
+// Behaviour describes how a token behaves on transfer
+type Behaviour = models.TokenBehaviour
+
+// Simulation is the raw result of a simulated buy and sell through a holder
+type Simulation struct {
+	BuyOK        bool     // Transfer out of the holder succeeded
+	Amount       *big.Int // Amount transferred out of the holder
+	Sent         *big.Int // Balance debited from the holder
+	Received     *big.Int // Balance credited to the recipient
+	SellOK       bool     // Transfer back to the holder succeeded
+	SellAmount   *big.Int // Amount transferred back to the holder
+	SellSent     *big.Int // Balance debited from the recipient
+	SellReceived *big.Int // Balance credited back to the holder
+	Code         []byte   // Runtime code of the token
+}
+
+// Simulate transfers token out of holder and back using an eth_call state
+// override, and fetches the token's code. The holder must have a balance of
+// the token, a pool for the token is a good choice since transfers to and from
+// it are what buys and sells look like to the token.
+func Simulate(ctx context.Context, token, holder common.Address, nodeURL string) (*Simulation, error) {
+	client, err := node.Dial(nodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to node: %v", err)
+	}
+	defer client.Close()
+
+	var (
+		sim = &Simulation{
+			Amount:       new(big.Int),
+			Sent:         new(big.Int),
+			Received:     new(big.Int),
+			SellAmount:   new(big.Int),
+			SellSent:     new(big.Int),
+			SellReceived: new(big.Int),
+		}
+		overrides = w3types.State{
+			holder:         {Code: probeCode},
+			probeRecipient: {Code: probeCode},
+		}
+	)
+
+	err = client.CallCtx(ctx,
+		eth.CallFunc(holder, funcProbe, token, probeRecipient, probeDivisor, true).
+			Overrides(overrides).
+			Returns(sim.Amount, sim.Sent, sim.Received, &sim.SellOK, sim.SellAmount, sim.SellSent, sim.SellReceived),
+		eth.Code(token, nil).Returns(&sim.Code),
+	)
+
+	// A reverting probe means the token can't be transferred out of the holder
+	callErrs, ok := err.(w3.CallErrors)
+	if err != nil && (!ok || callErrs[1] != nil) {
+		return nil, fmt.Errorf("failed to probe token: %v", err)
+	}
+	sim.BuyOK = !ok || callErrs[0] == nil
+
+	return sim, nil
+}
+
+// ProbeBehaviour simulates a transfer of token out of holder and inspects the
+// token's code for rebasing functions
+func ProbeBehaviour(ctx context.Context, token, holder common.Address, nodeURL string) (*Behaviour, error) {
+	sim, err := Simulate(ctx, token, holder, nodeURL)
+	if err != nil {
+		return nil, err
+	}
+	if !sim.BuyOK {
+		return nil, fmt.Errorf("failed to probe token: transfer from %s reverted", holder.Hex())
+	}
+	return sim.Behaviour(), nil
+}
+
+// Behaviour derives the transfer behaviour from the buy leg of the simulation
+func (sim *Simulation) Behaviour() *Behaviour {
+	behaviour := &Behaviour{
+		Rebasing: HasAnySelector(sim.Code, rebasingSelectors),
+		ProbedAt: time.Now().Unix(),
+	}
+
+	// A holder debited more or less than it sent is a sign of share accounting
+	if sim.Sent.Cmp(sim.Amount) != 0 {
+		behaviour.Rebasing = true
+	}
+
+	feeBps, rounding := TransferFeeBps(sim.Amount, sim.Received)
+	if rounding {
+		// A wei or two lost to share rounding, not a fee
+		behaviour.Rebasing = true
+	}
+	if feeBps > 0 {
+		behaviour.FeeOnTransfer = true
+		behaviour.TransferFeeBps = feeBps
+	}
+
+	return behaviour
+}
+
+// TransferFeeBps returns the fee taken from a transfer of amount that credited
+// received, rounded up so adjusted quotes stay conservative. A shortfall within
+// the rounding tolerance of share-based tokens is reported as rounding instead.
+func TransferFeeBps(amount, received *big.Int) (feeBps uint64, rounding bool) {
+	if amount.Sign() <= 0 || received.Cmp(amount) >= 0 {
+		return 0, false
+	}
+
+	shortfall := new(big.Int).Sub(amount, received)
+	if shortfall.Cmp(roundingTolerance) <= 0 {
+		return 0, true
+	}
+
+	fee := new(big.Int).Mul(shortfall, big.NewInt(10000))
+	fee.Add(fee, new(big.Int).Sub(amount, big.NewInt(1)))
+	fee.Div(fee, amount)
+	if fee.Cmp(big.NewInt(10000)) > 0 {
+		return 10000, false
+	}
+	return fee.Uint64(), false
+}
+
+// mustCompileProbe compiles EVM assembly to bytecode and panics on failure
+func mustCompileProbe(source string) []byte {
+	compiler := asm.NewCompiler(false)
+	compiler.Feed(asm.Lex([]byte(source), false))
+
+	out, errs := compiler.Compile()
+	if len(errs) > 0 {
+		panic(fmt.Sprintf("failed to compile probe: %v", errs))
+	}
+
+	code, err := hex.DecodeString(out)
+	if err != nil {
+		panic(fmt.Sprintf("failed to decode probe: %v", err))
+	}
+	return code
+}
+
+// Selectors returns the 4-byte selectors of the given function signatures
+func Selectors(signatures ...string) [][]byte {
+	out := make([][]byte, len(signatures))
+	for i, signature := range signatures {
+		out[i] = crypto.Keccak256([]byte(signature))[:4]
+	}
+	return out
+}
+
+// HasAnySelector reports whether the code pushes any of the selectors, which is
+// how the Solidity dispatcher matches them
+func HasAnySelector(code []byte, selectors [][]byte) bool {
+	for _, selector := range selectors {
+		if bytes.Contains(code, append([]byte{0x63}, selector...)) {
+			return true
+		}
+	}
+	return false
+}
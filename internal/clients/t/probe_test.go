@@ -0,0 +1,34 @@
+package tokens
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTransferFeeBps(t *testing.T) {
+	tests := []struct {
+		name         string
+		amount       int64
+		received     int64
+		wantFee      uint64
+		wantRounding bool
+	}{
+		{"no fee", 1000000, 1000000, 0, false},
+		{"credited more", 1000000, 1000001, 0, false},
+		{"one percent", 1000000, 990000, 100, false},
+		{"rounds up", 1000000, 999899, 2, false},
+		{"share rounding", 1000000, 999998, 0, true},
+		{"just over rounding", 1000000, 999997, 1, false},
+		{"nothing received", 1000000, 0, 10000, false},
+		{"zero amount", 0, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, rounding := TransferFeeBps(big.NewInt(tt.amount), big.NewInt(tt.received))
+			if fee != tt.wantFee || rounding != tt.wantRounding {
+				t.Errorf("TransferFeeBps(%d, %d) = %d, %v, want %d, %v", tt.amount, tt.received, fee, rounding, tt.wantFee, tt.wantRounding)
+			}
+		})
+	}
+}
@@ -1,43 +1,234 @@
 package tokens
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/lmittmann/w3"
 	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
 )
 
-// Function signatures for Uniswap interactions
+var logger = logging.For("tokens")
+
+// Function signatures for ERC-20 metadata. name() and symbol() are decoded as
+// string first and bytes32 second (e.g. MKR), decimals() as uint256 so
+// tokens returning a wider type than uint8 still decode.
 var (
-	funcName                  = w3.MustNewFunc("name()", "string")
-	funcSymbol                = w3.MustNewFunc("symbol()", "string")
-	funcDecimals              = w3.MustNewFunc("decimals()", "uint8")
+	funcName     = w3.MustNewFunc("name()", "string")
+	funcSymbol   = w3.MustNewFunc("symbol()", "string")
+	funcDecimals = w3.MustNewFunc("decimals()", "uint256")
+	funcBytes32  = w3.MustNewFunc("bytes32()", "bytes32")
+	maxDecimals  = big.NewInt(255)
 )
 
+// ErrNotToken is returned for addresses that aren't ERC-20 tokens
+var ErrNotToken = errors.New("not an ERC-20 token")
+
+// DefaultDecimals is assumed for tokens that don't implement decimals()
+const DefaultDecimals uint8 = 18
+
+// Metadata fields that can be inferred
+const (
+	FieldName     = models.FieldName
+	FieldSymbol   = models.FieldSymbol
+	FieldDecimals = models.FieldDecimals
+)
+
+// Metadata is the ERC-20 metadata of a token
+type Metadata struct {
+	Name     string
+	Symbol   string
+	Decimals uint8
+	Inferred []string // Fields the token didn't return that were filled in
+}
+
+// Partial reports whether any field was inferred rather than read from the token
+func (m *Metadata) Partial() bool {
+	return len(m.Inferred) > 0
+}
+
 // GetTokenMetadata gets token metadata (name, symbol, decimals)
 func GetTokenMetadata(tokenAddress common.Address, nodeURL string) (string, string, uint8, error) {
+	metadata, err := ResolveMetadata(context.Background(), tokenAddress, nodeURL)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return metadata.Name, metadata.Symbol, metadata.Decimals, nil
+}
+
+// ResolveMetadata gets token metadata, tolerating non-standard tokens. Each
+// field is requested separately so a missing name() doesn't fail the others,
+// bytes32 names and symbols are decoded, and missing fields are inferred and
+// listed in Inferred. It only fails if the address has no code or answers none
+// of the metadata calls.
+func ResolveMetadata(ctx context.Context, tokenAddress common.Address, nodeURL string) (*Metadata, error) {
+	metadata, errs, err := ResolveMetadataBatch(ctx, []common.Address{tokenAddress}, nodeURL)
+	if err != nil {
+		return nil, err
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	return metadata[0], nil
+}
+
+// metadataBatchSize is the number of tokens resolved per JSON-RPC batch
+const metadataBatchSize = 100
+
+// ResolveMetadataBatch resolves the metadata of many tokens with one JSON-RPC
+// batch of eth_getCode and eth_call requests per metadataBatchSize tokens. The
+// batch is sent as separate requests rather than a Multicall so each call
+// reverts on its own and it works on chains without a Multicall contract.
+// Results and per-token errors are in the same order as tokenAddresses; the
+// returned error is only set if the node can't be reached.
+func ResolveMetadataBatch(ctx context.Context, tokenAddresses []common.Address, nodeURL string) ([]*Metadata, []error, error) {
+	results := make([]*Metadata, len(tokenAddresses))
+	errs := make([]error, len(tokenAddresses))
+
+	// The native currency has no contract to query
+	var pending []int
+	for i, tokenAddress := range tokenAddresses {
+		if IsNative(tokenAddress) {
+			results[i] = &Metadata{Name: native.Name, Symbol: native.Symbol, Decimals: native.Decimals}
+			continue
+		}
+		pending = append(pending, i)
+	}
+	if len(pending) == 0 {
+		return results, errs, nil
+	}
+
 	// Create a client
-	client := w3.MustDial(nodeURL)
-	defer client.Close()
-	
-	// Get token metadata
-	var (
-		name     string
-		symbol   string
-		decimals uint8
-	)
-	
-	err := client.Call(
-		eth.CallFunc(tokenAddress, funcName).Returns(&name),
-		eth.CallFunc(tokenAddress, funcSymbol).Returns(&symbol),
-		eth.CallFunc(tokenAddress, funcDecimals).Returns(&decimals),
-	)
-	
+	client, err := node.Dial(nodeURL)
 	if err != nil {
-		log.Printf("Error getting token metadata: %v", err)
-		return "", "", 0, fmt.Errorf("failed to get token metadata: %v", err)
+		return nil, nil, fmt.Errorf("failed to connect to node: %v", err)
+	}
+	defer client.Close()
+
+	for start := 0; start < len(pending); start += metadataBatchSize {
+		end := start + metadataBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		chunk := pending[start:end]
+
+		// Four calls per token: code, name, symbol, decimals
+		code := make([][]byte, len(chunk))
+		raw := make([][3][]byte, len(chunk))
+		calls := make([]w3types.RPCCaller, 0, 4*len(chunk))
+		for j, i := range chunk {
+			tokenAddress := tokenAddresses[i]
+			calls = append(calls,
+				eth.Code(tokenAddress, nil).Returns(&code[j]),
+				eth.Call(metadataMsg(tokenAddress, funcName), nil, nil).Returns(&raw[j][0]),
+				eth.Call(metadataMsg(tokenAddress, funcSymbol), nil, nil).Returns(&raw[j][1]),
+				eth.Call(metadataMsg(tokenAddress, funcDecimals), nil, nil).Returns(&raw[j][2]),
+			)
+		}
+
+		// Individual calls may revert, anything else is a connection problem
+		err := client.CallCtx(ctx, calls...)
+		callErrs, ok := err.(w3.CallErrors)
+		if err != nil && !ok {
+			logger.ErrorContext(ctx, "Failed to get token metadata", "tokens", len(chunk), "error", err)
+			return nil, nil, fmt.Errorf("failed to get token metadata: %v", err)
+		}
+
+		for j, i := range chunk {
+			if ok && callErrs[4*j] != nil {
+				errs[i] = fmt.Errorf("failed to get token metadata: %v", callErrs[4*j])
+				continue
+			}
+			results[i], errs[i] = decodeMetadata(tokenAddresses[i], code[j], raw[j])
+		}
+	}
+
+	return results, errs, nil
+}
+
+// decodeMetadata builds metadata from the raw name(), symbol() and decimals()
+// return values, inferring whatever is missing
+func decodeMetadata(tokenAddress common.Address, code []byte, raw [3][]byte) (*Metadata, error) {
+	if len(code) == 0 {
+		return nil, fmt.Errorf("failed to get token metadata: no contract at %s: %w", tokenAddress.Hex(), ErrNotToken)
+	}
+
+	name, hasName := decodeText(raw[0])
+	symbol, hasSymbol := decodeText(raw[1])
+	decimals, hasDecimals := decodeDecimals(raw[2])
+
+	if !hasName && !hasSymbol && !hasDecimals {
+		return nil, fmt.Errorf("failed to get token metadata: %s does not implement ERC-20 metadata: %w", tokenAddress.Hex(), ErrNotToken)
+	}
+
+	metadata := &Metadata{Name: name, Symbol: symbol, Decimals: decimals}
+
+	if !hasSymbol {
+		metadata.Symbol = strings.ToUpper(tokenAddress.Hex()[2:8])
+		metadata.Inferred = append(metadata.Inferred, FieldSymbol)
+	}
+	if !hasName {
+		metadata.Name = metadata.Symbol
+		metadata.Inferred = append(metadata.Inferred, FieldName)
+	}
+	if !hasDecimals {
+		metadata.Decimals = DefaultDecimals
+		metadata.Inferred = append(metadata.Inferred, FieldDecimals)
+	}
+
+	return metadata, nil
+}
+
+// metadataMsg builds a call to a metadata function without arguments
+func metadataMsg(tokenAddress common.Address, fn w3types.Func) *w3types.Message {
+	return &w3types.Message{To: &tokenAddress, Func: fn}
+}
+
+// decodeText decodes a name() or symbol() return value encoded as either
+// string or bytes32
+func decodeText(raw []byte) (string, bool) {
+	if len(raw) == 0 {
+		return "", false
+	}
+
+	// Blank text counts as missing. An empty string must not fall through to
+	// bytes32, its offset word decodes as a space.
+	var text string
+	if err := funcName.DecodeReturns(raw, &text); err == nil {
+		text = strings.TrimSpace(text)
+		return text, text != ""
+	}
+
+	var word [32]byte
+	if err := funcBytes32.DecodeReturns(raw, &word); err == nil {
+		text := strings.TrimSpace(string(bytes.TrimRight(word[:], "\x00")))
+		if text != "" && utf8.ValidString(text) {
+			return text, true
+		}
+	}
+
+	return "", false
+}
+
+// decodeDecimals decodes a decimals() return value that fits in a uint8
+func decodeDecimals(raw []byte) (uint8, bool) {
+	if len(raw) == 0 {
+		return 0, false
+	}
+
+	var decimals big.Int
+	if err := funcDecimals.DecodeReturns(raw, &decimals); err != nil || decimals.Cmp(maxDecimals) > 0 {
+		return 0, false
 	}
-	
-	return name, symbol, decimals, nil
-}
\ No newline at end of file
+	return uint8(decimals.Uint64()), true
+}
@@ -0,0 +1,76 @@
+package tokens
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+)
+
+var testToken = common.HexToAddress("0xabcdef0000000000000000000000000000000001")
+
+// encodeReturn ABI-encodes a value as a function would return it
+func encodeReturn(t *testing.T, typ string, value any) []byte {
+	t.Helper()
+	data, err := w3.MustNewFunc("f("+typ+")", "").EncodeArgs(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data[4:] // Without the selector
+}
+
+func TestDecodeMetadata(t *testing.T) {
+	code := []byte{0x60}
+	name := encodeReturn(t, "string", "Wrapped Ether")
+	symbol := encodeReturn(t, "string", "WETH")
+	decimals := encodeReturn(t, "uint256", big.NewInt(18))
+	bytes32Symbol := encodeReturn(t, "bytes32", [32]byte{'M', 'K', 'R'})
+
+	tests := []struct {
+		name    string
+		code    []byte
+		raw     [3][]byte
+		want    Metadata
+		wantErr bool
+	}{
+		{"standard", code, [3][]byte{name, symbol, decimals}, Metadata{Name: "Wrapped Ether", Symbol: "WETH", Decimals: 18}, false},
+		{"bytes32 symbol", code, [3][]byte{nil, bytes32Symbol, encodeReturn(t, "uint256", big.NewInt(18))},
+			Metadata{Name: "MKR", Symbol: "MKR", Decimals: 18, Inferred: []string{FieldName}}, false},
+		{"padded text", code, [3][]byte{encodeReturn(t, "string", " Token "), symbol, decimals}, Metadata{Name: "Token", Symbol: "WETH", Decimals: 18}, false},
+		{"no symbol", code, [3][]byte{name, nil, decimals},
+			Metadata{Name: "Wrapped Ether", Symbol: "ABCDEF", Decimals: 18, Inferred: []string{FieldSymbol}}, false},
+		{"no decimals", code, [3][]byte{name, symbol, nil},
+			Metadata{Name: "Wrapped Ether", Symbol: "WETH", Decimals: DefaultDecimals, Inferred: []string{FieldDecimals}}, false},
+		{"decimals over uint8", code, [3][]byte{name, symbol, encodeReturn(t, "uint256", big.NewInt(256))},
+			Metadata{Name: "Wrapped Ether", Symbol: "WETH", Decimals: DefaultDecimals, Inferred: []string{FieldDecimals}}, false},
+		{"only decimals", code, [3][]byte{nil, nil, encodeReturn(t, "uint256", big.NewInt(6))},
+			Metadata{Name: "ABCDEF", Symbol: "ABCDEF", Decimals: 6, Inferred: []string{FieldSymbol, FieldName}}, false},
+		{"empty string", code, [3][]byte{encodeReturn(t, "string", ""), symbol, decimals},
+			Metadata{Name: "WETH", Symbol: "WETH", Decimals: 18, Inferred: []string{FieldName}}, false},
+		{"no code", nil, [3][]byte{name, symbol, decimals}, Metadata{}, true},
+		{"no metadata", code, [3][]byte{}, Metadata{}, true},
+		{"garbage", code, [3][]byte{{0x01}, {0x02}, {0x03}}, Metadata{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, err := decodeMetadata(testToken, tt.code, tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNotToken) {
+					t.Errorf("decodeMetadata error = %v, want ErrNotToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if metadata.Name != tt.want.Name || metadata.Symbol != tt.want.Symbol || metadata.Decimals != tt.want.Decimals ||
+				!slices.Equal(metadata.Inferred, tt.want.Inferred) {
+				t.Errorf("decodeMetadata = %+v, want %+v", *metadata, tt.want)
+			}
+		})
+	}
+}
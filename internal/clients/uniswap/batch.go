@@ -0,0 +1,121 @@
+package uniswap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+// callBatchSize is the number of calls sent per RPC batch
+const callBatchSize = 500
+
+// ErrBatchFailed is wrapped by the errors of calls whose RPC batch failed as a
+// whole, e.g. because the node went away or the deadline passed, rather than
+// the call itself reverting
+var ErrBatchFailed = errors.New("RPC batch failed")
+
+// PoolLookup is a pool to look up in a factory
+type PoolLookup struct {
+	TokenA, TokenB common.Address
+	Fee            uint64
+	Factory        common.Address
+}
+
+// QuoteCall is a single-pool quote to get from a router
+type QuoteCall struct {
+	TokenIn, TokenOut common.Address
+	Fee               uint64
+	AmountIn          *big.Int
+	Router            common.Address
+}
+
+// GetPoolAddresses looks up many pools with one RPC batch per callBatchSize
+// lookups. Pools that don't exist are the zero address. Per-lookup errors are
+// in the same order as lookups; the returned error is only set if the node
+// can't be dialled.
+func GetPoolAddresses(ctx context.Context, lookups []PoolLookup, nodeURL string) ([]common.Address, []error, error) {
+	pools := make([]common.Address, len(lookups))
+	calls := make([]w3types.RPCCaller, len(lookups))
+	for i, lookup := range lookups {
+		calls[i] = eth.CallFunc(lookup.Factory, funcGetPool, lookup.TokenA, lookup.TokenB, new(big.Int).SetUint64(lookup.Fee)).Returns(&pools[i])
+	}
+
+	errs, err := callBatch(ctx, calls, nodeURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pools, errs, nil
+}
+
+// GetQuotes gets many single-pool quotes with one RPC batch per
+// callBatchSize quotes. Results and per-quote errors are in the same order as
+// quotes; the returned error is only set if the node can't be dialled.
+func GetQuotes(ctx context.Context, quotes []QuoteCall, nodeURL string) ([]*big.Int, []error, error) {
+	amountsOut := make([]*big.Int, len(quotes))
+	calls := make([]w3types.RPCCaller, len(quotes))
+	for i, quote := range quotes {
+		amountsOut[i] = new(big.Int)
+		calls[i] = eth.CallFunc(
+			quote.Router,
+			funcQuoteExactInputSingle,
+			quote.TokenIn,
+			quote.TokenOut,
+			new(big.Int).SetUint64(quote.Fee),
+			quote.AmountIn,
+			w3.Big0,
+		).Returns(amountsOut[i])
+	}
+
+	errs, err := callBatch(ctx, calls, nodeURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range amountsOut {
+		if errs[i] != nil {
+			amountsOut[i] = nil
+		}
+	}
+	return amountsOut, errs, nil
+}
+
+// callBatch sends calls in RPC batches of callBatchSize, returning the error
+// of each call. A batch that fails doesn't stop the others, its calls get an
+// error wrapping ErrBatchFailed.
+func callBatch(ctx context.Context, calls []w3types.RPCCaller, nodeURL string) ([]error, error) {
+	errs := make([]error, len(calls))
+	if len(calls) == 0 {
+		return errs, nil
+	}
+
+	// Create a client
+	client, err := node.Dial(nodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to node: %v", err)
+	}
+	defer client.Close()
+
+	for start := 0; start < len(calls); start += callBatchSize {
+		end := start + callBatchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+
+		// Individual calls may revert, anything else is a connection problem
+		err := client.CallCtx(ctx, calls[start:end]...)
+		if callErrs, ok := err.(w3.CallErrors); ok {
+			copy(errs[start:end], callErrs)
+		} else if err != nil {
+			for i := start; i < end; i++ {
+				errs[i] = fmt.Errorf("%w: %v", ErrBatchFailed, err)
+			}
+		}
+	}
+	return errs, nil
+}
@@ -1,16 +1,25 @@
 package uniswap
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"math/big"
 
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/lmittmann/w3"
 	"github.com/lmittmann/w3/module/eth"
 	"github.com/lmittmann/w3/w3types"
 )
 
+var logger = logging.For("uniswap")
+
+// ErrPoolNotFound is returned by GetPoolAddress if the factory has no pool
+// for the pair and fee tier
+var ErrPoolNotFound = errors.New("pool doesn't exist")
+
 // Function signatures for Uniswap interactions
 var (
 	funcQuoteExactInputSingle = w3.MustNewFunc("quoteExactInputSingle(address tokenIn, address tokenOut, uint24 fee, uint256 amountIn, uint160 sqrtPriceLimitX96)", "uint256 amountOut")
@@ -22,6 +31,7 @@ var (
 
 // GetQuoteExactInputSingle gets a quote for a swap directly from the router contract
 func GetQuoteExactInputSingle(
+	ctx context.Context,
 	tokenIn, tokenOut common.Address,
 	fee *big.Int,
 	amountIn *big.Int,
@@ -29,13 +39,14 @@ func GetQuoteExactInputSingle(
 	nodeURL string,
 ) (*big.Int, error) {
 	// Create a client
-	client := w3.MustDial(nodeURL)
+	client := node.MustDial(nodeURL)
 	defer client.Close()
 	
 	// Get quote
 	var amountOut big.Int
 	
-	err := client.Call(
+	err := client.CallCtx(
+		ctx,
 		eth.CallFunc(routerAddress, funcQuoteExactInputSingle, tokenIn, tokenOut, fee, amountIn, w3.Big0).Returns(&amountOut),
 	)
 	
@@ -48,19 +59,21 @@ func GetQuoteExactInputSingle(
 
 // GetPoolAddress gets the pool address for a pair of tokens and a fee tier
 func GetPoolAddress(
+	ctx context.Context,
 	tokenIn, tokenOut common.Address,
 	fee *big.Int,
 	factoryAddress common.Address,
 	nodeURL string,
 ) (common.Address, error) {
 	// Create a client
-	client := w3.MustDial(nodeURL)
+	client := node.MustDial(nodeURL)
 	defer client.Close()
 	
 	// Get pool address
 	var poolAddress common.Address
 	
-	err := client.Call(
+	err := client.CallCtx(
+		ctx,
 		eth.CallFunc(factoryAddress, funcGetPool, tokenIn, tokenOut, fee).Returns(&poolAddress),
 	)
 	
@@ -70,7 +83,7 @@ func GetPoolAddress(
 	
 	// If the returned address is zero, the pool doesn't exist
 	if poolAddress == (common.Address{}) {
-		return common.Address{}, fmt.Errorf("pool doesn't exist")
+		return common.Address{}, ErrPoolNotFound
 	}
 	
 	return poolAddress, nil
@@ -85,7 +98,7 @@ func GetAllQuotes(
 	nodeURL string,
 ) (map[uint64]*big.Int, error) {
 	// Create a client
-	client := w3.MustDial(nodeURL)
+	client := node.MustDial(nodeURL)
 	defer client.Close()
 	
 	// Prepare calls for all fee tiers
@@ -123,7 +136,7 @@ func GetAllQuotes(
 	for i, feeTier := range feeTiers {
 		// Skip failed calls
 		if ok && callErrs[i] != nil {
-			log.Printf("Failed to get quote for fee tier %d: %v", feeTier, callErrs[i])
+			logger.Warn("Failed to get quote", "fee", feeTier, "error", callErrs[i])
 			continue
 		}
 		
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/lmittmann/w3"
 	"github.com/lmittmann/w3/module/eth"
@@ -34,7 +35,7 @@ func GetPairAddress(
 	nodeURL string,
 ) (common.Address, error) {
 	// Create a client
-	client := w3.MustDial(nodeURL)
+	client := node.MustDial(nodeURL)
 	defer client.Close()
 	
 	// Get pair address
@@ -62,7 +63,7 @@ func GetReserves(
 	nodeURL string,
 ) (*big.Int, *big.Int, uint32, error) {
 	// Create a client
-	client := w3.MustDial(nodeURL)
+	client := node.MustDial(nodeURL)
 	defer client.Close()
 	
 	// Get reserves
@@ -86,7 +87,7 @@ func GetTokenOrder(
 	nodeURL string,
 ) (common.Address, common.Address, error) {
 	// Create a client
-	client := w3.MustDial(nodeURL)
+	client := node.MustDial(nodeURL)
 	defer client.Close()
 	
 	// Get token0 and token1
@@ -149,7 +150,7 @@ func GetQuote(
 	}
 	
 	// Get token order
-	token0, token1, err := GetTokenOrder(pairAddress, nodeURL)
+	token0, _, err := GetTokenOrder(pairAddress, nodeURL)
 	if err != nil {
 		return nil, err
 	}
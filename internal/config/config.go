@@ -1,19 +1,69 @@
 package config
 
 import (
-	"log"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
 	"github.com/joho/godotenv"
 )
 
+var logger = logging.For("config")
+
 // Config holds all our environment configurations
 type Config struct {
-	Port          string
-	RedisURL      string
-	NodeURL       string
-	RedisPassword string
-	APIKey        string
+	Port             string
+	RedisURL         string
+	NodeURL          string
+	RedisPassword    string
+	StorageBackend   string
+	APIKey           string
+	ChainID          uint64
+	NativeName       string
+	NativeSymbol     string
+	WrappedNative    string
+	SwapRouters      string
+	TokenListURL     string
+	TokenListPath    string
+	TokenListOffline bool
+	TokenBatchLimit  uint64
+	TokenCacheTTL    time.Duration
+	TokenMissTTL     time.Duration
+	TokenLRUSize     uint64
+	TokenLRUTTL      time.Duration
+	QuoteCacheTTL    time.Duration
+	QuoteCacheDigits uint64
+	GraphRefresh     time.Duration
+	GraphMaxHops     uint64
+
+	// Quote streaming
+	NodeWSURL              string
+	StreamPollInterval     time.Duration
+	StreamMaxConnections   uint64
+	StreamMaxSubscriptions uint64
+
+	// gRPC API, disabled if GRPCPort is empty
+	GRPCPort       string
+	GRPCReflection bool
+
+	// Batch quotes
+	QuoteBatchLimit   uint64
+	QuoteBatchTimeout time.Duration
+
+	// Rate limits and usage metering
+	RateLimits     string
+	UsageRetention time.Duration
+
+	// Proxies whose X-Forwarded-For is trusted for the client IP, none if empty
+	TrustedProxies string
+
+	// OpenTelemetry traces, not exported if OTLPEndpoint is empty
+	OTLPEndpoint string
+
+	// Logging, LogLevel is a default level then per-package levels
+	LogLevel  string
+	LogFormat string
 }
 
 // Global config instance
@@ -24,19 +74,58 @@ func InitConfig() Config {
 	// Load .env file
 	err := godotenv.Load()
 	if err != nil {
-		log.Printf("Warning: .env file not found: %v", err)
+		logger.Warn(".env file not found", "error", err)
 	}
 
 	// Initialize config with environment variables
 	AppConfig = Config{
-		Port:          GetEnvWithDefault("PORT", "8080"),
-		RedisURL:      GetEnvWithDefault("REDIS_URL", "localhost:6379"),
-		NodeURL:       GetEnvWithDefault("NODE_URL", "https://testnet-rpc.monad.xyz/"),
-		RedisPassword: GetEnvWithDefault("REDIS_PASSWORD", "Test1234!"),
-		APIKey:        GetEnvWithDefault("API_KEY", "your-api-key"),
+		Port:             GetEnvWithDefault("PORT", "8080"),
+		RedisURL:         GetEnvWithDefault("REDIS_URL", "localhost:6379"),
+		NodeURL:          GetEnvWithDefault("NODE_URL", "https://testnet-rpc.monad.xyz/"),
+		RedisPassword:    GetEnvWithDefault("REDIS_PASSWORD", "Test1234!"),
+		StorageBackend:   GetEnvWithDefault("STORAGE_BACKEND", "redis"),
+		APIKey:           GetEnvWithDefault("API_KEY", ""),
+		ChainID:          GetEnvUint64WithDefault("CHAIN_ID", 10143),
+		NativeName:       GetEnvWithDefault("NATIVE_NAME", "Monad"),
+		NativeSymbol:     GetEnvWithDefault("NATIVE_SYMBOL", "MON"),
+		WrappedNative:    GetEnvWithDefault("WRAPPED_NATIVE_ADDRESS", "0x760AfE86e5de5fa0Ee542fc7B7B713e1c5425701"),
+		SwapRouters:      GetEnvWithDefault("SWAP_ROUTERS", ""),
+		TokenListURL:     GetEnvWithDefault("TOKEN_LIST_URL", ""),
+		TokenListPath:    GetEnvWithDefault("TOKEN_LIST_PATH", ""),
+		TokenListOffline: GetEnvBoolWithDefault("TOKEN_LIST_OFFLINE", false),
+		TokenBatchLimit:  GetEnvUint64WithDefault("TOKEN_BATCH_LIMIT", 500),
+		TokenCacheTTL:    GetEnvDurationWithDefault("TOKEN_CACHE_TTL", 24*time.Hour),
+		TokenMissTTL:     GetEnvDurationWithDefault("TOKEN_MISS_TTL", 10*time.Minute),
+		TokenLRUSize:     GetEnvUint64WithDefault("TOKEN_LRU_SIZE", 4096),
+		TokenLRUTTL:      GetEnvDurationWithDefault("TOKEN_LRU_TTL", 5*time.Minute),
+		QuoteCacheTTL:    GetEnvDurationWithDefault("QUOTE_CACHE_TTL", 30*time.Second),
+		QuoteCacheDigits: GetEnvUint64WithDefault("QUOTE_CACHE_AMOUNT_DIGITS", 0),
+		GraphRefresh:     GetEnvDurationWithDefault("GRAPH_REFRESH_INTERVAL", 5*time.Minute),
+		GraphMaxHops:     GetEnvUint64WithDefault("GRAPH_MAX_HOPS", 3),
+
+		NodeWSURL:              GetEnvWithDefault("NODE_WS_URL", ""),
+		StreamPollInterval:     GetEnvPositiveDurationWithDefault("STREAM_POLL_INTERVAL", time.Second),
+		StreamMaxConnections:   GetEnvUint64WithDefault("STREAM_MAX_CONNECTIONS", 1000),
+		StreamMaxSubscriptions: GetEnvUint64WithDefault("STREAM_MAX_SUBSCRIPTIONS", 10),
+
+		GRPCPort:       LookupEnvWithDefault("GRPC_PORT", "9090"),
+		GRPCReflection: GetEnvBoolWithDefault("GRPC_REFLECTION", false),
+
+		QuoteBatchLimit:   GetEnvUint64WithDefault("QUOTE_BATCH_LIMIT", 500),
+		QuoteBatchTimeout: GetEnvDurationWithDefault("QUOTE_BATCH_TIMEOUT", 30*time.Second),
+
+		RateLimits:     GetEnvWithDefault("RATE_LIMITS", "ip=50/s:100,quote=10/s:20,token:write=1/s:5,/quotes/batch=10/m:5,/depth=30/m:10"),
+		UsageRetention: GetEnvDurationWithDefault("USAGE_RETENTION", 90*24*time.Hour),
+
+		TrustedProxies: GetEnvWithDefault("TRUSTED_PROXIES", ""),
+
+		OTLPEndpoint: GetEnvWithDefault("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+
+		LogLevel:  GetEnvWithDefault("LOG_LEVEL", "info"),
+		LogFormat: GetEnvWithDefault("LOG_FORMAT", "json"),
 	}
 
-	log.Printf("Config loaded. Port: %s", AppConfig.Port)
+	logger.Info("Config loaded", "port", AppConfig.Port)
 	return AppConfig
 }
 
@@ -49,7 +138,71 @@ func GetEnvWithDefault(key, defaultValue string) string {
 	return value
 }
 
+// LookupEnvWithDefault gets an environment variable or returns a default value
+// if it isn't set. Unlike GetEnvWithDefault an empty value is kept, so it can
+// turn off a setting that is on by default.
+func LookupEnvWithDefault(key, defaultValue string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+	return value
+}
+
+// GetEnvUint64WithDefault gets an environment variable as an unsigned integer or returns a default value
+func GetEnvUint64WithDefault(key string, defaultValue uint64) uint64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		logger.Warn("Invalid value, using default", "key", key, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// GetEnvBoolWithDefault gets an environment variable as a boolean or returns a default value
+func GetEnvBoolWithDefault(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.Warn("Invalid value, using default", "key", key, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// GetEnvDurationWithDefault gets an environment variable as a duration (e.g. 10m) or returns a default value
+func GetEnvDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		logger.Warn("Invalid value, using default", "key", key, "value", value, "default", defaultValue.String())
+		return defaultValue
+	}
+	return parsed
+}
+
+// GetEnvPositiveDurationWithDefault gets an environment variable as a duration
+// that must be more than zero, e.g. an interval, or returns a default value
+func GetEnvPositiveDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	value := GetEnvDurationWithDefault(key, defaultValue)
+	if value <= 0 {
+		logger.Warn("Invalid value, using default", "key", key, "value", value.String(), "default", defaultValue.String())
+		return defaultValue
+	}
+	return value
+}
+
 // GetConfig returns the current configuration
 func GetConfig() Config {
 	return AppConfig
-}
\ No newline at end of file
+}
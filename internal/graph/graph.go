@@ -0,0 +1,371 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var logger = logging.For("graph")
+
+var (
+	// ErrUnknownToken is returned for tokens that aren't in any known pool
+	ErrUnknownToken = errors.New("token is not in any known pool")
+	// ErrNoPath is returned when two tokens aren't connected within the hop limit
+	ErrNoPath = errors.New("no path between tokens")
+)
+
+// PoolSource lists the known pools, e.g. the pool registry
+type PoolSource interface {
+	AllProtocolPairs() []pairs.ProtocolPair
+}
+
+// Token is a node of the graph
+type Token struct {
+	Address  common.Address `json:"address"`
+	Symbol   string         `json:"symbol,omitempty"`
+	Decimals uint8          `json:"decimals"`
+}
+
+// Edge is a pool that swaps From into To. Share weighs the edge by liquidity:
+// the fraction of all pooled From held by this pool, 0 if its reserves are unknown.
+type Edge struct {
+	Protocol   string         `json:"protocol"`
+	Pool       common.Address `json:"pool"`
+	Fee        uint64         `json:"fee"`
+	From       Token          `json:"tokenIn"`
+	To         Token          `json:"tokenOut"`
+	ReserveIn  *utils.Amount  `json:"reserveIn,omitempty"`
+	ReserveOut *utils.Amount  `json:"reserveOut,omitempty"`
+	Share      float64        `json:"liquidityShare"`
+}
+
+// Neighbour is a token that can be swapped into directly and the pools to do it
+type Neighbour struct {
+	Token Token   `json:"token"`
+	Share float64 `json:"liquidityShare"` // Sum of the pools' shares
+	Pools []Edge  `json:"pools"`
+}
+
+// Reach is a token reachable within a number of hops
+type Reach struct {
+	Token Token `json:"token"`
+	Hops  int   `json:"hops"`
+}
+
+// Path is a sequence of pools connecting two tokens. Liquidity is the share
+// of its weakest hop.
+type Path struct {
+	Hops      []Edge  `json:"hops"`
+	Liquidity float64 `json:"liquidityShare"`
+}
+
+// Graph is an in-memory liquidity graph of tokens connected by pools, rebuilt
+// from the pool source on Refresh
+type Graph struct {
+	source  PoolSource
+	nodeURL string
+
+	mu        sync.RWMutex
+	tokens    map[common.Address]Token
+	edges     map[common.Address][]Edge
+	pools     int
+	updatedAt time.Time
+}
+
+// New creates an empty graph of the pools in source
+func New(source PoolSource, nodeURL string) *Graph {
+	return &Graph{
+		source:  source,
+		nodeURL: nodeURL,
+		tokens:  make(map[common.Address]Token),
+		edges:   make(map[common.Address][]Edge),
+	}
+}
+
+// Run refreshes the graph now and then every interval until ctx is done. An
+// interval of 0 only refreshes it once.
+func (g *Graph) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		if err := g.Refresh(ctx); err != nil {
+			logger.ErrorContext(ctx, "Failed to refresh token graph", "error", err)
+		}
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := g.Refresh(ctx); err != nil {
+			logger.ErrorContext(ctx, "Failed to refresh token graph", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh rebuilds the graph from the pool source, reading every pool's
+// reserves in one batch. Pools known to be empty are left out; pools whose
+// reserves can't be read are kept with no weight. The previous graph is kept
+// if the node can't be reached.
+func (g *Graph) Refresh(ctx context.Context) error {
+	var pools []pairs.ProtocolPair
+	seen := make(map[string]bool)
+	for _, pool := range g.source.AllProtocolPairs() {
+		key := pool.ProtocolName + ":" + strings.ToLower(pool.ContractAddress)
+		if seen[key] || !common.IsHexAddress(pool.ContractAddress) {
+			continue
+		}
+		seen[key] = true
+		pools = append(pools, pool)
+	}
+
+	holdings := make([]tokens.Holding, 0, 2*len(pools))
+	for _, pool := range pools {
+		address := common.HexToAddress(pool.ContractAddress)
+		holdings = append(holdings,
+			tokens.Holding{Token: pool.Pair.Token0.Address, Holder: address},
+			tokens.Holding{Token: pool.Pair.Token1.Address, Holder: address},
+		)
+	}
+
+	balances, _, err := tokens.BalanceBatch(ctx, holdings, g.nodeURL)
+	if err != nil {
+		return fmt.Errorf("failed to read pool reserves: %v", err)
+	}
+
+	// Total pooled amount of each token, to weigh pools against each other
+	totals := make(map[common.Address]*big.Int)
+	for i, holding := range holdings {
+		if balances[i] == nil {
+			continue
+		}
+		token := tokens.QuoteAddress(holding.Token)
+		if totals[token] == nil {
+			totals[token] = new(big.Int)
+		}
+		totals[token].Add(totals[token], balances[i])
+	}
+
+	nodes := make(map[common.Address]Token)
+	edges := make(map[common.Address][]Edge)
+	for i, pool := range pools {
+		reserve0, reserve1 := balances[2*i], balances[2*i+1]
+		if (reserve0 != nil && reserve0.Sign() == 0) || (reserve1 != nil && reserve1.Sign() == 0) {
+			continue
+		}
+
+		token0, token1 := newToken(pool.Pair.Token0), newToken(pool.Pair.Token1)
+		nodes[token0.Address], nodes[token1.Address] = token0, token1
+
+		address := common.HexToAddress(pool.ContractAddress)
+		edges[token0.Address] = append(edges[token0.Address], newEdge(pool, address, token0, token1, reserve0, reserve1, totals))
+		edges[token1.Address] = append(edges[token1.Address], newEdge(pool, address, token1, token0, reserve1, reserve0, totals))
+	}
+
+	for _, tokenEdges := range edges {
+		sortEdges(tokenEdges)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tokens, g.edges, g.pools, g.updatedAt = nodes, edges, len(pools), time.Now()
+	return nil
+}
+
+// Stats returns the size of the graph and when it was last refreshed
+func (g *Graph) Stats() (tokenCount, poolCount int, updatedAt time.Time) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.tokens), g.pools, g.updatedAt
+}
+
+// Neighbours returns the tokens a token swaps into directly, most liquid first
+func (g *Graph) Neighbours(token common.Address) (Token, []Neighbour, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	node, ok := g.tokens[token]
+	if !ok {
+		return Token{}, nil, ErrUnknownToken
+	}
+
+	byToken := make(map[common.Address]*Neighbour)
+	var neighbours []*Neighbour
+	for _, edge := range g.edges[token] {
+		neighbour, ok := byToken[edge.To.Address]
+		if !ok {
+			neighbour = &Neighbour{Token: edge.To}
+			byToken[edge.To.Address] = neighbour
+			neighbours = append(neighbours, neighbour)
+		}
+		neighbour.Share += edge.Share
+		neighbour.Pools = append(neighbour.Pools, edge)
+	}
+
+	sort.SliceStable(neighbours, func(i, j int) bool {
+		return neighbours[i].Share > neighbours[j].Share
+	})
+
+	result := make([]Neighbour, len(neighbours))
+	for i, neighbour := range neighbours {
+		result[i] = *neighbour
+	}
+	return node, result, nil
+}
+
+// Reachable returns every token reachable from token within maxHops swaps,
+// nearest first
+func (g *Graph) Reachable(token common.Address, maxHops int) (Token, []Reach, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	node, ok := g.tokens[token]
+	if !ok {
+		return Token{}, nil, ErrUnknownToken
+	}
+
+	hops := map[common.Address]int{token: 0}
+	frontier := []common.Address{token}
+	var reached []Reach
+	for depth := 1; depth <= maxHops && len(frontier) > 0; depth++ {
+		var next []common.Address
+		for _, from := range frontier {
+			for _, edge := range g.edges[from] {
+				if _, ok := hops[edge.To.Address]; ok {
+					continue
+				}
+				hops[edge.To.Address] = depth
+				next = append(next, edge.To.Address)
+				reached = append(reached, Reach{Token: edge.To, Hops: depth})
+			}
+		}
+		frontier = next
+	}
+
+	sort.SliceStable(reached, func(i, j int) bool {
+		if reached[i].Hops != reached[j].Hops {
+			return reached[i].Hops < reached[j].Hops
+		}
+		return reached[i].Token.Symbol < reached[j].Token.Symbol
+	})
+	return node, reached, nil
+}
+
+// BestPath returns the path from one token to another with the fewest hops,
+// up to maxHops. Among paths of that length it picks the one whose weakest
+// pool holds the largest share of its input token's liquidity.
+func (g *Graph) BestPath(from, to common.Address, maxHops int) (*Path, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if _, ok := g.tokens[from]; !ok {
+		return nil, fmt.Errorf("%s: %w", from.Hex(), ErrUnknownToken)
+	}
+	if _, ok := g.tokens[to]; !ok {
+		return nil, fmt.Errorf("%s: %w", to.Hex(), ErrUnknownToken)
+	}
+	if from == to {
+		return nil, fmt.Errorf("tokenIn and tokenOut are the same")
+	}
+
+	// Breadth first one hop at a time, keeping the widest way into each token
+	// of the current hop
+	depth := map[common.Address]int{from: 0}
+	width := map[common.Address]float64{from: 1}
+	previous := make(map[common.Address]Edge)
+	frontier := []common.Address{from}
+	for hop := 1; hop <= maxHops && len(frontier) > 0; hop++ {
+		var next []common.Address
+		for _, token := range frontier {
+			for _, edge := range g.edges[token] {
+				reachedAt, seen := depth[edge.To.Address]
+				if seen && reachedAt < hop {
+					continue
+				}
+
+				score := width[token]
+				if edge.Share < score {
+					score = edge.Share
+				}
+				if !seen {
+					depth[edge.To.Address] = hop
+					next = append(next, edge.To.Address)
+				} else if score <= width[edge.To.Address] {
+					continue
+				}
+				width[edge.To.Address] = score
+				previous[edge.To.Address] = edge
+			}
+		}
+
+		if _, ok := depth[to]; ok {
+			path := &Path{Liquidity: width[to]}
+			for token := to; token != from; {
+				edge := previous[token]
+				path.Hops = append([]Edge{edge}, path.Hops...)
+				token = edge.From.Address
+			}
+			return path, nil
+		}
+		frontier = next
+	}
+
+	return nil, ErrNoPath
+}
+
+// newToken converts a pool token to a node, mapping the native currency
+// placeholder to its wrapped token
+func newToken(token pairs.ERC20Token) Token {
+	return Token{Address: tokens.QuoteAddress(token.Address), Symbol: token.Symbol, Decimals: token.Decimals}
+}
+
+// newEdge builds the edge swapping from into to through a pool
+func newEdge(pool pairs.ProtocolPair, address common.Address, from, to Token, reserveIn, reserveOut *big.Int, totals map[common.Address]*big.Int) Edge {
+	edge := Edge{
+		Protocol: pool.ProtocolName,
+		Pool:     address,
+		Fee:      pool.Fee,
+		From:     from,
+		To:       to,
+	}
+	if reserveIn != nil {
+		reserve := utils.NewAmount(reserveIn, from.Decimals)
+		edge.ReserveIn = &reserve
+		if total := totals[from.Address]; total != nil && total.Sign() > 0 {
+			edge.Share, _ = new(big.Float).Quo(new(big.Float).SetInt(reserveIn), new(big.Float).SetInt(total)).Float64()
+		}
+	}
+	if reserveOut != nil {
+		reserve := utils.NewAmount(reserveOut, to.Decimals)
+		edge.ReserveOut = &reserve
+	}
+	return edge
+}
+
+// sortEdges orders edges by share, most liquid first, then by pool address
+// so results don't depend on the registry's order
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Share != edges[j].Share {
+			return edges[i].Share > edges[j].Share
+		}
+		return edges[i].Pool.Hex() < edges[j].Pool.Hex()
+	})
+}
@@ -0,0 +1,154 @@
+package graph
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tokens of the test graph. A reaches D through B or, more liquid, through C.
+// F and G are only connected to each other.
+var (
+	tokenA = Token{Address: common.HexToAddress("0x0a"), Symbol: "A"}
+	tokenB = Token{Address: common.HexToAddress("0x0b"), Symbol: "B"}
+	tokenC = Token{Address: common.HexToAddress("0x0c"), Symbol: "C"}
+	tokenD = Token{Address: common.HexToAddress("0x0d"), Symbol: "D"}
+	tokenE = Token{Address: common.HexToAddress("0x0e"), Symbol: "E"}
+	tokenF = Token{Address: common.HexToAddress("0x0f"), Symbol: "F"}
+	tokenG = Token{Address: common.HexToAddress("0x10"), Symbol: "G"}
+)
+
+// testPool is a pool between two tokens and the share each side's reserve
+// holds of that token's liquidity
+type testPool struct {
+	pool           string
+	from, to       Token
+	share, reverse float64
+}
+
+// newTestGraph builds a graph from pools without reading reserves
+func newTestGraph(pools []testPool) *Graph {
+	g := New(nil, "")
+	for _, p := range pools {
+		pool := common.HexToAddress(p.pool)
+		g.tokens[p.from.Address], g.tokens[p.to.Address] = p.from, p.to
+		g.edges[p.from.Address] = append(g.edges[p.from.Address], Edge{Pool: pool, From: p.from, To: p.to, Share: p.share})
+		g.edges[p.to.Address] = append(g.edges[p.to.Address], Edge{Pool: pool, From: p.to, To: p.from, Share: p.reverse})
+	}
+	for _, edges := range g.edges {
+		sortEdges(edges)
+	}
+	g.pools = len(pools)
+	return g
+}
+
+var testPools = []testPool{
+	{"0x1ab", tokenA, tokenB, 0.5, 0.5},
+	{"0x1bd", tokenB, tokenD, 0.2, 0.2},
+	{"0x1ac", tokenA, tokenC, 0.9, 0.9},
+	{"0x1cd", tokenC, tokenD, 0.8, 0.8},
+	{"0x2cd", tokenC, tokenD, 0.1, 0.1}, // Thinner pool for the same pair
+	{"0x1de", tokenD, tokenE, 1, 1},
+	{"0x1fg", tokenF, tokenG, 1, 1},
+}
+
+func TestBestPath(t *testing.T) {
+	g := newTestGraph(testPools)
+	unknown := common.HexToAddress("0xff")
+
+	tests := []struct {
+		name      string
+		from, to  common.Address
+		maxHops   int
+		want      []string // Pools of the path
+		liquidity float64
+		wantErr   error
+	}{
+		{"direct", tokenA.Address, tokenB.Address, 3, []string{"0x1ab"}, 0.5, nil},
+		{"widest of equal length", tokenA.Address, tokenD.Address, 3, []string{"0x1ac", "0x1cd"}, 0.8, nil},
+		{"fewest hops", tokenB.Address, tokenD.Address, 3, []string{"0x1bd"}, 0.2, nil},
+		{"three hops", tokenA.Address, tokenE.Address, 3, []string{"0x1ac", "0x1cd", "0x1de"}, 0.8, nil},
+		{"reverse", tokenE.Address, tokenA.Address, 3, []string{"0x1de", "0x1cd", "0x1ac"}, 0.8, nil},
+		{"hop limit", tokenA.Address, tokenE.Address, 2, nil, 0, ErrNoPath},
+		{"disconnected", tokenA.Address, tokenF.Address, 5, nil, 0, ErrNoPath},
+		{"unknown from", unknown, tokenA.Address, 3, nil, 0, ErrUnknownToken},
+		{"unknown to", tokenA.Address, unknown, 3, nil, 0, ErrUnknownToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := g.BestPath(tt.from, tt.to, tt.maxHops)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("BestPath error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, len(path.Hops))
+			for i, hop := range path.Hops {
+				got[i] = hop.Pool.Hex()
+			}
+			want := make([]string, len(tt.want))
+			for i, pool := range tt.want {
+				want[i] = common.HexToAddress(pool).Hex()
+			}
+			if !slices.Equal(got, want) || path.Liquidity != tt.liquidity {
+				t.Errorf("BestPath = %v (%v), want %v (%v)", got, path.Liquidity, want, tt.liquidity)
+			}
+			if path.Hops[0].From.Address != tt.from || path.Hops[len(path.Hops)-1].To.Address != tt.to {
+				t.Errorf("path runs %s to %s", path.Hops[0].From.Symbol, path.Hops[len(path.Hops)-1].To.Symbol)
+			}
+		})
+	}
+
+	if _, err := g.BestPath(tokenA.Address, tokenA.Address, 3); err == nil {
+		t.Error("BestPath found a path from a token to itself")
+	}
+}
+
+func TestReachable(t *testing.T) {
+	g := newTestGraph(testPools)
+
+	tests := []struct {
+		name    string
+		token   Token
+		maxHops int
+		want    []Reach
+		wantErr error
+	}{
+		{"one hop", tokenA, 1, []Reach{{tokenB, 1}, {tokenC, 1}}, nil},
+		{"two hops", tokenA, 2, []Reach{{tokenB, 1}, {tokenC, 1}, {tokenD, 2}}, nil},
+		{"everything", tokenA, 10, []Reach{{tokenB, 1}, {tokenC, 1}, {tokenD, 2}, {tokenE, 3}}, nil},
+		{"from the middle", tokenD, 1, []Reach{{tokenB, 1}, {tokenC, 1}, {tokenE, 1}}, nil},
+		{"no hops", tokenA, 0, nil, nil},
+		{"separate component", tokenF, 3, []Reach{{tokenG, 1}}, nil},
+		{"unknown", Token{Address: common.HexToAddress("0xff")}, 3, nil, ErrUnknownToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, reached, err := g.Reachable(tt.token.Address, tt.maxHops)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Reachable error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if node != tt.token {
+				t.Errorf("node = %v, want %v", node, tt.token)
+			}
+			if !slices.Equal(reached, tt.want) {
+				t.Errorf("Reachable = %v, want %v", reached, tt.want)
+			}
+		})
+	}
+}
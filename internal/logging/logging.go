@@ -0,0 +1,196 @@
+// Package logging writes structured logs with log/slog. Each package logs
+// through its own logger, whose level can be set separately, and records
+// logged with a context carry the request ID and trace ID of the request.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tracing"
+)
+
+// Output formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Levels are the minimum levels by package, and the level of every other
+// package
+type Levels struct {
+	Default  slog.Level
+	Packages map[string]slog.Level
+}
+
+// level returns the minimum level of a package
+func (l Levels) level(pkg string) slog.Level {
+	if level, ok := l.Packages[pkg]; ok {
+		return level
+	}
+	return l.Default
+}
+
+// ParseLevels parses a default level followed by comma-separated package
+// levels, e.g. "info,aggregator=debug,storage=warn". Levels are debug, info,
+// warn or error.
+func ParseLevels(spec string) (Levels, error) {
+	levels := Levels{Default: slog.LevelInfo, Packages: make(map[string]slog.Level)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		pkg, name, scoped := strings.Cut(entry, "=")
+		if !scoped {
+			name = pkg
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			return Levels{}, fmt.Errorf("level %q is not debug, info, warn or error", entry)
+		}
+
+		if scoped {
+			levels.Packages[pkg] = level
+		} else {
+			levels.Default = level
+		}
+	}
+	return levels, nil
+}
+
+// config is the handler records are written with and the levels they're
+// filtered by
+type config struct {
+	handler slog.Handler
+	levels  Levels
+}
+
+// current is the configuration set by Init. Until then records are written
+// as JSON to stderr at info.
+var current atomic.Pointer[config]
+
+func init() {
+	current.Store(&config{
+		handler: slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		levels:  Levels{Default: slog.LevelInfo},
+	})
+}
+
+// Init writes logs to w in format, JSON or text, filtered by levels. The
+// standard log package and slog's default logger write through it too.
+func Init(w io.Writer, format string, levels Levels) error {
+	// Filtering is done by level, the handler writes every record it's given
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var handler slog.Handler
+	switch format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("log format %q is not %s or %s", format, FormatJSON, FormatText)
+	}
+
+	current.Store(&config{handler: handler, levels: levels})
+	slog.SetDefault(slog.New(&packageHandler{}))
+	log.SetFlags(0)
+	return nil
+}
+
+// For returns the logger of a package. Its records are tagged with the
+// package, and filtered by the package's level. Loggers can be created before
+// Init, they write with whatever configuration is current.
+func For(pkg string) *slog.Logger {
+	return slog.New(&packageHandler{pkg: pkg})
+}
+
+// requestIDKey holds the request ID in a context
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying a request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID in ctx, or "" if there isn't one
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random request ID
+func NewRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// packageHandler writes the records of a package through the current
+// configuration
+type packageHandler struct {
+	pkg string
+	// with adds the attributes and groups of the logger to the handler
+	with func(slog.Handler) slog.Handler
+}
+
+// Enabled reports whether the package logs at level
+func (h *packageHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= current.Load().levels.level(h.pkg)
+}
+
+// Handle adds the request and trace IDs in ctx to a record and writes it. The
+// IDs are added before the logger's groups, so they're always at the top level.
+func (h *packageHandler) Handle(ctx context.Context, r slog.Record) error {
+	var attrs []slog.Attr
+	if h.pkg != "" {
+		attrs = append(attrs, slog.String("package", h.pkg))
+	}
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+		if id := tracing.TraceID(ctx); id != "" {
+			attrs = append(attrs, slog.String("trace_id", id))
+		}
+	}
+
+	handler := current.Load().handler
+	if len(attrs) > 0 {
+		handler = handler.WithAttrs(attrs)
+	}
+	if h.with != nil {
+		handler = h.with(handler)
+	}
+	return handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler adding attrs to every record
+func (h *packageHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.extend(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+// WithGroup returns a handler nesting the attributes of every record in a group
+func (h *packageHandler) WithGroup(name string) slog.Handler {
+	return h.extend(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+// extend returns a copy of the handler that also applies next
+func (h *packageHandler) extend(next func(slog.Handler) slog.Handler) slog.Handler {
+	previous := h.with
+	return &packageHandler{pkg: h.pkg, with: func(handler slog.Handler) slog.Handler {
+		if previous != nil {
+			handler = previous(handler)
+		}
+		return next(handler)
+	}}
+}
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestParseLevels(t *testing.T) {
+	tests := []struct {
+		spec     string
+		want     slog.Level
+		packages map[string]slog.Level
+		wantErr  bool
+	}{
+		{"", slog.LevelInfo, map[string]slog.Level{}, false},
+		{"debug", slog.LevelDebug, map[string]slog.Level{}, false},
+		{"warn,aggregator=debug, storage=error", slog.LevelWarn, map[string]slog.Level{"aggregator": slog.LevelDebug, "storage": slog.LevelError}, false},
+		{"aggregator=DEBUG", slog.LevelInfo, map[string]slog.Level{"aggregator": slog.LevelDebug}, false},
+		{"verbose", 0, nil, true},
+		{"aggregator=loud", 0, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			levels, err := ParseLevels(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseLevels(%q) = %+v, want an error", tt.spec, levels)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLevels(%q): %v", tt.spec, err)
+			}
+			if levels.Default != tt.want || !maps.Equal(levels.Packages, tt.packages) {
+				t.Errorf("ParseLevels(%q) = %+v, want default %v and %v", tt.spec, levels, tt.want, tt.packages)
+			}
+		})
+	}
+}
+
+// capture writes logs as JSON to a buffer at levels until the test ends
+func capture(t *testing.T, levels Levels) *bytes.Buffer {
+	t.Helper()
+	previous, defaultLogger := current.Load(), slog.Default()
+	t.Cleanup(func() {
+		current.Store(previous)
+		slog.SetDefault(defaultLogger)
+	})
+
+	var buf bytes.Buffer
+	if err := Init(&buf, FormatJSON, levels); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+// records decodes the JSON records written to buf
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestForLevels(t *testing.T) {
+	// Loggers created before Init follow it
+	aggregator := For("aggregator")
+	buf := capture(t, Levels{Default: slog.LevelWarn, Packages: map[string]slog.Level{"aggregator": slog.LevelDebug, "storage": slog.LevelError}})
+	storage, stream := For("storage"), For("stream")
+
+	aggregator.Debug("aggregator debug")
+	storage.Warn("storage warn")
+	storage.Error("storage error")
+	stream.Info("stream info")
+	stream.Warn("stream warn")
+	slog.Info("default info")
+	slog.Warn("default warn")
+
+	var got []string
+	for _, record := range records(t, buf) {
+		got = append(got, record["msg"].(string))
+	}
+	want := []string{"aggregator debug", "storage error", "stream warn", "default warn"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+}
+
+func TestForAttrs(t *testing.T) {
+	buf := capture(t, Levels{Default: slog.LevelInfo})
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	For("aggregator").With("pair", "WETH/USDC").WithGroup("quote").InfoContext(ctx, "Quoted", "routes", 3)
+
+	logged := records(t, buf)
+	if len(logged) != 1 {
+		t.Fatalf("logged %d records, want 1", len(logged))
+	}
+	record := logged[0]
+	if record["package"] != "aggregator" || record["request_id"] != "req-1" || record["pair"] != "WETH/USDC" {
+		t.Errorf("record = %v, want package, request_id and pair", record)
+	}
+	if group, ok := record["quote"].(map[string]any); !ok || group["routes"] != float64(3) {
+		t.Errorf("record = %v, want routes in the quote group", record)
+	}
+}
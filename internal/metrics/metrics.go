@@ -0,0 +1,90 @@
+// Package metrics holds the Prometheus metrics of the aggregator. They are
+// registered with the default registry and served at /metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "defi_aggregator"
+
+// Token cache layers
+const (
+	CacheLocal    = "local"    // In-process LRU
+	CacheStore    = "store"    // Token metadata in the store
+	CacheNegative = "negative" // Addresses recently found not to be tokens
+)
+
+// Quote stages that can fail
+const (
+	StagePool  = "pool"  // Looking up the pool of a fee tier
+	StageQuote = "quote" // Quoting the pool
+)
+
+var (
+	// HTTPRequestDuration is the latency of HTTP requests by route and status
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// ProtocolQuoteDuration is how long quoting every fee tier of a protocol took
+	ProtocolQuoteDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "protocol_quote_duration_seconds",
+		Help:      "Latency of quoting every fee tier of a protocol.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"protocol"})
+
+	// ProtocolQuoteErrors counts failed pool lookups and quotes by protocol
+	ProtocolQuoteErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "protocol_quote_errors_total",
+		Help:      "Failed pool lookups and pool quotes by protocol.",
+	}, []string{"protocol", "stage"})
+
+	// BestRoutes counts the protocol of the best route of each quote
+	BestRoutes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "best_routes_total",
+		Help:      "Quotes whose best route was on each protocol.",
+	}, []string{"protocol"})
+
+	// RPCCalls counts JSON-RPC calls to the node by method and endpoint
+	RPCCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_calls_total",
+		Help:      "JSON-RPC calls by method and node endpoint, counting each call of a batch.",
+	}, []string{"method", "endpoint"})
+
+	// RPCFailures counts JSON-RPC calls that failed or returned an error
+	RPCFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_call_failures_total",
+		Help:      "JSON-RPC calls that failed or returned an error, by method and node endpoint.",
+	}, []string{"method", "endpoint"})
+
+	// RPCRequestDuration is the latency of HTTP requests to the node
+	RPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_request_duration_seconds",
+		Help:      "Latency of HTTP requests to the node, each holding a call or a batch of calls.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	// TokenCacheLookups counts token metadata cache hits and misses by layer
+	TokenCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_cache_lookups_total",
+		Help:      "Token metadata cache lookups by cache layer and result.",
+	}, []string{"cache", "result"})
+)
+
+// TokenCache counts hits and misses of a token cache layer
+func TokenCache(cache string, hits, misses int) {
+	TokenCacheLookups.WithLabelValues(cache, "hit").Add(float64(hits))
+	TokenCacheLookups.WithLabelValues(cache, "miss").Add(float64(misses))
+}
@@ -1,14 +1,73 @@
 package models
 
-// // TokenRequest defines the structure for the token post request
-// type TokenRequest struct {
-// 	Address string `json:"address" binding:"required"`
-// }
-
-// // TokenMetadata represents the ERC20 token metadata
-// type TokenMetadata struct {
-// 	Address  string `json:"address"`
-// 	Name     string `json:"name"`
-// 	Symbol   string `json:"symbol"`
-// 	Decimals uint8  `json:"decimals"`
-// }
\ No newline at end of file
+import "slices"
+
+// Token metadata fields that can be inferred
+const (
+	FieldName     = "name"
+	FieldSymbol   = "symbol"
+	FieldDecimals = "decimals"
+)
+
+// TokenRequest defines the structure for the token post request
+type TokenRequest struct {
+	Address string `json:"address" binding:"required"`
+}
+
+// TokenMetadata represents the ERC20 token metadata
+type TokenMetadata struct {
+	Address   string          `json:"address"`
+	Name      string          `json:"name"`
+	Symbol    string          `json:"symbol"`
+	Decimals  uint8           `json:"decimals"`
+	Behaviour *TokenBehaviour `json:"behaviour,omitempty"` // Fee-on-transfer and rebasing probe result
+	Risk      *RiskReport     `json:"risk,omitempty"`      // Honeypot and token-risk screening result
+	Inferred  []string        `json:"inferred,omitempty"`  // Fields the token didn't return that were filled in
+	LogoURI   string          `json:"logoURI,omitempty"`   // Logo from the token list
+	Tags      []string        `json:"tags,omitempty"`      // Tags from the token list
+	Warnings  []string        `json:"warnings,omitempty"`  // Differences between the token list and the chain
+	Curated   bool            `json:"curated,omitempty"`   // Imported from a token list
+}
+
+// DecimalsInferred reports whether the token didn't return its decimals, so
+// a default was assumed and amounts in whole tokens may be wrong
+func (t *TokenMetadata) DecimalsInferred() bool {
+	return slices.Contains(t.Inferred, FieldDecimals)
+}
+
+// TokenBehaviour describes how a token behaves on transfer
+type TokenBehaviour struct {
+	FeeOnTransfer  bool   `json:"feeOnTransfer"`  // Recipient receives less than the amount sent
+	TransferFeeBps uint64 `json:"transferFeeBps"` // Transfer fee in basis points
+	Rebasing       bool   `json:"rebasing"`       // Balances are share-based or change without transfers
+	ProbedAt       int64  `json:"probedAt"`       // Unix timestamp of the probe
+}
+
+// RiskReport is the result of screening a token
+type RiskReport struct {
+	Score          int      `json:"score"`           // 0 (no findings) to 100 (avoid)
+	Level          string   `json:"level"`           // low, medium or high
+	Reasons        []string `json:"reasons"`         // Findings that contributed to the score
+	BuyBlocked     bool     `json:"buyBlocked"`      // Transfers out of the pool revert
+	SellBlocked    bool     `json:"sellBlocked"`     // Transfers into the pool revert or credit nothing
+	BuyTaxBps      uint64   `json:"buyTaxBps"`       // Fee taken when buying from the pool
+	SellTaxBps     uint64   `json:"sellTaxBps"`      // Fee taken when selling into the pool
+	Blacklist      bool     `json:"blacklist"`       // Code exposes blacklist functions
+	Upgradeable    bool     `json:"upgradeable"`     // Token is behind an upgradeable proxy
+	Owner          string   `json:"owner,omitempty"` // Current owner, if the token is ownable
+	OwnerSupplyBps uint64   `json:"ownerSupplyBps"`  // Share of total supply held by the owner
+	ScreenedAt     int64    `json:"screenedAt"`      // Unix timestamp of the screening
+	Pool           string   `json:"pool"`            // Pool used for the buy and sell simulation
+}
+
+// APIKey is an issued API key. Only the SHA-256 hash of the key is stored, the
+// key itself is shown once when it's issued.
+type APIKey struct {
+	ID        string   `json:"id"`                  // First characters of the hash, used to look the key up
+	Hash      string   `json:"hash"`                // Hex SHA-256 of the key
+	Owner     string   `json:"owner"`               // Who the key was issued to
+	Scopes    []string `json:"scopes"`              // What the key may call
+	CreatedAt int64    `json:"createdAt"`           // Unix timestamp the key was issued
+	ExpiresAt int64    `json:"expiresAt,omitempty"` // Unix timestamp the key stops working, 0 never expires
+	RevokedAt int64    `json:"revokedAt,omitempty"` // Unix timestamp the key was revoked, 0 if it wasn't
+}
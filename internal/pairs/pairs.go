@@ -2,13 +2,16 @@ package pairs
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"sort"
+	"sync"
 
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/go-redis/redis/v8"
 )
 
+var logger = logging.For("pairs")
+
 // ERC20Token represents a single ERC20 token
 type ERC20Token struct {
 	Address  common.Address
@@ -27,21 +30,26 @@ type TokenPair struct {
 type ProtocolPair struct {
 	ProtocolName    string
 	ContractAddress string
+	Fee             uint64 // Fee tier of the pool, 0 if the protocol has none
 	Pair            TokenPair
 }
 
-// PairHandler manages operations related to token pairs
+// Store persists protocol pairs by pair key
+type Store interface {
+	AddProtocolPair(ctx context.Context, pairKey string, pair ProtocolPair) error
+	GetProtocolPairs(ctx context.Context, pairKey string) ([]ProtocolPair, error)
+	GetAllProtocolPairs(ctx context.Context) (map[string][]ProtocolPair, error)
+}
+
+// PairHandler is the registry of known pools. Pools are persisted in the store
+// and mirrored in ProtocolPairs, which is loaded from the store at startup.
 type PairHandler struct {
-	redisClient   *redis.Client
+	store         Store
+	mu            sync.RWMutex
 	Pairs         map[string]TokenPair
 	ProtocolPairs map[string][]ProtocolPair
 }
 
-type TokenHandler struct {
-	redisClient *redis.Client
-	nodeUrl	 string
-}
-
 func NewERC20Token(address common.Address, symbol string, decimals uint8) ERC20Token {
 	return ERC20Token{Address: address, Symbol: symbol, Decimals: decimals}
 }
@@ -55,96 +63,141 @@ func NewProtocolPair(protocolName, contractAddress string, pair TokenPair) Proto
 }
 
 // NewPairHandler creates a new PairHandler instance
-func NewPairHandler(redisUrl string) *PairHandler {
-	client := redis.NewClient(&redis.Options{
-		Addr:     redisUrl,
-		DB:       0,
-		Password: "Test1234!",
-	})
-
+func NewPairHandler(store Store) *PairHandler {
 	return &PairHandler{
-		redisClient:   client,
+		store:         store,
 		Pairs:         make(map[string]TokenPair),
 		ProtocolPairs: make(map[string][]ProtocolPair),
 	}
 }
 
-func NewTokenHandler(redisUrl string, nodeUrl string) *TokenHandler {
-	client := redis.NewClient(&redis.Options{
-		Addr:     redisUrl,
-		DB:       0,
-		Password : "Test1234!",
-	})
-
-	return &TokenHandler{
-		redisClient: client,
-		nodeUrl: nodeUrl,
+// Load replaces the in-memory pools with every pool in the store
+func (ph *PairHandler) Load(ctx context.Context) error {
+	protocolPairs, err := ph.store.GetAllProtocolPairs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load pools: %v", err)
 	}
-}
 
-func (th *TokenHandler) GetTokenData(ctx context.Context, address string) (string, error) {
-	// return client.Get(ctx, tokenAddress).Result()
-	return th.redisClient.Get(ctx, address).Result()
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+	ph.ProtocolPairs = protocolPairs
+	return nil
 }
 
-// AddProtocolPair adds a new protocol pair to the handler
-func (ph *PairHandler) AddProtocolPair(ctx context.Context, protocolName, contractAddress string, pair TokenPair) {
+// AddProtocolPair adds a pool to the registry. Pools that are already known
+// aren't written to the store again.
+func (ph *PairHandler) AddProtocolPair(ctx context.Context, protocolName, contractAddress string, fee uint64, pair TokenPair) error {
 	protocolPair := ProtocolPair{
 		ProtocolName:    protocolName,
 		ContractAddress: contractAddress,
+		Fee:             fee,
 		Pair:            pair,
 	}
 
 	pairKey := getPairKey(pair.Token0.Address.String(), pair.Token1.Address.String())
-	data, err := json.Marshal(protocolPair)
 
-	if err != nil {
-		fmt.Printf("failed to marshal protocol pair: %v\n", err)
-		return
+	ph.mu.RLock()
+	known := containsPool(ph.ProtocolPairs[pairKey], protocolPair)
+	ph.mu.RUnlock()
+	if known {
+		return nil
 	}
 
-	err = ph.redisClient.SAdd(ctx, pairKey, data).Err()
+	err := ph.store.AddProtocolPair(ctx, pairKey, protocolPair)
 	if err != nil {
-		fmt.Printf("failed to add protocol pair to Redis: %v", err)
-		return
+		return fmt.Errorf("failed to add protocol pair to store: %v", err)
+	}
+
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+	if !containsPool(ph.ProtocolPairs[pairKey], protocolPair) {
+		ph.ProtocolPairs[pairKey] = append(ph.ProtocolPairs[pairKey], protocolPair)
 	}
-	ph.ProtocolPairs[pairKey] = append(ph.ProtocolPairs[pairKey], protocolPair)
+	return nil
 }
 
-// GetProtocolPairs retrieves all protocol pairs for a given token pair
+// GetProtocolPairs retrieves all protocol pairs for a given token pair from memory
 func (ph *PairHandler) GetProtocolPairs(token0Address, token1Address string) []ProtocolPair {
 	pairKey := getPairKey(token0Address, token1Address)
-	return ph.ProtocolPairs[pairKey]
+
+	ph.mu.RLock()
+	defer ph.mu.RUnlock()
+	return append([]ProtocolPair(nil), ph.ProtocolPairs[pairKey]...)
+}
+
+// AllProtocolPairs returns every known pool from memory
+func (ph *PairHandler) AllProtocolPairs() []ProtocolPair {
+	ph.mu.RLock()
+	defer ph.mu.RUnlock()
+
+	var all []ProtocolPair
+	for _, protocolPairs := range ph.ProtocolPairs {
+		all = append(all, protocolPairs...)
+	}
+	return all
 }
 
 // AddPair adds a new token pair to the handler
 func (ph *PairHandler) AddPair(token0, token1 ERC20Token) {
 	pairKey := getPairKey(token0.Address.String(), token1.Address.String())
+
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
 	ph.Pairs[pairKey] = TokenPair{Token0: token0, Token1: token1}
 }
 
 // GetPair retrieves a token pair from the handler
 func (ph *PairHandler) GetPair(token0Address, token1Address string) (TokenPair, bool) {
 	pairKey := getPairKey(token0Address, token1Address)
+
+	ph.mu.RLock()
+	defer ph.mu.RUnlock()
 	pair, exists := ph.Pairs[pairKey]
 	return pair, exists
 }
 
-// FindProtocolsForPair finds all protocols that include the specified token pair
-func (ph *PairHandler) FindProtocolsForPair(token0Address, token1Address string) []ProtocolPair {
+// FindProtocolsForPair finds all pools for the specified token pair in the
+// store, in either token order, sorted by protocol then fee. The in-memory
+// pools are used if the store can't be read.
+func (ph *PairHandler) FindProtocolsForPair(ctx context.Context, token0Address, token1Address string) []ProtocolPair {
 	pairKey := getPairKey(token0Address, token1Address)
-	protocols := ph.ProtocolPairs[pairKey]
 
-	// Also check for the reverse pair
-	reversePairKey := getPairKey(token1Address, token0Address)
-	reverseProtocols := ph.ProtocolPairs[reversePairKey]
+	protocolPairs, err := ph.store.GetProtocolPairs(ctx, pairKey)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to read protocol pairs from store, using pools in memory", "pair", pairKey, "error", err)
+		protocolPairs = ph.GetProtocolPairs(token0Address, token1Address)
+	} else {
+		// Pick up pools added by other instances
+		ph.mu.Lock()
+		ph.ProtocolPairs[pairKey] = protocolPairs
+		ph.mu.Unlock()
+		protocolPairs = append([]ProtocolPair(nil), protocolPairs...)
+	}
+
+	sort.Slice(protocolPairs, func(i, j int) bool {
+		if protocolPairs[i].ProtocolName != protocolPairs[j].ProtocolName {
+			return protocolPairs[i].ProtocolName < protocolPairs[j].ProtocolName
+		}
+		return protocolPairs[i].Fee < protocolPairs[j].Fee
+	})
+	return protocolPairs
+}
 
-	// Combine and return all found protocols
-	return append(protocols, reverseProtocols...)
+// containsPool reports whether a pool is in the list
+func containsPool(protocolPairs []ProtocolPair, pool ProtocolPair) bool {
+	for _, existing := range protocolPairs {
+		if existing.ProtocolName == pool.ProtocolName && existing.ContractAddress == pool.ContractAddress {
+			return true
+		}
+	}
+	return false
 }
 
-// Helper function to generate a unique key for a token pair
+// Helper function to generate a unique key for a token pair. Addresses are
+// checksummed so the key doesn't depend on their case.
 func getPairKey(address0, address1 string) string {
+	address0 = common.HexToAddress(address0).Hex()
+	address1 = common.HexToAddress(address1).Hex()
 	if address0 < address1 {
 		return address0 + "-" + address1
 	}
@@ -0,0 +1,166 @@
+package pairs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	tokenA = NewERC20Token(common.HexToAddress("0x00000000000000000000000000000000000000aa"), "AAA", 18)
+	tokenB = NewERC20Token(common.HexToAddress("0x00000000000000000000000000000000000000bb"), "BBB", 6)
+)
+
+// fakeStore is a Store that counts writes and can be made to fail
+type fakeStore struct {
+	pairs  map[string][]ProtocolPair
+	writes int
+	fail   bool
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{pairs: make(map[string][]ProtocolPair)}
+}
+
+func (s *fakeStore) AddProtocolPair(ctx context.Context, pairKey string, pair ProtocolPair) error {
+	if s.fail {
+		return errors.New("store is down")
+	}
+	s.writes++
+	s.pairs[pairKey] = append(s.pairs[pairKey], pair)
+	return nil
+}
+
+func (s *fakeStore) GetProtocolPairs(ctx context.Context, pairKey string) ([]ProtocolPair, error) {
+	if s.fail {
+		return nil, errors.New("store is down")
+	}
+	return s.pairs[pairKey], nil
+}
+
+func (s *fakeStore) GetAllProtocolPairs(ctx context.Context) (map[string][]ProtocolPair, error) {
+	if s.fail {
+		return nil, errors.New("store is down")
+	}
+	return s.pairs, nil
+}
+
+func TestLoad(t *testing.T) {
+	store := newFakeStore()
+	pairKey := getPairKey(tokenA.Address.Hex(), tokenB.Address.Hex())
+	store.pairs[pairKey] = []ProtocolPair{{ProtocolName: "Uniswap V3", ContractAddress: "0x01", Fee: 500}}
+
+	ph := NewPairHandler(store)
+	if err := ph.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if pools := ph.GetProtocolPairs(tokenB.Address.Hex(), tokenA.Address.Hex()); len(pools) != 1 || pools[0].Fee != 500 {
+		t.Errorf("GetProtocolPairs = %+v, want the stored pool", pools)
+	}
+
+	store.fail = true
+	if err := ph.Load(context.Background()); err == nil {
+		t.Error("Load succeeded with the store down")
+	}
+	if pools := ph.AllProtocolPairs(); len(pools) != 1 {
+		t.Errorf("AllProtocolPairs = %+v, want the pools loaded before", pools)
+	}
+}
+
+func TestAddProtocolPair(t *testing.T) {
+	ctx := context.Background()
+	pair := NewTokenPair(tokenA, tokenB)
+	reversed := NewTokenPair(tokenB, tokenA)
+
+	tests := []struct {
+		name   string
+		add    func(ph *PairHandler) error
+		writes int
+		pools  int
+	}{
+		{"new pool", func(ph *PairHandler) error {
+			return ph.AddProtocolPair(ctx, "Uniswap V3", "0x01", 500, pair)
+		}, 1, 1},
+		{"same pool twice", func(ph *PairHandler) error {
+			ph.AddProtocolPair(ctx, "Uniswap V3", "0x01", 500, pair)
+			return ph.AddProtocolPair(ctx, "Uniswap V3", "0x01", 500, pair)
+		}, 1, 1},
+		{"same pool reversed", func(ph *PairHandler) error {
+			ph.AddProtocolPair(ctx, "Uniswap V3", "0x01", 500, pair)
+			return ph.AddProtocolPair(ctx, "Uniswap V3", "0x01", 500, reversed)
+		}, 1, 1},
+		{"another fee tier", func(ph *PairHandler) error {
+			ph.AddProtocolPair(ctx, "Uniswap V3", "0x01", 500, pair)
+			return ph.AddProtocolPair(ctx, "Uniswap V3", "0x02", 3000, pair)
+		}, 2, 2},
+		{"another protocol", func(ph *PairHandler) error {
+			ph.AddProtocolPair(ctx, "Uniswap V3", "0x01", 500, pair)
+			return ph.AddProtocolPair(ctx, "Sushiswap V3", "0x01", 500, pair)
+		}, 2, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newFakeStore()
+			ph := NewPairHandler(store)
+			if err := test.add(ph); err != nil {
+				t.Fatal(err)
+			}
+			if store.writes != test.writes {
+				t.Errorf("store writes = %d, want %d", store.writes, test.writes)
+			}
+			if pools := ph.GetProtocolPairs(tokenA.Address.Hex(), tokenB.Address.Hex()); len(pools) != test.pools {
+				t.Errorf("pools = %+v, want %d", pools, test.pools)
+			}
+		})
+	}
+
+	t.Run("store down", func(t *testing.T) {
+		store := newFakeStore()
+		store.fail = true
+		ph := NewPairHandler(store)
+		if err := ph.AddProtocolPair(ctx, "Uniswap V3", "0x01", 500, pair); err == nil {
+			t.Fatal("AddProtocolPair succeeded with the store down")
+		}
+		if pools := ph.AllProtocolPairs(); len(pools) != 0 {
+			t.Errorf("pools = %+v, want none kept in memory", pools)
+		}
+	})
+}
+
+func TestFindProtocolsForPair(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	ph := NewPairHandler(store)
+	pair := NewTokenPair(tokenA, tokenB)
+	ph.AddProtocolPair(ctx, "Uniswap V3", "0x02", 3000, pair)
+	ph.AddProtocolPair(ctx, "Sushiswap V3", "0x03", 500, pair)
+	ph.AddProtocolPair(ctx, "Uniswap V3", "0x01", 500, pair)
+
+	// Another instance adds a pool to the store
+	pairKey := getPairKey(tokenA.Address.Hex(), tokenB.Address.Hex())
+	store.pairs[pairKey] = append(store.pairs[pairKey], ProtocolPair{ProtocolName: "PancakeSwap V3", ContractAddress: "0x04", Fee: 2500, Pair: pair})
+
+	want := []string{"0x04", "0x03", "0x01", "0x02"}
+	check := func(t *testing.T, pools []ProtocolPair) {
+		t.Helper()
+		if len(pools) != len(want) {
+			t.Fatalf("pools = %+v, want %v", pools, want)
+		}
+		for i, pool := range pools {
+			if pool.ContractAddress != want[i] {
+				t.Errorf("pool %d = %s, want %s", i, pool.ContractAddress, want[i])
+			}
+		}
+	}
+
+	// Lowercase and reversed addresses find the same pair
+	check(t, ph.FindProtocolsForPair(ctx, tokenB.Address.Hex(), tokenA.Address.Hex()))
+	check(t, ph.FindProtocolsForPair(ctx, "0x00000000000000000000000000000000000000aa", "0x00000000000000000000000000000000000000bb"))
+
+	// The pools in memory, now including the other instance's, are used if
+	// the store can't be read
+	store.fail = true
+	check(t, ph.FindProtocolsForPair(ctx, tokenA.Address.Hex(), tokenB.Address.Hex()))
+}
@@ -1,6 +1,9 @@
 package protocols
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -8,10 +11,15 @@ import (
 type ProtocolConfig struct {
 	Name           string         `json:"name"`
 	FactoryAddress common.Address `json:"factoryAddress"`
-	RouterAddress  common.Address `json:"routerAddress"`
+	RouterAddress  common.Address `json:"routerAddress"` // Quoter contract quotes are read from
+	// SwapRouter02-compatible router swaps are sent to, set with SetSwapRouters.
+	// Swaps can't be built for protocols without one.
+	SwapRouterAddress common.Address `json:"swapRouterAddress"`
 	// Some protocols might need additional parameters
 	FeeTiers      []uint64 `json:"feeTiers"`      // Available fee tiers (e.g., 500, 3000, 10000 for Uniswap V3)
 	IsUniswapFork bool     `json:"isUniswapFork"` // Is this a Uniswap-compatible fork
+	SwapGas       uint64   `json:"swapGas"`       // Estimated gas of a single swap through the router
+	Priority      int      `json:"priority"`      // Preference when routes tie, lower is preferred
 }
 
 // Protocols is a map of protocol configurations
@@ -22,6 +30,8 @@ var Protocols = map[string]ProtocolConfig{
 		RouterAddress:  common.HexToAddress("0x4c4eabd5fb1d1a7234a48692551eaecff8194ca7"),
 		FeeTiers:       []uint64{500, 3000, 10000},
 		IsUniswapFork:  true,
+		SwapGas:        130000,
+		Priority:       1,
 	},
 	"uniswapv2": {
 		Name:           "Uniswap V2",
@@ -29,6 +39,8 @@ var Protocols = map[string]ProtocolConfig{
 		RouterAddress:  common.HexToAddress("0x3ae6d8a282d67893e17aa70ebffb33ee5aa65893"), //
 		FeeTiers:       []uint64{30}, // Uniswap V2 has a fixed 0.3% fee (represented as 30 basis points here)
 		IsUniswapFork:  false,
+		SwapGas:        110000,
+		Priority:       6,
 		// IsUniswapV2:    true,
 	},
 	"sushiswapv3": {
@@ -37,6 +49,8 @@ var Protocols = map[string]ProtocolConfig{
 		RouterAddress:  common.HexToAddress("0x8A21F6768C1f8075791D08546Bd61770d3F8a48F"),
 		FeeTiers:       []uint64{100, 500, 3000, 10000},
 		IsUniswapFork:  true,
+		SwapGas:        130000,
+		Priority:       2,
 	},
 	"pancakeswapv3": {
 		Name:           "PancakeSwap V3",
@@ -44,6 +58,8 @@ var Protocols = map[string]ProtocolConfig{
 		RouterAddress:  common.HexToAddress("0x13f4EA83D0bd40E75C8222255bc855a974568Dd4"),
 		FeeTiers:       []uint64{100, 500, 2500, 10000},
 		IsUniswapFork:  true,
+		SwapGas:        130000,
+		Priority:       3,
 	},
 	"tayaswap": {
 		Name:           "Tayaswap V3",
@@ -51,6 +67,8 @@ var Protocols = map[string]ProtocolConfig{
 		RouterAddress:  common.HexToAddress("0x4ba4be2fb69e2aa059a551ce5d609ef5818dd72f"),
 		FeeTiers:       []uint64{100, 500, 2500, 10000},
 		IsUniswapFork:  true,
+		SwapGas:        130000,
+		Priority:       4,
 	},
 	"reactor": {
 		Name:           "Reactor V3",
@@ -58,6 +76,8 @@ var Protocols = map[string]ProtocolConfig{
 		RouterAddress:  common.HexToAddress("0x4ba4be2fb69e2aa059a551ce5d609ef5818dd72f"),
 		FeeTiers:       []uint64{100, 500, 2500, 10000},
 		IsUniswapFork:  true,
+		SwapGas:        130000,
+		Priority:       5,
 	},
 	"naddotfun": {
 		Name:           "Naddotfun", // uni v2 fork
@@ -65,11 +85,46 @@ var Protocols = map[string]ProtocolConfig{
 		RouterAddress:  common.HexToAddress("0x3ae6d8a282d67893e17aa70ebffb33ee5aa65893"), //
 		FeeTiers:       []uint64{30}, // Uniswap V2 has a fixed 0.3% fee (represented as 30 basis points here)
 		IsUniswapFork:  false,
+		SwapGas:        110000,
+		Priority:       7,
 		// IsUniswapV2:    true,
 	},
 	// Add more protocols as needed
 }
 
+// SetSwapRouters sets the swap router of protocols from comma-separated
+// protocol=address entries, e.g. "uniswapv3=0x...,sushiswapv3=0x...". It must
+// be called before the protocols are used.
+func SetSwapRouters(spec string) error {
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, address, ok := strings.Cut(entry, "=")
+		protocol, exists := Protocols[name]
+		if !ok || !exists {
+			return fmt.Errorf("swap router %q is not protocol=address for a known protocol", entry)
+		}
+		if !common.IsHexAddress(address) {
+			return fmt.Errorf("swap router %q has an invalid address", entry)
+		}
+		protocol.SwapRouterAddress = common.HexToAddress(address)
+		Protocols[name] = protocol
+	}
+	return nil
+}
+
+// SwapRouter returns the swap router of a protocol as a string, or "" if it
+// has none
+func (p ProtocolConfig) SwapRouter() string {
+	if p.SwapRouterAddress == (common.Address{}) {
+		return ""
+	}
+	return p.SwapRouterAddress.String()
+}
+
 // GetSupportedProtocols returns a list of all supported protocol names
 func GetSupportedProtocols() []string {
 	protocols := make([]string, 0, len(Protocols))
@@ -85,6 +140,16 @@ func GetProtocolByName(name string) (ProtocolConfig, bool) {
 	return protocol, exists
 }
 
+// GetProtocolByDisplayName returns a protocol configuration by its display name, e.g. "Uniswap V3"
+func GetProtocolByDisplayName(name string) (ProtocolConfig, bool) {
+	for _, protocol := range Protocols {
+		if protocol.Name == name {
+			return protocol, true
+		}
+	}
+	return ProtocolConfig{}, false
+}
+
 // GetUniswapForks returns all Uniswap-compatible forks
 func GetUniswapForks() []ProtocolConfig {
 	forks := make([]ProtocolConfig, 0)
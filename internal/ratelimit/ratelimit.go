@@ -0,0 +1,209 @@
+// Package ratelimit limits requests per client IP and per API key with token
+// buckets kept in the store, and meters each key's daily usage.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TargetIP is the limit target applied to every request by client IP
+const TargetIP = "ip"
+
+// dayFormat is the format of the days usage is counted by, in UTC
+const dayFormat = "2006-01-02"
+
+// LimitedField is the usage field counting requests that were rate limited
+const LimitedField = "rate_limited"
+
+// Limit is a token bucket refilled at Rate tokens a second up to Burst
+// tokens. The zero Limit doesn't limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited reports whether the limit lets every request through
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Limits are the limits by target: TargetIP, a scope, or an endpoint path
+// such as /quotes/batch. An endpoint's limit replaces its scope's.
+type Limits map[string]Limit
+
+// Targets returns the configured targets in order, for logging
+func (l Limits) Targets() []string {
+	targets := make([]string, 0, len(l))
+	for target, limit := range l {
+		if !limit.Unlimited() {
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// units are the periods a rate can be given per
+var units = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// ParseLimits parses comma-separated limits of the form
+// target=requests/unit[:burst], e.g. "ip=20/s:40,quote=600/m,/depth=1/s". The
+// unit is s, m or h and the burst defaults to the requests per unit. A limit
+// of 0 requests removes the target's limit.
+func ParseLimits(spec string) (Limits, error) {
+	limits := make(Limits)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		target, value, ok := strings.Cut(entry, "=")
+		if !ok || target == "" {
+			return nil, fmt.Errorf("limit %q is not target=requests/unit[:burst]", entry)
+		}
+		value, burstText, hasBurst := strings.Cut(value, ":")
+		countText, unit, ok := strings.Cut(value, "/")
+		period, known := units[unit]
+		if !ok || !known {
+			return nil, fmt.Errorf("limit %q needs a rate per s, m or h", entry)
+		}
+		count, err := strconv.ParseUint(countText, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("limit %q has an invalid request count: %v", entry, err)
+		}
+
+		burst := int(count)
+		if hasBurst {
+			parsed, err := strconv.ParseUint(burstText, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("limit %q has an invalid burst: %v", entry, err)
+			}
+			burst = int(parsed)
+		}
+		limits[target] = Limit{Rate: float64(count) / period.Seconds(), Burst: burst}
+	}
+	return limits, nil
+}
+
+// Store keeps token buckets and usage counters, see storage.LimitRepository
+type Store interface {
+	TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, float64, error)
+	IncrementUsage(ctx context.Context, keyID, day, field string, ttl time.Duration) error
+	GetUsage(ctx context.Context, keyID, day string) (map[string]int64, error)
+}
+
+// Result is the state of a limit after a request
+type Result struct {
+	Allowed    bool
+	Limit      int           // Burst of the limit, 0 if the request wasn't limited
+	Remaining  int           // Requests that can be made right away
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the next request is allowed, if this one wasn't
+}
+
+// Limiter applies limits and counts usage. Usage counters are kept for
+// retention, at least a day.
+type Limiter struct {
+	store     Store
+	limits    Limits
+	retention time.Duration
+}
+
+// New creates a limiter
+func New(store Store, limits Limits, retention time.Duration) *Limiter {
+	return &Limiter{store: store, limits: limits, retention: max(retention, 24*time.Hour)}
+}
+
+// RetentionDays is the number of days usage is kept for
+func (l *Limiter) RetentionDays() int {
+	return int(l.retention / (24 * time.Hour))
+}
+
+// AllowIP takes a request of a client IP from the TargetIP limit
+func (l *Limiter) AllowIP(ctx context.Context, ip string) (Result, error) {
+	return l.take(ctx, "ip:"+ip, l.limits[TargetIP])
+}
+
+// AllowKey takes a request of an API key to an endpoint from the endpoint's
+// limit, or from the limit of the scope the endpoint needs. Every endpoint of a
+// scope shares the scope's bucket.
+func (l *Limiter) AllowKey(ctx context.Context, keyID, scope, endpoint string) (Result, error) {
+	if limit, ok := l.limits[endpoint]; ok {
+		return l.take(ctx, "key:"+keyID+":"+endpoint, limit)
+	}
+	return l.take(ctx, "key:"+keyID+":"+scope, l.limits[scope])
+}
+
+// take takes a token from a bucket. If the store fails the request is
+// allowed, so an outage of the store doesn't take the API down with it.
+func (l *Limiter) take(ctx context.Context, bucket string, limit Limit) (Result, error) {
+	if limit.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+
+	taken, tokens, err := l.store.TakeToken(ctx, bucket, limit.Rate, limit.Burst, time.Now())
+	if err != nil {
+		return Result{Allowed: true}, err
+	}
+
+	result := Result{
+		Allowed:   taken,
+		Limit:     limit.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !taken {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return result, nil
+}
+
+// seconds converts seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Record counts a request of an API key under field, typically the endpoint
+func (l *Limiter) Record(ctx context.Context, keyID, field string) error {
+	return l.store.IncrementUsage(ctx, keyID, time.Now().UTC().Format(dayFormat), field, l.retention)
+}
+
+// DayUsage is an API key's requests on a day
+type DayUsage struct {
+	Date      string           `json:"date"`
+	Total     int64            `json:"total"` // Requests served, not counting rate limited ones
+	Endpoints map[string]int64 `json:"endpoints"`
+}
+
+// Usage returns an API key's usage for the last days days, most recent first
+func (l *Limiter) Usage(ctx context.Context, keyID string, days int) ([]DayUsage, error) {
+	usage := make([]DayUsage, 0, days)
+	today := time.Now().UTC()
+	for i := 0; i < days; i++ {
+		date := today.AddDate(0, 0, -i).Format(dayFormat)
+		counters, err := l.store.GetUsage(ctx, keyID, date)
+		if err != nil {
+			return nil, err
+		}
+
+		day := DayUsage{Date: date, Endpoints: counters}
+		for field, count := range counters {
+			if field != LimitedField {
+				day.Total += count
+			}
+		}
+		usage = append(usage, day)
+	}
+	return usage, nil
+}
@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestParseLimits(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Limits
+		wantErr bool
+	}{
+		{"", Limits{}, false},
+		{"ip=20/s:40", Limits{"ip": {Rate: 20, Burst: 40}}, false},
+		{"quote=600/m", Limits{"quote": {Rate: 10, Burst: 600}}, false},
+		{"/depth=36/h", Limits{"/depth": {Rate: 0.01, Burst: 36}}, false},
+		{" ip=1/s , ,admin=0/s", Limits{"ip": {Rate: 1, Burst: 1}, "admin": {Rate: 0, Burst: 0}}, false},
+		{"ip=1/s,ip=2/s", Limits{"ip": {Rate: 2, Burst: 2}}, false}, // Last one wins
+		{"ip", nil, true},
+		{"=1/s", nil, true},
+		{"ip=1", nil, true},
+		{"ip=1/d", nil, true},
+		{"ip=-1/s", nil, true},
+		{"ip=a/s", nil, true},
+		{"ip=1/s:", nil, true},
+		{"ip=1/s:x", nil, true},
+		{"ip=99999999999/s", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			limits, err := ParseLimits(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseLimits(%q) = %v, want an error", tt.spec, limits)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLimits(%q): %v", tt.spec, err)
+			}
+			if !maps.Equal(limits, tt.want) {
+				t.Errorf("ParseLimits(%q) = %v, want %v", tt.spec, limits, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargets(t *testing.T) {
+	limits, err := ParseLimits("quote=1/s,ip=1/s,admin=0/s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := limits.Targets(); len(got) != 2 || got[0] != "ip" || got[1] != "quote" {
+		t.Errorf("Targets = %v, want [ip quote]", got)
+	}
+}
+
+// bucketStore is a Store whose buckets hold burst tokens and never refill,
+// failing with err if set
+type bucketStore struct {
+	taken map[string]int
+	err   error
+}
+
+func (s *bucketStore) TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, float64, error) {
+	if s.err != nil {
+		return false, 0, s.err
+	}
+	if s.taken[key] >= burst {
+		return false, 0, nil
+	}
+	s.taken[key]++
+	return true, float64(burst - s.taken[key]), nil
+}
+
+func (s *bucketStore) IncrementUsage(ctx context.Context, keyID, day, field string, ttl time.Duration) error {
+	return nil
+}
+
+func (s *bucketStore) GetUsage(ctx context.Context, keyID, day string) (map[string]int64, error) {
+	return nil, nil
+}
+
+func TestAllowKey(t *testing.T) {
+	limits, err := ParseLimits("quote=2/s,/depth=1/s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &bucketStore{taken: make(map[string]int)}
+	limiter := New(store, limits, 0)
+	ctx := context.Background()
+
+	// Endpoints of a scope share its bucket, an endpoint with its own limit doesn't
+	steps := []struct {
+		key, scope, endpoint string
+		allowed              bool
+	}{
+		{"a", "quote", "/pairs", true},
+		{"a", "quote", "/swap", true},
+		{"a", "quote", "/pairs", false},
+		{"a", "quote", "/depth", true},
+		{"a", "quote", "/depth", false},
+		{"b", "quote", "/pairs", true},
+		{"a", "admin", "/admin/keys/:id/usage", true}, // Unlimited
+	}
+	for i, step := range steps {
+		result, err := limiter.AllowKey(ctx, step.key, step.scope, step.endpoint)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if result.Allowed != step.allowed {
+			t.Errorf("step %d: %s %s allowed = %v, want %v", i, step.key, step.endpoint, result.Allowed, step.allowed)
+		}
+		if !result.Allowed && result.RetryAfter <= 0 {
+			t.Errorf("step %d: RetryAfter = %v, want a wait", i, result.RetryAfter)
+		}
+	}
+	if _, ok := store.taken["key:a:admin"]; ok {
+		t.Error("unlimited scope took from a bucket")
+	}
+
+	// The store failing lets requests through
+	store.err = errors.New("connection refused")
+	if result, err := limiter.AllowKey(ctx, "a", "quote", "/pairs"); err == nil || !result.Allowed {
+		t.Errorf("AllowKey with the store down = %+v, %v, want allowed with an error", result, err)
+	}
+}
+
+func TestRetentionDays(t *testing.T) {
+	if days := New(nil, nil, time.Hour).RetentionDays(); days != 1 {
+		t.Errorf("RetentionDays = %d, want at least 1", days)
+	}
+	if days := New(nil, nil, 90*24*time.Hour).RetentionDays(); days != 90 {
+		t.Errorf("RetentionDays = %d, want 90", days)
+	}
+}
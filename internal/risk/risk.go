@@ -0,0 +1,211 @@
+package risk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+	"github.com/lmittmann/w3/w3types"
+)
+
+var logger = logging.For("risk")
+
+// Risk levels derived from the score
+const (
+	LevelLow    = "low"
+	LevelMedium = "medium"
+	LevelHigh   = "high"
+)
+
+var (
+	funcOwner       = w3.MustNewFunc("owner()", "address")
+	funcTotalSupply = w3.MustNewFunc("totalSupply()", "uint256")
+	funcBalanceOf   = w3.MustNewFunc("balanceOf(address)", "uint256")
+
+	// EIP-1967 storage slots for the implementation and beacon of a proxy
+	implementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	beaconSlot         = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+
+	// blacklistSelectors are owner functions commonly used to block holders from selling
+	blacklistSelectors = tokens.Selectors(
+		"blacklist(address)",
+		"addToBlacklist(address)",
+		"setBlacklist(address,bool)",
+		"setBlacklisted(address,bool)",
+		"isBlacklisted(address)",
+		"addBot(address)",
+		"setBots(address[],bool)",
+		"blockBots(address[])",
+		"isBot(address)",
+	)
+
+	// minimalProxyPrefix is the start of EIP-1167 clone code, which is not upgradeable
+	minimalProxyPrefix = common.FromHex("0x363d3d373d3d3d363d73")
+)
+
+// Report is the result of screening a token
+type Report = models.RiskReport
+
+// Screen simulates a buy and a sell of token through pool and inspects the
+// token for owner-controlled blacklists, proxy upgradability and supply
+// concentration. It also returns the transfer behaviour seen during the buy,
+// or nil if the buy reverted.
+//
+// The buy and sell are simulated as transfers out of and back into the pool
+// rather than as swaps through a router. Swap routers are optional (a
+// protocol may be quoted without one) and a router swap would need a funded,
+// approved holder of the wrapped native token, while the token itself only
+// sees the transfers: pool to buyer, and seller to pool. What this misses is
+// a token that restricts who may initiate the transfer, e.g. one that only
+// allows sells when the router is the caller.
+func Screen(ctx context.Context, token, pool common.Address, nodeURL string) (*Report, *tokens.Behaviour, error) {
+	sim, err := tokens.Simulate(ctx, token, pool, nodeURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := node.Dial(nodeURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to node: %v", err)
+	}
+	defer client.Close()
+
+	// Ownership and proxy slots, each of which may legitimately be missing
+	var (
+		owner          common.Address
+		totalSupply    big.Int
+		implementation common.Hash
+		beacon         common.Hash
+	)
+	err = client.CallCtx(ctx,
+		eth.CallFunc(token, funcOwner).Returns(&owner),
+		eth.CallFunc(token, funcTotalSupply).Returns(&totalSupply),
+		eth.StorageAt(token, implementationSlot, nil).Returns(&implementation),
+		eth.StorageAt(token, beaconSlot, nil).Returns(&beacon),
+	)
+	if _, ok := err.(w3.CallErrors); err != nil && !ok {
+		return nil, nil, fmt.Errorf("failed to screen token: %v", err)
+	}
+
+	report := &Report{
+		ScreenedAt: time.Now().Unix(),
+		Pool:       pool.Hex(),
+	}
+	var behaviour *tokens.Behaviour
+
+	// Buy and sell simulation
+	if !sim.BuyOK {
+		report.BuyBlocked = true
+		addFinding(report, 100, "transfers out of the pool revert")
+	} else {
+		behaviour = sim.Behaviour()
+		report.BuyTaxBps = behaviour.TransferFeeBps
+
+		if !sim.SellOK || sim.SellReceived.Sign() == 0 {
+			report.SellBlocked = true
+			addFinding(report, 90, "selling back into the pool is blocked")
+		} else {
+			report.SellTaxBps, _ = tokens.TransferFeeBps(sim.SellAmount, sim.SellReceived)
+		}
+
+		addTax(report, "buy", report.BuyTaxBps)
+		addTax(report, "sell", report.SellTaxBps)
+	}
+
+	// Code of the token, or of its implementation when it is a proxy
+	code := sim.Code
+	implementationAddress := common.BytesToAddress(implementation.Bytes())
+	if implementationAddress != (common.Address{}) || beacon != (common.Hash{}) {
+		report.Upgradeable = true
+		addFinding(report, 20, "token is an upgradeable proxy")
+	}
+
+	// Owner balance and implementation code
+	var ownerBalance big.Int
+	var implementationCode []byte
+	calls := make([]w3types.RPCCaller, 0, 2)
+	if owner != (common.Address{}) {
+		report.Owner = owner.Hex()
+		calls = append(calls, eth.CallFunc(token, funcBalanceOf, owner).Returns(&ownerBalance))
+	}
+	if implementationAddress != (common.Address{}) {
+		calls = append(calls, eth.Code(implementationAddress, nil).Returns(&implementationCode))
+	}
+	if len(calls) > 0 {
+		err = client.CallCtx(ctx, calls...)
+		if _, ok := err.(w3.CallErrors); err != nil && !ok {
+			return nil, nil, fmt.Errorf("failed to screen token: %v", err)
+		}
+	}
+	if len(implementationCode) > 0 {
+		code = implementationCode
+	}
+
+	// Owner controls
+	if !bytes.HasPrefix(code, minimalProxyPrefix) && tokens.HasAnySelector(code, blacklistSelectors) {
+		report.Blacklist = true
+		if owner != (common.Address{}) {
+			addFinding(report, 25, "owner can blacklist holders")
+		} else {
+			addFinding(report, 5, "token has blacklist functions but ownership is renounced")
+		}
+	}
+
+	// Supply concentration
+	if totalSupply.Sign() > 0 && ownerBalance.Sign() > 0 {
+		share := new(big.Int).Mul(&ownerBalance, big.NewInt(10000))
+		share.Div(share, &totalSupply)
+		report.OwnerSupplyBps = share.Uint64()
+
+		switch {
+		case report.OwnerSupplyBps >= 5000:
+			addFinding(report, 30, fmt.Sprintf("owner holds %d%% of the supply", report.OwnerSupplyBps/100))
+		case report.OwnerSupplyBps >= 2000:
+			addFinding(report, 10, fmt.Sprintf("owner holds %d%% of the supply", report.OwnerSupplyBps/100))
+		}
+	}
+
+	report.Level = level(report.Score)
+	logger.DebugContext(ctx, "Screened token", "token", token.Hex(), "pool", pool.Hex(), "score", report.Score, "level", report.Level, "reasons", report.Reasons)
+	return report, behaviour, nil
+}
+
+// addFinding raises the score, capped at 100, and records the reason
+func addFinding(r *Report, points int, reason string) {
+	r.Score += points
+	if r.Score > 100 {
+		r.Score = 100
+	}
+	r.Reasons = append(r.Reasons, reason)
+}
+
+// addTax scores a buy or sell tax
+func addTax(r *Report, side string, bps uint64) {
+	switch {
+	case bps >= 1000:
+		addFinding(r, 30, fmt.Sprintf("%s tax of %d%%", side, bps/100))
+	case bps > 0:
+		addFinding(r, 10, fmt.Sprintf("%s tax of %d.%02d%%", side, bps/100, bps%100))
+	}
+}
+
+// level maps a score to a risk level
+func level(score int) string {
+	switch {
+	case score >= 50:
+		return LevelHigh
+	case score >= 20:
+		return LevelMedium
+	default:
+		return LevelLow
+	}
+}
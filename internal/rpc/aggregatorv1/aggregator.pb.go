@@ -0,0 +1,1875 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: aggregator/v1/aggregator.proto
+
+package aggregatorv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Updates decides when a subscription is quoted again.
+type Updates int32
+
+const (
+	Updates_UPDATES_UNSPECIFIED Updates = 0
+	// Every new block, the default.
+	Updates_UPDATES_BLOCK Updates = 1
+	// Only when the best route changes.
+	Updates_UPDATES_CHANGE Updates = 2
+)
+
+// Enum value maps for Updates.
+var (
+	Updates_name = map[int32]string{
+		0: "UPDATES_UNSPECIFIED",
+		1: "UPDATES_BLOCK",
+		2: "UPDATES_CHANGE",
+	}
+	Updates_value = map[string]int32{
+		"UPDATES_UNSPECIFIED": 0,
+		"UPDATES_BLOCK":       1,
+		"UPDATES_CHANGE":      2,
+	}
+)
+
+func (x Updates) Enum() *Updates {
+	p := new(Updates)
+	*p = x
+	return p
+}
+
+func (x Updates) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Updates) Descriptor() protoreflect.EnumDescriptor {
+	return file_aggregator_v1_aggregator_proto_enumTypes[0].Descriptor()
+}
+
+func (Updates) Type() protoreflect.EnumType {
+	return &file_aggregator_v1_aggregator_proto_enumTypes[0]
+}
+
+func (x Updates) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Updates.Descriptor instead.
+func (Updates) EnumDescriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{0}
+}
+
+// Amount is an exact token amount.
+type Amount struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Integer in the token's smallest unit, base 10.
+	Raw string `protobuf:"bytes,1,opt,name=raw,proto3" json:"raw,omitempty"`
+	// Amount in whole tokens, e.g. "1.5".
+	Display       string `protobuf:"bytes,2,opt,name=display,proto3" json:"display,omitempty"`
+	Decimals      uint32 `protobuf:"varint,3,opt,name=decimals,proto3" json:"decimals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Amount) Reset() {
+	*x = Amount{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Amount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Amount) ProtoMessage() {}
+
+func (x *Amount) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Amount.ProtoReflect.Descriptor instead.
+func (*Amount) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Amount) GetRaw() string {
+	if x != nil {
+		return x.Raw
+	}
+	return ""
+}
+
+func (x *Amount) GetDisplay() string {
+	if x != nil {
+		return x.Display
+	}
+	return ""
+}
+
+func (x *Amount) GetDecimals() uint32 {
+	if x != nil {
+		return x.Decimals
+	}
+	return 0
+}
+
+// Behaviour is the result of probing a token's transfers.
+type Behaviour struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Recipients receive less than the amount sent.
+	FeeOnTransfer  bool   `protobuf:"varint,1,opt,name=fee_on_transfer,json=feeOnTransfer,proto3" json:"fee_on_transfer,omitempty"`
+	TransferFeeBps uint64 `protobuf:"varint,2,opt,name=transfer_fee_bps,json=transferFeeBps,proto3" json:"transfer_fee_bps,omitempty"`
+	// Balances are share-based or change without transfers.
+	Rebasing bool `protobuf:"varint,3,opt,name=rebasing,proto3" json:"rebasing,omitempty"`
+	// Unix timestamp of the probe.
+	ProbedAt      int64 `protobuf:"varint,4,opt,name=probed_at,json=probedAt,proto3" json:"probed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Behaviour) Reset() {
+	*x = Behaviour{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Behaviour) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Behaviour) ProtoMessage() {}
+
+func (x *Behaviour) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Behaviour.ProtoReflect.Descriptor instead.
+func (*Behaviour) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Behaviour) GetFeeOnTransfer() bool {
+	if x != nil {
+		return x.FeeOnTransfer
+	}
+	return false
+}
+
+func (x *Behaviour) GetTransferFeeBps() uint64 {
+	if x != nil {
+		return x.TransferFeeBps
+	}
+	return 0
+}
+
+func (x *Behaviour) GetRebasing() bool {
+	if x != nil {
+		return x.Rebasing
+	}
+	return false
+}
+
+func (x *Behaviour) GetProbedAt() int64 {
+	if x != nil {
+		return x.ProbedAt
+	}
+	return 0
+}
+
+// Risk is the result of screening a token.
+type Risk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 0 (no findings) to 100 (avoid).
+	Score int32 `protobuf:"varint,1,opt,name=score,proto3" json:"score,omitempty"`
+	// low, medium or high.
+	Level          string   `protobuf:"bytes,2,opt,name=level,proto3" json:"level,omitempty"`
+	Reasons        []string `protobuf:"bytes,3,rep,name=reasons,proto3" json:"reasons,omitempty"`
+	BuyBlocked     bool     `protobuf:"varint,4,opt,name=buy_blocked,json=buyBlocked,proto3" json:"buy_blocked,omitempty"`
+	SellBlocked    bool     `protobuf:"varint,5,opt,name=sell_blocked,json=sellBlocked,proto3" json:"sell_blocked,omitempty"`
+	BuyTaxBps      uint64   `protobuf:"varint,6,opt,name=buy_tax_bps,json=buyTaxBps,proto3" json:"buy_tax_bps,omitempty"`
+	SellTaxBps     uint64   `protobuf:"varint,7,opt,name=sell_tax_bps,json=sellTaxBps,proto3" json:"sell_tax_bps,omitempty"`
+	Blacklist      bool     `protobuf:"varint,8,opt,name=blacklist,proto3" json:"blacklist,omitempty"`
+	Upgradeable    bool     `protobuf:"varint,9,opt,name=upgradeable,proto3" json:"upgradeable,omitempty"`
+	Owner          string   `protobuf:"bytes,10,opt,name=owner,proto3" json:"owner,omitempty"`
+	OwnerSupplyBps uint64   `protobuf:"varint,11,opt,name=owner_supply_bps,json=ownerSupplyBps,proto3" json:"owner_supply_bps,omitempty"`
+	// Unix timestamp of the screening.
+	ScreenedAt int64 `protobuf:"varint,12,opt,name=screened_at,json=screenedAt,proto3" json:"screened_at,omitempty"`
+	// Pool used for the buy and sell simulation.
+	Pool          string `protobuf:"bytes,13,opt,name=pool,proto3" json:"pool,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Risk) Reset() {
+	*x = Risk{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Risk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Risk) ProtoMessage() {}
+
+func (x *Risk) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Risk.ProtoReflect.Descriptor instead.
+func (*Risk) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Risk) GetScore() int32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Risk) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *Risk) GetReasons() []string {
+	if x != nil {
+		return x.Reasons
+	}
+	return nil
+}
+
+func (x *Risk) GetBuyBlocked() bool {
+	if x != nil {
+		return x.BuyBlocked
+	}
+	return false
+}
+
+func (x *Risk) GetSellBlocked() bool {
+	if x != nil {
+		return x.SellBlocked
+	}
+	return false
+}
+
+func (x *Risk) GetBuyTaxBps() uint64 {
+	if x != nil {
+		return x.BuyTaxBps
+	}
+	return 0
+}
+
+func (x *Risk) GetSellTaxBps() uint64 {
+	if x != nil {
+		return x.SellTaxBps
+	}
+	return 0
+}
+
+func (x *Risk) GetBlacklist() bool {
+	if x != nil {
+		return x.Blacklist
+	}
+	return false
+}
+
+func (x *Risk) GetUpgradeable() bool {
+	if x != nil {
+		return x.Upgradeable
+	}
+	return false
+}
+
+func (x *Risk) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Risk) GetOwnerSupplyBps() uint64 {
+	if x != nil {
+		return x.OwnerSupplyBps
+	}
+	return 0
+}
+
+func (x *Risk) GetScreenedAt() int64 {
+	if x != nil {
+		return x.ScreenedAt
+	}
+	return 0
+}
+
+func (x *Risk) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+// Token is a token's metadata.
+type Token struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Address  string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Name     string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Symbol   string                 `protobuf:"bytes,3,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Decimals uint32                 `protobuf:"varint,4,opt,name=decimals,proto3" json:"decimals,omitempty"`
+	LogoUri  string                 `protobuf:"bytes,5,opt,name=logo_uri,json=logoUri,proto3" json:"logo_uri,omitempty"`
+	// Unset if the token hasn't been probed.
+	Behaviour *Behaviour `protobuf:"bytes,6,opt,name=behaviour,proto3" json:"behaviour,omitempty"`
+	// Unset if the token hasn't been screened.
+	Risk *Risk `protobuf:"bytes,7,opt,name=risk,proto3" json:"risk,omitempty"`
+	// Fields the token didn't return that were filled in.
+	Inferred []string `protobuf:"bytes,8,rep,name=inferred,proto3" json:"inferred,omitempty"`
+	// Tags from the token list.
+	Tags []string `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Differences between the token list and the chain.
+	Warnings []string `protobuf:"bytes,10,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	// Imported from a token list.
+	Curated       bool `protobuf:"varint,11,opt,name=curated,proto3" json:"curated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Token) Reset() {
+	*x = Token{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Token) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Token) ProtoMessage() {}
+
+func (x *Token) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Token.ProtoReflect.Descriptor instead.
+func (*Token) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Token) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Token) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Token) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Token) GetDecimals() uint32 {
+	if x != nil {
+		return x.Decimals
+	}
+	return 0
+}
+
+func (x *Token) GetLogoUri() string {
+	if x != nil {
+		return x.LogoUri
+	}
+	return ""
+}
+
+func (x *Token) GetBehaviour() *Behaviour {
+	if x != nil {
+		return x.Behaviour
+	}
+	return nil
+}
+
+func (x *Token) GetRisk() *Risk {
+	if x != nil {
+		return x.Risk
+	}
+	return nil
+}
+
+func (x *Token) GetInferred() []string {
+	if x != nil {
+		return x.Inferred
+	}
+	return nil
+}
+
+func (x *Token) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Token) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+func (x *Token) GetCurated() bool {
+	if x != nil {
+		return x.Curated
+	}
+	return false
+}
+
+// Route is a quote through a single pool.
+type Route struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Protocol    string                 `protobuf:"bytes,1,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	PoolAddress string                 `protobuf:"bytes,2,opt,name=pool_address,json=poolAddress,proto3" json:"pool_address,omitempty"`
+	// Fee tier, e.g. 500 or 3000.
+	Fee uint64 `protobuf:"varint,3,opt,name=fee,proto3" json:"fee,omitempty"`
+	// Symbol of the input token.
+	TokenIn string `protobuf:"bytes,4,opt,name=token_in,json=tokenIn,proto3" json:"token_in,omitempty"`
+	// Symbol of the output token.
+	TokenOut  string  `protobuf:"bytes,5,opt,name=token_out,json=tokenOut,proto3" json:"token_out,omitempty"`
+	AmountIn  *Amount `protobuf:"bytes,6,opt,name=amount_in,json=amountIn,proto3" json:"amount_in,omitempty"`
+	AmountOut *Amount `protobuf:"bytes,7,opt,name=amount_out,json=amountOut,proto3" json:"amount_out,omitempty"`
+	// Estimated gas, including wrapping and unwrapping.
+	Gas uint64 `protobuf:"varint,8,opt,name=gas,proto3" json:"gas,omitempty"`
+	// Router, or wrapped native token, the swap is sent to.
+	Router       string `protobuf:"bytes,9,opt,name=router,proto3" json:"router,omitempty"`
+	WrapNative   bool   `protobuf:"varint,10,opt,name=wrap_native,json=wrapNative,proto3" json:"wrap_native,omitempty"`
+	UnwrapNative bool   `protobuf:"varint,11,opt,name=unwrap_native,json=unwrapNative,proto3" json:"unwrap_native,omitempty"`
+	// Token behaviour the user should know about.
+	Warnings      []string `protobuf:"bytes,12,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Route) Reset() {
+	*x = Route{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Route) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Route) ProtoMessage() {}
+
+func (x *Route) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Route.ProtoReflect.Descriptor instead.
+func (*Route) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Route) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *Route) GetPoolAddress() string {
+	if x != nil {
+		return x.PoolAddress
+	}
+	return ""
+}
+
+func (x *Route) GetFee() uint64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *Route) GetTokenIn() string {
+	if x != nil {
+		return x.TokenIn
+	}
+	return ""
+}
+
+func (x *Route) GetTokenOut() string {
+	if x != nil {
+		return x.TokenOut
+	}
+	return ""
+}
+
+func (x *Route) GetAmountIn() *Amount {
+	if x != nil {
+		return x.AmountIn
+	}
+	return nil
+}
+
+func (x *Route) GetAmountOut() *Amount {
+	if x != nil {
+		return x.AmountOut
+	}
+	return nil
+}
+
+func (x *Route) GetGas() uint64 {
+	if x != nil {
+		return x.Gas
+	}
+	return 0
+}
+
+func (x *Route) GetRouter() string {
+	if x != nil {
+		return x.Router
+	}
+	return ""
+}
+
+func (x *Route) GetWrapNative() bool {
+	if x != nil {
+		return x.WrapNative
+	}
+	return false
+}
+
+func (x *Route) GetUnwrapNative() bool {
+	if x != nil {
+		return x.UnwrapNative
+	}
+	return false
+}
+
+func (x *Route) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+// QuoteRequest is the pair and amount to quote. At most one of amount and
+// amount_human may be set; without either 10000 of the smallest unit is
+// quoted.
+type QuoteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Input token address, 0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE for the
+	// native currency.
+	TokenIn  string `protobuf:"bytes,1,opt,name=token_in,json=tokenIn,proto3" json:"token_in,omitempty"`
+	TokenOut string `protobuf:"bytes,2,opt,name=token_out,json=tokenOut,proto3" json:"token_out,omitempty"`
+	// Amount in the input token's smallest unit.
+	Amount string `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	// Amount in whole input tokens, e.g. "1.5".
+	AmountHuman string `protobuf:"bytes,4,opt,name=amount_human,json=amountHuman,proto3" json:"amount_human,omitempty"`
+	// Route ranking: output (default), gas or fee.
+	Rank string `protobuf:"bytes,5,opt,name=rank,proto3" json:"rank,omitempty"`
+	// Maximum number of routes, 0 returns all.
+	Limit         int32 `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuoteRequest) Reset() {
+	*x = QuoteRequest{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuoteRequest) ProtoMessage() {}
+
+func (x *QuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuoteRequest.ProtoReflect.Descriptor instead.
+func (*QuoteRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *QuoteRequest) GetTokenIn() string {
+	if x != nil {
+		return x.TokenIn
+	}
+	return ""
+}
+
+func (x *QuoteRequest) GetTokenOut() string {
+	if x != nil {
+		return x.TokenOut
+	}
+	return ""
+}
+
+func (x *QuoteRequest) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *QuoteRequest) GetAmountHuman() string {
+	if x != nil {
+		return x.AmountHuman
+	}
+	return ""
+}
+
+func (x *QuoteRequest) GetRank() string {
+	if x != nil {
+		return x.Rank
+	}
+	return ""
+}
+
+func (x *QuoteRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// Quote is the ranked routes for a pair.
+type Quote struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	TokenIn   *Token                 `protobuf:"bytes,1,opt,name=token_in,json=tokenIn,proto3" json:"token_in,omitempty"`
+	TokenOut  *Token                 `protobuf:"bytes,2,opt,name=token_out,json=tokenOut,proto3" json:"token_out,omitempty"`
+	AmountIn  *Amount                `protobuf:"bytes,3,opt,name=amount_in,json=amountIn,proto3" json:"amount_in,omitempty"`
+	BestRoute *Route                 `protobuf:"bytes,4,opt,name=best_route,json=bestRoute,proto3" json:"best_route,omitempty"`
+	// Every route, best first.
+	Routes []*Route `protobuf:"bytes,5,rep,name=routes,proto3" json:"routes,omitempty"`
+	// Screening of the input token, unset if unknown.
+	TokenInRisk *Risk `protobuf:"bytes,6,opt,name=token_in_risk,json=tokenInRisk,proto3" json:"token_in_risk,omitempty"`
+	// Screening of the output token, unset if unknown.
+	TokenOutRisk *Risk `protobuf:"bytes,7,opt,name=token_out_risk,json=tokenOutRisk,proto3" json:"token_out_risk,omitempty"`
+	// amount_in was rounded from the requested amount so nearby amounts share
+	// cached quotes.
+	Approximate   bool `protobuf:"varint,8,opt,name=approximate,proto3" json:"approximate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Quote) Reset() {
+	*x = Quote{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quote) ProtoMessage() {}
+
+func (x *Quote) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quote.ProtoReflect.Descriptor instead.
+func (*Quote) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Quote) GetTokenIn() *Token {
+	if x != nil {
+		return x.TokenIn
+	}
+	return nil
+}
+
+func (x *Quote) GetTokenOut() *Token {
+	if x != nil {
+		return x.TokenOut
+	}
+	return nil
+}
+
+func (x *Quote) GetAmountIn() *Amount {
+	if x != nil {
+		return x.AmountIn
+	}
+	return nil
+}
+
+func (x *Quote) GetBestRoute() *Route {
+	if x != nil {
+		return x.BestRoute
+	}
+	return nil
+}
+
+func (x *Quote) GetRoutes() []*Route {
+	if x != nil {
+		return x.Routes
+	}
+	return nil
+}
+
+func (x *Quote) GetTokenInRisk() *Risk {
+	if x != nil {
+		return x.TokenInRisk
+	}
+	return nil
+}
+
+func (x *Quote) GetTokenOutRisk() *Risk {
+	if x != nil {
+		return x.TokenOutRisk
+	}
+	return nil
+}
+
+func (x *Quote) GetApproximate() bool {
+	if x != nil {
+		return x.Approximate
+	}
+	return false
+}
+
+type GetQuoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quote         *QuoteRequest          `protobuf:"bytes,1,opt,name=quote,proto3" json:"quote,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetQuoteRequest) Reset() {
+	*x = GetQuoteRequest{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetQuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetQuoteRequest) ProtoMessage() {}
+
+func (x *GetQuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetQuoteRequest.ProtoReflect.Descriptor instead.
+func (*GetQuoteRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetQuoteRequest) GetQuote() *QuoteRequest {
+	if x != nil {
+		return x.Quote
+	}
+	return nil
+}
+
+type GetQuoteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quote         *Quote                 `protobuf:"bytes,1,opt,name=quote,proto3" json:"quote,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetQuoteResponse) Reset() {
+	*x = GetQuoteResponse{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetQuoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetQuoteResponse) ProtoMessage() {}
+
+func (x *GetQuoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetQuoteResponse.ProtoReflect.Descriptor instead.
+func (*GetQuoteResponse) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetQuoteResponse) GetQuote() *Quote {
+	if x != nil {
+		return x.Quote
+	}
+	return nil
+}
+
+type SubscribeQuotesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quote         *QuoteRequest          `protobuf:"bytes,1,opt,name=quote,proto3" json:"quote,omitempty"`
+	Updates       Updates                `protobuf:"varint,2,opt,name=updates,proto3,enum=aggregator.v1.Updates" json:"updates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeQuotesRequest) Reset() {
+	*x = SubscribeQuotesRequest{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeQuotesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeQuotesRequest) ProtoMessage() {}
+
+func (x *SubscribeQuotesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeQuotesRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeQuotesRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SubscribeQuotesRequest) GetQuote() *QuoteRequest {
+	if x != nil {
+		return x.Quote
+	}
+	return nil
+}
+
+func (x *SubscribeQuotesRequest) GetUpdates() Updates {
+	if x != nil {
+		return x.Updates
+	}
+	return Updates_UPDATES_UNSPECIFIED
+}
+
+// Error is a failed quote of a subscription.
+type Error struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The HTTP API's error code, e.g. "no_route".
+	Code          string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Error) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type SubscribeQuotesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Block the quote was made at, 0 if no block has been seen yet.
+	Block uint64 `protobuf:"varint,1,opt,name=block,proto3" json:"block,omitempty"`
+	// Types that are valid to be assigned to Result:
+	//
+	//	*SubscribeQuotesResponse_Quote
+	//	*SubscribeQuotesResponse_Error
+	Result        isSubscribeQuotesResponse_Result `protobuf_oneof:"result"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeQuotesResponse) Reset() {
+	*x = SubscribeQuotesResponse{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeQuotesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeQuotesResponse) ProtoMessage() {}
+
+func (x *SubscribeQuotesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeQuotesResponse.ProtoReflect.Descriptor instead.
+func (*SubscribeQuotesResponse) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SubscribeQuotesResponse) GetBlock() uint64 {
+	if x != nil {
+		return x.Block
+	}
+	return 0
+}
+
+func (x *SubscribeQuotesResponse) GetResult() isSubscribeQuotesResponse_Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *SubscribeQuotesResponse) GetQuote() *Quote {
+	if x != nil {
+		if x, ok := x.Result.(*SubscribeQuotesResponse_Quote); ok {
+			return x.Quote
+		}
+	}
+	return nil
+}
+
+func (x *SubscribeQuotesResponse) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Result.(*SubscribeQuotesResponse_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isSubscribeQuotesResponse_Result interface {
+	isSubscribeQuotesResponse_Result()
+}
+
+type SubscribeQuotesResponse_Quote struct {
+	Quote *Quote `protobuf:"bytes,2,opt,name=quote,proto3,oneof"`
+}
+
+type SubscribeQuotesResponse_Error struct {
+	Error *Error `protobuf:"bytes,3,opt,name=error,proto3,oneof"`
+}
+
+func (*SubscribeQuotesResponse_Quote) isSubscribeQuotesResponse_Result() {}
+
+func (*SubscribeQuotesResponse_Error) isSubscribeQuotesResponse_Result() {}
+
+type GetTokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTokenRequest) Reset() {
+	*x = GetTokenRequest{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTokenRequest) ProtoMessage() {}
+
+func (x *GetTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTokenRequest.ProtoReflect.Descriptor instead.
+func (*GetTokenRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetTokenRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type GetTokenResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Token *Token                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	// Where the metadata came from: cache or chain.
+	Source        string `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTokenResponse) Reset() {
+	*x = GetTokenResponse{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTokenResponse) ProtoMessage() {}
+
+func (x *GetTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTokenResponse.ProtoReflect.Descriptor instead.
+func (*GetTokenResponse) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetTokenResponse) GetToken() *Token {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+func (x *GetTokenResponse) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+// Protocol is a supported DEX protocol.
+type Protocol struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Key used in configuration, e.g. "uniswapv3".
+	Id             string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name           string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	FactoryAddress string `protobuf:"bytes,3,opt,name=factory_address,json=factoryAddress,proto3" json:"factory_address,omitempty"`
+	// Quoter contract quotes are read from.
+	RouterAddress string   `protobuf:"bytes,4,opt,name=router_address,json=routerAddress,proto3" json:"router_address,omitempty"`
+	FeeTiers      []uint64 `protobuf:"varint,5,rep,packed,name=fee_tiers,json=feeTiers,proto3" json:"fee_tiers,omitempty"`
+	UniswapFork   bool     `protobuf:"varint,6,opt,name=uniswap_fork,json=uniswapFork,proto3" json:"uniswap_fork,omitempty"`
+	// Estimated gas of a single swap through the router.
+	SwapGas uint64 `protobuf:"varint,7,opt,name=swap_gas,json=swapGas,proto3" json:"swap_gas,omitempty"`
+	// Preference when routes tie, lower is preferred.
+	Priority int32 `protobuf:"varint,8,opt,name=priority,proto3" json:"priority,omitempty"`
+	// Router swaps are sent to, empty if swaps can't be built.
+	SwapRouterAddress string `protobuf:"bytes,9,opt,name=swap_router_address,json=swapRouterAddress,proto3" json:"swap_router_address,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Protocol) Reset() {
+	*x = Protocol{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Protocol) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Protocol) ProtoMessage() {}
+
+func (x *Protocol) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Protocol.ProtoReflect.Descriptor instead.
+func (*Protocol) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Protocol) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Protocol) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Protocol) GetFactoryAddress() string {
+	if x != nil {
+		return x.FactoryAddress
+	}
+	return ""
+}
+
+func (x *Protocol) GetRouterAddress() string {
+	if x != nil {
+		return x.RouterAddress
+	}
+	return ""
+}
+
+func (x *Protocol) GetFeeTiers() []uint64 {
+	if x != nil {
+		return x.FeeTiers
+	}
+	return nil
+}
+
+func (x *Protocol) GetUniswapFork() bool {
+	if x != nil {
+		return x.UniswapFork
+	}
+	return false
+}
+
+func (x *Protocol) GetSwapGas() uint64 {
+	if x != nil {
+		return x.SwapGas
+	}
+	return 0
+}
+
+func (x *Protocol) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Protocol) GetSwapRouterAddress() string {
+	if x != nil {
+		return x.SwapRouterAddress
+	}
+	return ""
+}
+
+type ListProtocolsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProtocolsRequest) Reset() {
+	*x = ListProtocolsRequest{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProtocolsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProtocolsRequest) ProtoMessage() {}
+
+func (x *ListProtocolsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProtocolsRequest.ProtoReflect.Descriptor instead.
+func (*ListProtocolsRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{15}
+}
+
+type ListProtocolsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Protocols     []*Protocol            `protobuf:"bytes,1,rep,name=protocols,proto3" json:"protocols,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProtocolsResponse) Reset() {
+	*x = ListProtocolsResponse{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProtocolsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProtocolsResponse) ProtoMessage() {}
+
+func (x *ListProtocolsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProtocolsResponse.ProtoReflect.Descriptor instead.
+func (*ListProtocolsResponse) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListProtocolsResponse) GetProtocols() []*Protocol {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
+type BuildSwapRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	TokenIn  string                 `protobuf:"bytes,1,opt,name=token_in,json=tokenIn,proto3" json:"token_in,omitempty"`
+	TokenOut string                 `protobuf:"bytes,2,opt,name=token_out,json=tokenOut,proto3" json:"token_out,omitempty"`
+	// Amount in the input token's smallest unit.
+	Amount string `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	// Receiver of the output tokens.
+	Recipient string `protobuf:"bytes,4,opt,name=recipient,proto3" json:"recipient,omitempty"`
+	// Slippage tolerance in basis points, 0 to 10000.
+	SlippageBps uint32 `protobuf:"varint,5,opt,name=slippage_bps,json=slippageBps,proto3" json:"slippage_bps,omitempty"`
+	// Route ranking: output (default), gas or fee.
+	Rank          string `protobuf:"bytes,6,opt,name=rank,proto3" json:"rank,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildSwapRequest) Reset() {
+	*x = BuildSwapRequest{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildSwapRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildSwapRequest) ProtoMessage() {}
+
+func (x *BuildSwapRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildSwapRequest.ProtoReflect.Descriptor instead.
+func (*BuildSwapRequest) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BuildSwapRequest) GetTokenIn() string {
+	if x != nil {
+		return x.TokenIn
+	}
+	return ""
+}
+
+func (x *BuildSwapRequest) GetTokenOut() string {
+	if x != nil {
+		return x.TokenOut
+	}
+	return ""
+}
+
+func (x *BuildSwapRequest) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *BuildSwapRequest) GetRecipient() string {
+	if x != nil {
+		return x.Recipient
+	}
+	return ""
+}
+
+func (x *BuildSwapRequest) GetSlippageBps() uint32 {
+	if x != nil {
+		return x.SlippageBps
+	}
+	return 0
+}
+
+func (x *BuildSwapRequest) GetRank() string {
+	if x != nil {
+		return x.Rank
+	}
+	return ""
+}
+
+// Transaction is an unsigned transaction for a wallet to sign.
+type Transaction struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	To    string                 `protobuf:"bytes,1,opt,name=to,proto3" json:"to,omitempty"`
+	// Hex encoded calldata.
+	Data string `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// Native amount to send in wei.
+	Value         string `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Transaction) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *Transaction) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *Transaction) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type BuildSwapResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Route            *Route                 `protobuf:"bytes,1,opt,name=route,proto3" json:"route,omitempty"`
+	AmountOutMinimum *Amount                `protobuf:"bytes,2,opt,name=amount_out_minimum,json=amountOutMinimum,proto3" json:"amount_out_minimum,omitempty"`
+	Transaction      *Transaction           `protobuf:"bytes,3,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	// Screening of the input token, unset if unknown.
+	TokenInRisk *Risk `protobuf:"bytes,4,opt,name=token_in_risk,json=tokenInRisk,proto3" json:"token_in_risk,omitempty"`
+	// Screening of the output token, unset if unknown.
+	TokenOutRisk  *Risk `protobuf:"bytes,5,opt,name=token_out_risk,json=tokenOutRisk,proto3" json:"token_out_risk,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildSwapResponse) Reset() {
+	*x = BuildSwapResponse{}
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildSwapResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildSwapResponse) ProtoMessage() {}
+
+func (x *BuildSwapResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aggregator_v1_aggregator_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildSwapResponse.ProtoReflect.Descriptor instead.
+func (*BuildSwapResponse) Descriptor() ([]byte, []int) {
+	return file_aggregator_v1_aggregator_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BuildSwapResponse) GetRoute() *Route {
+	if x != nil {
+		return x.Route
+	}
+	return nil
+}
+
+func (x *BuildSwapResponse) GetAmountOutMinimum() *Amount {
+	if x != nil {
+		return x.AmountOutMinimum
+	}
+	return nil
+}
+
+func (x *BuildSwapResponse) GetTransaction() *Transaction {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+func (x *BuildSwapResponse) GetTokenInRisk() *Risk {
+	if x != nil {
+		return x.TokenInRisk
+	}
+	return nil
+}
+
+func (x *BuildSwapResponse) GetTokenOutRisk() *Risk {
+	if x != nil {
+		return x.TokenOutRisk
+	}
+	return nil
+}
+
+var File_aggregator_v1_aggregator_proto protoreflect.FileDescriptor
+
+const file_aggregator_v1_aggregator_proto_rawDesc = "" +
+	"\n" +
+	"\x1eaggregator/v1/aggregator.proto\x12\raggregator.v1\"P\n" +
+	"\x06Amount\x12\x10\n" +
+	"\x03raw\x18\x01 \x01(\tR\x03raw\x12\x18\n" +
+	"\adisplay\x18\x02 \x01(\tR\adisplay\x12\x1a\n" +
+	"\bdecimals\x18\x03 \x01(\rR\bdecimals\"\x96\x01\n" +
+	"\tBehaviour\x12&\n" +
+	"\x0ffee_on_transfer\x18\x01 \x01(\bR\rfeeOnTransfer\x12(\n" +
+	"\x10transfer_fee_bps\x18\x02 \x01(\x04R\x0etransferFeeBps\x12\x1a\n" +
+	"\brebasing\x18\x03 \x01(\bR\brebasing\x12\x1b\n" +
+	"\tprobed_at\x18\x04 \x01(\x03R\bprobedAt\"\x87\x03\n" +
+	"\x04Risk\x12\x14\n" +
+	"\x05score\x18\x01 \x01(\x05R\x05score\x12\x14\n" +
+	"\x05level\x18\x02 \x01(\tR\x05level\x12\x18\n" +
+	"\areasons\x18\x03 \x03(\tR\areasons\x12\x1f\n" +
+	"\vbuy_blocked\x18\x04 \x01(\bR\n" +
+	"buyBlocked\x12!\n" +
+	"\fsell_blocked\x18\x05 \x01(\bR\vsellBlocked\x12\x1e\n" +
+	"\vbuy_tax_bps\x18\x06 \x01(\x04R\tbuyTaxBps\x12 \n" +
+	"\fsell_tax_bps\x18\a \x01(\x04R\n" +
+	"sellTaxBps\x12\x1c\n" +
+	"\tblacklist\x18\b \x01(\bR\tblacklist\x12 \n" +
+	"\vupgradeable\x18\t \x01(\bR\vupgradeable\x12\x14\n" +
+	"\x05owner\x18\n" +
+	" \x01(\tR\x05owner\x12(\n" +
+	"\x10owner_supply_bps\x18\v \x01(\x04R\x0eownerSupplyBps\x12\x1f\n" +
+	"\vscreened_at\x18\f \x01(\x03R\n" +
+	"screenedAt\x12\x12\n" +
+	"\x04pool\x18\r \x01(\tR\x04pool\"\xcb\x02\n" +
+	"\x05Token\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06symbol\x18\x03 \x01(\tR\x06symbol\x12\x1a\n" +
+	"\bdecimals\x18\x04 \x01(\rR\bdecimals\x12\x19\n" +
+	"\blogo_uri\x18\x05 \x01(\tR\alogoUri\x126\n" +
+	"\tbehaviour\x18\x06 \x01(\v2\x18.aggregator.v1.BehaviourR\tbehaviour\x12'\n" +
+	"\x04risk\x18\a \x01(\v2\x13.aggregator.v1.RiskR\x04risk\x12\x1a\n" +
+	"\binferred\x18\b \x03(\tR\binferred\x12\x12\n" +
+	"\x04tags\x18\t \x03(\tR\x04tags\x12\x1a\n" +
+	"\bwarnings\x18\n" +
+	" \x03(\tR\bwarnings\x12\x18\n" +
+	"\acurated\x18\v \x01(\bR\acurated\"\x86\x03\n" +
+	"\x05Route\x12\x1a\n" +
+	"\bprotocol\x18\x01 \x01(\tR\bprotocol\x12!\n" +
+	"\fpool_address\x18\x02 \x01(\tR\vpoolAddress\x12\x10\n" +
+	"\x03fee\x18\x03 \x01(\x04R\x03fee\x12\x19\n" +
+	"\btoken_in\x18\x04 \x01(\tR\atokenIn\x12\x1b\n" +
+	"\ttoken_out\x18\x05 \x01(\tR\btokenOut\x122\n" +
+	"\tamount_in\x18\x06 \x01(\v2\x15.aggregator.v1.AmountR\bamountIn\x124\n" +
+	"\n" +
+	"amount_out\x18\a \x01(\v2\x15.aggregator.v1.AmountR\tamountOut\x12\x10\n" +
+	"\x03gas\x18\b \x01(\x04R\x03gas\x12\x16\n" +
+	"\x06router\x18\t \x01(\tR\x06router\x12\x1f\n" +
+	"\vwrap_native\x18\n" +
+	" \x01(\bR\n" +
+	"wrapNative\x12#\n" +
+	"\runwrap_native\x18\v \x01(\bR\funwrapNative\x12\x1a\n" +
+	"\bwarnings\x18\f \x03(\tR\bwarnings\"\xab\x01\n" +
+	"\fQuoteRequest\x12\x19\n" +
+	"\btoken_in\x18\x01 \x01(\tR\atokenIn\x12\x1b\n" +
+	"\ttoken_out\x18\x02 \x01(\tR\btokenOut\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\tR\x06amount\x12!\n" +
+	"\famount_human\x18\x04 \x01(\tR\vamountHuman\x12\x12\n" +
+	"\x04rank\x18\x05 \x01(\tR\x04rank\x12\x14\n" +
+	"\x05limit\x18\x06 \x01(\x05R\x05limit\"\x98\x03\n" +
+	"\x05Quote\x12/\n" +
+	"\btoken_in\x18\x01 \x01(\v2\x14.aggregator.v1.TokenR\atokenIn\x121\n" +
+	"\ttoken_out\x18\x02 \x01(\v2\x14.aggregator.v1.TokenR\btokenOut\x122\n" +
+	"\tamount_in\x18\x03 \x01(\v2\x15.aggregator.v1.AmountR\bamountIn\x123\n" +
+	"\n" +
+	"best_route\x18\x04 \x01(\v2\x14.aggregator.v1.RouteR\tbestRoute\x12,\n" +
+	"\x06routes\x18\x05 \x03(\v2\x14.aggregator.v1.RouteR\x06routes\x127\n" +
+	"\rtoken_in_risk\x18\x06 \x01(\v2\x13.aggregator.v1.RiskR\vtokenInRisk\x129\n" +
+	"\x0etoken_out_risk\x18\a \x01(\v2\x13.aggregator.v1.RiskR\ftokenOutRisk\x12 \n" +
+	"\vapproximate\x18\b \x01(\bR\vapproximate\"D\n" +
+	"\x0fGetQuoteRequest\x121\n" +
+	"\x05quote\x18\x01 \x01(\v2\x1b.aggregator.v1.QuoteRequestR\x05quote\">\n" +
+	"\x10GetQuoteResponse\x12*\n" +
+	"\x05quote\x18\x01 \x01(\v2\x14.aggregator.v1.QuoteR\x05quote\"}\n" +
+	"\x16SubscribeQuotesRequest\x121\n" +
+	"\x05quote\x18\x01 \x01(\v2\x1b.aggregator.v1.QuoteRequestR\x05quote\x120\n" +
+	"\aupdates\x18\x02 \x01(\x0e2\x16.aggregator.v1.UpdatesR\aupdates\"5\n" +
+	"\x05Error\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x95\x01\n" +
+	"\x17SubscribeQuotesResponse\x12\x14\n" +
+	"\x05block\x18\x01 \x01(\x04R\x05block\x12,\n" +
+	"\x05quote\x18\x02 \x01(\v2\x14.aggregator.v1.QuoteH\x00R\x05quote\x12,\n" +
+	"\x05error\x18\x03 \x01(\v2\x14.aggregator.v1.ErrorH\x00R\x05errorB\b\n" +
+	"\x06result\"+\n" +
+	"\x0fGetTokenRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\"V\n" +
+	"\x10GetTokenResponse\x12*\n" +
+	"\x05token\x18\x01 \x01(\v2\x14.aggregator.v1.TokenR\x05token\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\"\xa5\x02\n" +
+	"\bProtocol\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12'\n" +
+	"\x0ffactory_address\x18\x03 \x01(\tR\x0efactoryAddress\x12%\n" +
+	"\x0erouter_address\x18\x04 \x01(\tR\rrouterAddress\x12\x1b\n" +
+	"\tfee_tiers\x18\x05 \x03(\x04R\bfeeTiers\x12!\n" +
+	"\funiswap_fork\x18\x06 \x01(\bR\vuniswapFork\x12\x19\n" +
+	"\bswap_gas\x18\a \x01(\x04R\aswapGas\x12\x1a\n" +
+	"\bpriority\x18\b \x01(\x05R\bpriority\x12.\n" +
+	"\x13swap_router_address\x18\t \x01(\tR\x11swapRouterAddress\"\x16\n" +
+	"\x14ListProtocolsRequest\"N\n" +
+	"\x15ListProtocolsResponse\x125\n" +
+	"\tprotocols\x18\x01 \x03(\v2\x17.aggregator.v1.ProtocolR\tprotocols\"\xb7\x01\n" +
+	"\x10BuildSwapRequest\x12\x19\n" +
+	"\btoken_in\x18\x01 \x01(\tR\atokenIn\x12\x1b\n" +
+	"\ttoken_out\x18\x02 \x01(\tR\btokenOut\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\tR\x06amount\x12\x1c\n" +
+	"\trecipient\x18\x04 \x01(\tR\trecipient\x12!\n" +
+	"\fslippage_bps\x18\x05 \x01(\rR\vslippageBps\x12\x12\n" +
+	"\x04rank\x18\x06 \x01(\tR\x04rank\"G\n" +
+	"\vTransaction\x12\x0e\n" +
+	"\x02to\x18\x01 \x01(\tR\x02to\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\tR\x04data\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\"\xb6\x02\n" +
+	"\x11BuildSwapResponse\x12*\n" +
+	"\x05route\x18\x01 \x01(\v2\x14.aggregator.v1.RouteR\x05route\x12C\n" +
+	"\x12amount_out_minimum\x18\x02 \x01(\v2\x15.aggregator.v1.AmountR\x10amountOutMinimum\x12<\n" +
+	"\vtransaction\x18\x03 \x01(\v2\x1a.aggregator.v1.TransactionR\vtransaction\x127\n" +
+	"\rtoken_in_risk\x18\x04 \x01(\v2\x13.aggregator.v1.RiskR\vtokenInRisk\x129\n" +
+	"\x0etoken_out_risk\x18\x05 \x01(\v2\x13.aggregator.v1.RiskR\ftokenOutRisk*I\n" +
+	"\aUpdates\x12\x17\n" +
+	"\x13UPDATES_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rUPDATES_BLOCK\x10\x01\x12\x12\n" +
+	"\x0eUPDATES_CHANGE\x10\x022\xbd\x03\n" +
+	"\x11AggregatorService\x12K\n" +
+	"\bGetQuote\x12\x1e.aggregator.v1.GetQuoteRequest\x1a\x1f.aggregator.v1.GetQuoteResponse\x12b\n" +
+	"\x0fSubscribeQuotes\x12%.aggregator.v1.SubscribeQuotesRequest\x1a&.aggregator.v1.SubscribeQuotesResponse0\x01\x12K\n" +
+	"\bGetToken\x12\x1e.aggregator.v1.GetTokenRequest\x1a\x1f.aggregator.v1.GetTokenResponse\x12Z\n" +
+	"\rListProtocols\x12#.aggregator.v1.ListProtocolsRequest\x1a$.aggregator.v1.ListProtocolsResponse\x12N\n" +
+	"\tBuildSwap\x12\x1f.aggregator.v1.BuildSwapRequest\x1a .aggregator.v1.BuildSwapResponseBSZQgithub.com/bitcoinbrisbane/defi-aggregator/internal/rpc/aggregatorv1;aggregatorv1b\x06proto3"
+
+var (
+	file_aggregator_v1_aggregator_proto_rawDescOnce sync.Once
+	file_aggregator_v1_aggregator_proto_rawDescData []byte
+)
+
+func file_aggregator_v1_aggregator_proto_rawDescGZIP() []byte {
+	file_aggregator_v1_aggregator_proto_rawDescOnce.Do(func() {
+		file_aggregator_v1_aggregator_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_aggregator_v1_aggregator_proto_rawDesc), len(file_aggregator_v1_aggregator_proto_rawDesc)))
+	})
+	return file_aggregator_v1_aggregator_proto_rawDescData
+}
+
+var file_aggregator_v1_aggregator_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_aggregator_v1_aggregator_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_aggregator_v1_aggregator_proto_goTypes = []any{
+	(Updates)(0),                    // 0: aggregator.v1.Updates
+	(*Amount)(nil),                  // 1: aggregator.v1.Amount
+	(*Behaviour)(nil),               // 2: aggregator.v1.Behaviour
+	(*Risk)(nil),                    // 3: aggregator.v1.Risk
+	(*Token)(nil),                   // 4: aggregator.v1.Token
+	(*Route)(nil),                   // 5: aggregator.v1.Route
+	(*QuoteRequest)(nil),            // 6: aggregator.v1.QuoteRequest
+	(*Quote)(nil),                   // 7: aggregator.v1.Quote
+	(*GetQuoteRequest)(nil),         // 8: aggregator.v1.GetQuoteRequest
+	(*GetQuoteResponse)(nil),        // 9: aggregator.v1.GetQuoteResponse
+	(*SubscribeQuotesRequest)(nil),  // 10: aggregator.v1.SubscribeQuotesRequest
+	(*Error)(nil),                   // 11: aggregator.v1.Error
+	(*SubscribeQuotesResponse)(nil), // 12: aggregator.v1.SubscribeQuotesResponse
+	(*GetTokenRequest)(nil),         // 13: aggregator.v1.GetTokenRequest
+	(*GetTokenResponse)(nil),        // 14: aggregator.v1.GetTokenResponse
+	(*Protocol)(nil),                // 15: aggregator.v1.Protocol
+	(*ListProtocolsRequest)(nil),    // 16: aggregator.v1.ListProtocolsRequest
+	(*ListProtocolsResponse)(nil),   // 17: aggregator.v1.ListProtocolsResponse
+	(*BuildSwapRequest)(nil),        // 18: aggregator.v1.BuildSwapRequest
+	(*Transaction)(nil),             // 19: aggregator.v1.Transaction
+	(*BuildSwapResponse)(nil),       // 20: aggregator.v1.BuildSwapResponse
+}
+var file_aggregator_v1_aggregator_proto_depIdxs = []int32{
+	2,  // 0: aggregator.v1.Token.behaviour:type_name -> aggregator.v1.Behaviour
+	3,  // 1: aggregator.v1.Token.risk:type_name -> aggregator.v1.Risk
+	1,  // 2: aggregator.v1.Route.amount_in:type_name -> aggregator.v1.Amount
+	1,  // 3: aggregator.v1.Route.amount_out:type_name -> aggregator.v1.Amount
+	4,  // 4: aggregator.v1.Quote.token_in:type_name -> aggregator.v1.Token
+	4,  // 5: aggregator.v1.Quote.token_out:type_name -> aggregator.v1.Token
+	1,  // 6: aggregator.v1.Quote.amount_in:type_name -> aggregator.v1.Amount
+	5,  // 7: aggregator.v1.Quote.best_route:type_name -> aggregator.v1.Route
+	5,  // 8: aggregator.v1.Quote.routes:type_name -> aggregator.v1.Route
+	3,  // 9: aggregator.v1.Quote.token_in_risk:type_name -> aggregator.v1.Risk
+	3,  // 10: aggregator.v1.Quote.token_out_risk:type_name -> aggregator.v1.Risk
+	6,  // 11: aggregator.v1.GetQuoteRequest.quote:type_name -> aggregator.v1.QuoteRequest
+	7,  // 12: aggregator.v1.GetQuoteResponse.quote:type_name -> aggregator.v1.Quote
+	6,  // 13: aggregator.v1.SubscribeQuotesRequest.quote:type_name -> aggregator.v1.QuoteRequest
+	0,  // 14: aggregator.v1.SubscribeQuotesRequest.updates:type_name -> aggregator.v1.Updates
+	7,  // 15: aggregator.v1.SubscribeQuotesResponse.quote:type_name -> aggregator.v1.Quote
+	11, // 16: aggregator.v1.SubscribeQuotesResponse.error:type_name -> aggregator.v1.Error
+	4,  // 17: aggregator.v1.GetTokenResponse.token:type_name -> aggregator.v1.Token
+	15, // 18: aggregator.v1.ListProtocolsResponse.protocols:type_name -> aggregator.v1.Protocol
+	5,  // 19: aggregator.v1.BuildSwapResponse.route:type_name -> aggregator.v1.Route
+	1,  // 20: aggregator.v1.BuildSwapResponse.amount_out_minimum:type_name -> aggregator.v1.Amount
+	19, // 21: aggregator.v1.BuildSwapResponse.transaction:type_name -> aggregator.v1.Transaction
+	3,  // 22: aggregator.v1.BuildSwapResponse.token_in_risk:type_name -> aggregator.v1.Risk
+	3,  // 23: aggregator.v1.BuildSwapResponse.token_out_risk:type_name -> aggregator.v1.Risk
+	8,  // 24: aggregator.v1.AggregatorService.GetQuote:input_type -> aggregator.v1.GetQuoteRequest
+	10, // 25: aggregator.v1.AggregatorService.SubscribeQuotes:input_type -> aggregator.v1.SubscribeQuotesRequest
+	13, // 26: aggregator.v1.AggregatorService.GetToken:input_type -> aggregator.v1.GetTokenRequest
+	16, // 27: aggregator.v1.AggregatorService.ListProtocols:input_type -> aggregator.v1.ListProtocolsRequest
+	18, // 28: aggregator.v1.AggregatorService.BuildSwap:input_type -> aggregator.v1.BuildSwapRequest
+	9,  // 29: aggregator.v1.AggregatorService.GetQuote:output_type -> aggregator.v1.GetQuoteResponse
+	12, // 30: aggregator.v1.AggregatorService.SubscribeQuotes:output_type -> aggregator.v1.SubscribeQuotesResponse
+	14, // 31: aggregator.v1.AggregatorService.GetToken:output_type -> aggregator.v1.GetTokenResponse
+	17, // 32: aggregator.v1.AggregatorService.ListProtocols:output_type -> aggregator.v1.ListProtocolsResponse
+	20, // 33: aggregator.v1.AggregatorService.BuildSwap:output_type -> aggregator.v1.BuildSwapResponse
+	29, // [29:34] is the sub-list for method output_type
+	24, // [24:29] is the sub-list for method input_type
+	24, // [24:24] is the sub-list for extension type_name
+	24, // [24:24] is the sub-list for extension extendee
+	0,  // [0:24] is the sub-list for field type_name
+}
+
+func init() { file_aggregator_v1_aggregator_proto_init() }
+func file_aggregator_v1_aggregator_proto_init() {
+	if File_aggregator_v1_aggregator_proto != nil {
+		return
+	}
+	file_aggregator_v1_aggregator_proto_msgTypes[11].OneofWrappers = []any{
+		(*SubscribeQuotesResponse_Quote)(nil),
+		(*SubscribeQuotesResponse_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_aggregator_v1_aggregator_proto_rawDesc), len(file_aggregator_v1_aggregator_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_aggregator_v1_aggregator_proto_goTypes,
+		DependencyIndexes: file_aggregator_v1_aggregator_proto_depIdxs,
+		EnumInfos:         file_aggregator_v1_aggregator_proto_enumTypes,
+		MessageInfos:      file_aggregator_v1_aggregator_proto_msgTypes,
+	}.Build()
+	File_aggregator_v1_aggregator_proto = out.File
+	file_aggregator_v1_aggregator_proto_goTypes = nil
+	file_aggregator_v1_aggregator_proto_depIdxs = nil
+}
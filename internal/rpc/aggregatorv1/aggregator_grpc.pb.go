@@ -0,0 +1,307 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: aggregator/v1/aggregator.proto
+
+package aggregatorv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AggregatorService_GetQuote_FullMethodName        = "/aggregator.v1.AggregatorService/GetQuote"
+	AggregatorService_SubscribeQuotes_FullMethodName = "/aggregator.v1.AggregatorService/SubscribeQuotes"
+	AggregatorService_GetToken_FullMethodName        = "/aggregator.v1.AggregatorService/GetToken"
+	AggregatorService_ListProtocols_FullMethodName   = "/aggregator.v1.AggregatorService/ListProtocols"
+	AggregatorService_BuildSwap_FullMethodName       = "/aggregator.v1.AggregatorService/BuildSwap"
+)
+
+// AggregatorServiceClient is the client API for AggregatorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AggregatorService quotes and builds swaps across the supported DEX protocols.
+// It is served alongside the HTTP API from the same aggregator.
+//
+// Failures carry a google.rpc.ErrorInfo detail whose reason is the HTTP API's
+// error code, e.g. "invalid_amount" or "no_route".
+type AggregatorServiceClient interface {
+	// GetQuote quotes every supported protocol and returns the routes ranked
+	// best first.
+	GetQuote(ctx context.Context, in *GetQuoteRequest, opts ...grpc.CallOption) (*GetQuoteResponse, error)
+	// SubscribeQuotes quotes a pair every new block, or only when the best
+	// route changes, until the client cancels. Failed quotes are sent as
+	// updates with an error instead of ending the stream.
+	SubscribeQuotes(ctx context.Context, in *SubscribeQuotesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeQuotesResponse], error)
+	// GetToken returns a token's metadata, reading it from the chain if it
+	// isn't cached.
+	GetToken(ctx context.Context, in *GetTokenRequest, opts ...grpc.CallOption) (*GetTokenResponse, error)
+	// ListProtocols returns the supported protocols, most preferred first.
+	ListProtocols(ctx context.Context, in *ListProtocolsRequest, opts ...grpc.CallOption) (*ListProtocolsResponse, error)
+	// BuildSwap builds an unsigned transaction for the best route.
+	BuildSwap(ctx context.Context, in *BuildSwapRequest, opts ...grpc.CallOption) (*BuildSwapResponse, error)
+}
+
+type aggregatorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAggregatorServiceClient(cc grpc.ClientConnInterface) AggregatorServiceClient {
+	return &aggregatorServiceClient{cc}
+}
+
+func (c *aggregatorServiceClient) GetQuote(ctx context.Context, in *GetQuoteRequest, opts ...grpc.CallOption) (*GetQuoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetQuoteResponse)
+	err := c.cc.Invoke(ctx, AggregatorService_GetQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aggregatorServiceClient) SubscribeQuotes(ctx context.Context, in *SubscribeQuotesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeQuotesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AggregatorService_ServiceDesc.Streams[0], AggregatorService_SubscribeQuotes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeQuotesRequest, SubscribeQuotesResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AggregatorService_SubscribeQuotesClient = grpc.ServerStreamingClient[SubscribeQuotesResponse]
+
+func (c *aggregatorServiceClient) GetToken(ctx context.Context, in *GetTokenRequest, opts ...grpc.CallOption) (*GetTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTokenResponse)
+	err := c.cc.Invoke(ctx, AggregatorService_GetToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aggregatorServiceClient) ListProtocols(ctx context.Context, in *ListProtocolsRequest, opts ...grpc.CallOption) (*ListProtocolsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListProtocolsResponse)
+	err := c.cc.Invoke(ctx, AggregatorService_ListProtocols_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aggregatorServiceClient) BuildSwap(ctx context.Context, in *BuildSwapRequest, opts ...grpc.CallOption) (*BuildSwapResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BuildSwapResponse)
+	err := c.cc.Invoke(ctx, AggregatorService_BuildSwap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AggregatorServiceServer is the server API for AggregatorService service.
+// All implementations must embed UnimplementedAggregatorServiceServer
+// for forward compatibility.
+//
+// AggregatorService quotes and builds swaps across the supported DEX protocols.
+// It is served alongside the HTTP API from the same aggregator.
+//
+// Failures carry a google.rpc.ErrorInfo detail whose reason is the HTTP API's
+// error code, e.g. "invalid_amount" or "no_route".
+type AggregatorServiceServer interface {
+	// GetQuote quotes every supported protocol and returns the routes ranked
+	// best first.
+	GetQuote(context.Context, *GetQuoteRequest) (*GetQuoteResponse, error)
+	// SubscribeQuotes quotes a pair every new block, or only when the best
+	// route changes, until the client cancels. Failed quotes are sent as
+	// updates with an error instead of ending the stream.
+	SubscribeQuotes(*SubscribeQuotesRequest, grpc.ServerStreamingServer[SubscribeQuotesResponse]) error
+	// GetToken returns a token's metadata, reading it from the chain if it
+	// isn't cached.
+	GetToken(context.Context, *GetTokenRequest) (*GetTokenResponse, error)
+	// ListProtocols returns the supported protocols, most preferred first.
+	ListProtocols(context.Context, *ListProtocolsRequest) (*ListProtocolsResponse, error)
+	// BuildSwap builds an unsigned transaction for the best route.
+	BuildSwap(context.Context, *BuildSwapRequest) (*BuildSwapResponse, error)
+	mustEmbedUnimplementedAggregatorServiceServer()
+}
+
+// UnimplementedAggregatorServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAggregatorServiceServer struct{}
+
+func (UnimplementedAggregatorServiceServer) GetQuote(context.Context, *GetQuoteRequest) (*GetQuoteResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetQuote not implemented")
+}
+func (UnimplementedAggregatorServiceServer) SubscribeQuotes(*SubscribeQuotesRequest, grpc.ServerStreamingServer[SubscribeQuotesResponse]) error {
+	return status.Error(codes.Unimplemented, "method SubscribeQuotes not implemented")
+}
+func (UnimplementedAggregatorServiceServer) GetToken(context.Context, *GetTokenRequest) (*GetTokenResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetToken not implemented")
+}
+func (UnimplementedAggregatorServiceServer) ListProtocols(context.Context, *ListProtocolsRequest) (*ListProtocolsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListProtocols not implemented")
+}
+func (UnimplementedAggregatorServiceServer) BuildSwap(context.Context, *BuildSwapRequest) (*BuildSwapResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method BuildSwap not implemented")
+}
+func (UnimplementedAggregatorServiceServer) mustEmbedUnimplementedAggregatorServiceServer() {}
+func (UnimplementedAggregatorServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeAggregatorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AggregatorServiceServer will
+// result in compilation errors.
+type UnsafeAggregatorServiceServer interface {
+	mustEmbedUnimplementedAggregatorServiceServer()
+}
+
+func RegisterAggregatorServiceServer(s grpc.ServiceRegistrar, srv AggregatorServiceServer) {
+	// If the following call panics, it indicates UnimplementedAggregatorServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AggregatorService_ServiceDesc, srv)
+}
+
+func _AggregatorService_GetQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AggregatorServiceServer).GetQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AggregatorService_GetQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AggregatorServiceServer).GetQuote(ctx, req.(*GetQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AggregatorService_SubscribeQuotes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeQuotesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AggregatorServiceServer).SubscribeQuotes(m, &grpc.GenericServerStream[SubscribeQuotesRequest, SubscribeQuotesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AggregatorService_SubscribeQuotesServer = grpc.ServerStreamingServer[SubscribeQuotesResponse]
+
+func _AggregatorService_GetToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AggregatorServiceServer).GetToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AggregatorService_GetToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AggregatorServiceServer).GetToken(ctx, req.(*GetTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AggregatorService_ListProtocols_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProtocolsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AggregatorServiceServer).ListProtocols(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AggregatorService_ListProtocols_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AggregatorServiceServer).ListProtocols(ctx, req.(*ListProtocolsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AggregatorService_BuildSwap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuildSwapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AggregatorServiceServer).BuildSwap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AggregatorService_BuildSwap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AggregatorServiceServer).BuildSwap(ctx, req.(*BuildSwapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AggregatorService_ServiceDesc is the grpc.ServiceDesc for AggregatorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AggregatorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "aggregator.v1.AggregatorService",
+	HandlerType: (*AggregatorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetQuote",
+			Handler:    _AggregatorService_GetQuote_Handler,
+		},
+		{
+			MethodName: "GetToken",
+			Handler:    _AggregatorService_GetToken_Handler,
+		},
+		{
+			MethodName: "ListProtocols",
+			Handler:    _AggregatorService_ListProtocols_Handler,
+		},
+		{
+			MethodName: "BuildSwap",
+			Handler:    _AggregatorService_BuildSwap_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeQuotes",
+			Handler:       _AggregatorService_SubscribeQuotes_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "aggregator/v1/aggregator.proto",
+}
@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/api"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/auth"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var logger = logging.For("rpc")
+
+// apiKeyHeader is the metadata key the API key is sent in, like the HTTP header
+const apiKeyHeader = "x-api-key"
+
+// reflectionPrefix is the service prefix of the reflection API, which when
+// enabled is served without a key so the service can be explored
+const reflectionPrefix = "/grpc.reflection."
+
+// authUnary rejects unary calls without an API key with the quote scope, or
+// over the key's rate limit
+func (s *Server) authUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authStream rejects streaming calls without an API key with the quote scope,
+// or over the key's rate limit
+func (s *Server) authStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize checks the API key in the call's metadata and takes the call from
+// its rate limit. Methods can be limited like HTTP endpoints by full name, e.g.
+// /aggregator.v1.AggregatorService/GetQuote.
+func (s *Server) authorize(ctx context.Context, method string) error {
+	if strings.HasPrefix(method, reflectionPrefix) {
+		return nil
+	}
+
+	var apiKey string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(apiKeyHeader); len(values) > 0 {
+			apiKey = values[0]
+		}
+	}
+
+	key, err := s.keys.Authenticate(ctx, apiKey)
+	if err != nil {
+		if auth.Rejected(err) {
+			return statusError(&api.Error{Code: api.CodeUnauthorized, Message: fmt.Sprintf("Unauthorized: %v", err)})
+		}
+		logger.ErrorContext(ctx, "Failed to check API key", "error", err)
+		return statusError(&api.Error{Code: api.CodeUnavailable, Message: "Failed to check API key"})
+	}
+	if !auth.Allows(key, auth.ScopeQuote) {
+		return statusError(&api.Error{Code: api.CodeForbidden, Message: fmt.Sprintf("API key doesn't have the %s scope", auth.ScopeQuote)})
+	}
+
+	result, err := s.limiter.AllowKey(ctx, key.ID, auth.ScopeQuote, method)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check rate limit", "key", key.ID, "error", err)
+	}
+	field := method
+	if !result.Allowed {
+		field = ratelimit.LimitedField
+	}
+	if err := s.limiter.Record(ctx, key.ID, field); err != nil {
+		logger.ErrorContext(ctx, "Failed to record usage", "key", key.ID, "error", err)
+	}
+	if !result.Allowed {
+		retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+		return statusError(&api.Error{Code: api.CodeLimitExceeded, Message: fmt.Sprintf("Rate limit exceeded, retry in %ds", retryAfter)})
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/auth"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/ratelimit"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const getQuoteMethod = "/aggregator.v1.AggregatorService/GetQuote"
+
+// issueKey stores a key with scopes, returning the key to send
+func issueKey(t *testing.T, store *storage.MemoryStore, scopes []string, ttl time.Duration) string {
+	t.Helper()
+	plaintext, key, err := auth.Generate("test", scopes, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveAPIKey(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+	return plaintext
+}
+
+func TestAuthorize(t *testing.T) {
+	store := storage.NewMemoryStore()
+	quoteKey := issueKey(t, store, []string{auth.ScopeQuote}, 0)
+	adminKey := issueKey(t, store, []string{auth.ScopeAdmin}, 0)
+	tokenKey := issueKey(t, store, []string{auth.ScopeTokenWrite}, 0)
+	expiredKey := issueKey(t, store, []string{auth.ScopeQuote}, time.Nanosecond) // Expiry is in whole seconds
+
+	s := NewServer(Backend{}, nil, auth.NewAuthenticator(store, "root-key"), ratelimit.New(store, ratelimit.Limits{}, 0))
+
+	tests := []struct {
+		name   string
+		method string
+		key    string
+		want   codes.Code
+	}{
+		{"quote scope", getQuoteMethod, quoteKey, codes.OK},
+		{"admin scope", getQuoteMethod, adminKey, codes.OK},
+		{"root key", getQuoteMethod, "root-key", codes.OK},
+		{"missing key", getQuoteMethod, "", codes.Unauthenticated},
+		{"invalid key", getQuoteMethod, "not-a-key", codes.Unauthenticated},
+		{"unknown key", getQuoteMethod, "dfa_0123", codes.Unauthenticated},
+		{"expired key", getQuoteMethod, expiredKey, codes.Unauthenticated},
+		{"forbidden key", getQuoteMethod, tokenKey, codes.PermissionDenied},
+		{"reflection without a key", "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", "", codes.OK},
+		{"reflection-like method", "/grpc.reflectionx.Service/Method", "", codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.key != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(apiKeyHeader, tt.key))
+			}
+			if got := status.Code(s.authorize(ctx, tt.method)); got != tt.want {
+				t.Errorf("authorize = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeRateLimit(t *testing.T) {
+	store := storage.NewMemoryStore()
+	key := issueKey(t, store, []string{auth.ScopeQuote}, 0)
+	limits := ratelimit.Limits{auth.ScopeQuote: {Rate: 0.001, Burst: 1}}
+	s := NewServer(Backend{}, nil, auth.NewAuthenticator(store, ""), ratelimit.New(store, limits, 0))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(apiKeyHeader, key))
+	if err := s.authorize(ctx, getQuoteMethod); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if got := status.Code(s.authorize(ctx, getQuoteMethod)); got != codes.ResourceExhausted {
+		t.Errorf("second call = %v, want %v", got, codes.ResourceExhausted)
+	}
+}
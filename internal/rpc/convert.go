@@ -0,0 +1,139 @@
+package rpc
+
+import (
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/api"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/risk"
+	pb "github.com/bitcoinbrisbane/defi-aggregator/internal/rpc/aggregatorv1"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/utils"
+)
+
+// quoteRequest converts a quote request to the API's
+func quoteRequest(req *pb.QuoteRequest) api.QuoteRequest {
+	return api.QuoteRequest{
+		TokenIn:     req.GetTokenIn(),
+		TokenOut:    req.GetTokenOut(),
+		Amount:      req.GetAmount(),
+		AmountHuman: req.GetAmountHuman(),
+		Rank:        req.GetRank(),
+		Limit:       int(req.GetLimit()),
+	}
+}
+
+// quote converts an API quote
+func quote(response *api.QuoteResponse) *pb.Quote {
+	routes := make([]*pb.Route, len(response.Routes))
+	for i, r := range response.Routes {
+		routes[i] = route(r)
+	}
+
+	return &pb.Quote{
+		TokenIn:      apiToken(response.TokenIn),
+		TokenOut:     apiToken(response.TokenOut),
+		AmountIn:     amount(response.AmountIn),
+		Approximate:  response.Approximate,
+		BestRoute:    route(response.BestRoute),
+		Routes:       routes,
+		TokenInRisk:  riskReport(response.Risk.TokenIn),
+		TokenOutRisk: riskReport(response.Risk.TokenOut),
+	}
+}
+
+// streamResponse converts a stream message to a subscription update
+func streamResponse(msg api.StreamMessage) *pb.SubscribeQuotesResponse {
+	response := &pb.SubscribeQuotesResponse{Block: msg.Block}
+	if msg.Error != nil {
+		response.Result = &pb.SubscribeQuotesResponse_Error{Error: &pb.Error{Code: msg.Error.Code, Message: msg.Error.Message}}
+	} else if msg.Quote != nil {
+		response.Result = &pb.SubscribeQuotesResponse_Quote{Quote: quote(msg.Quote)}
+	}
+	return response
+}
+
+// route converts a route quote
+func route(r aggregator.RouteQuote) *pb.Route {
+	return &pb.Route{
+		Protocol:     r.Protocol,
+		PoolAddress:  r.PoolAddress,
+		Fee:          r.Fee,
+		TokenIn:      r.TokenIn,
+		TokenOut:     r.TokenOut,
+		AmountIn:     amount(r.AmountIn),
+		AmountOut:    amount(r.AmountOut),
+		Gas:          r.Gas,
+		Router:       r.Router,
+		WrapNative:   r.WrapNative,
+		UnwrapNative: r.UnwrapNative,
+		Warnings:     r.Warnings,
+	}
+}
+
+// amount converts an exact amount
+func amount(a utils.Amount) *pb.Amount {
+	return &pb.Amount{Raw: a.Raw().String(), Display: a.String(), Decimals: uint32(a.Decimals())}
+}
+
+// apiToken converts the token summary of a quote
+func apiToken(t api.Token) *pb.Token {
+	return &pb.Token{
+		Address:  t.Address,
+		Name:     t.Name,
+		Symbol:   t.Symbol,
+		Decimals: uint32(t.Decimals),
+		LogoUri:  t.LogoURI,
+	}
+}
+
+// token converts token metadata, including its screening
+func token(metadata *models.TokenMetadata) *pb.Token {
+	return &pb.Token{
+		Address:   metadata.Address,
+		Name:      metadata.Name,
+		Symbol:    metadata.Symbol,
+		Decimals:  uint32(metadata.Decimals),
+		LogoUri:   metadata.LogoURI,
+		Behaviour: behaviour(metadata.Behaviour),
+		Risk:      riskReport(metadata.Risk),
+		Inferred:  metadata.Inferred,
+		Tags:      metadata.Tags,
+		Warnings:  metadata.Warnings,
+		Curated:   metadata.Curated,
+	}
+}
+
+// behaviour converts a transfer probe, nil if the token wasn't probed
+func behaviour(b *tokens.Behaviour) *pb.Behaviour {
+	if b == nil {
+		return nil
+	}
+	return &pb.Behaviour{
+		FeeOnTransfer:  b.FeeOnTransfer,
+		TransferFeeBps: b.TransferFeeBps,
+		Rebasing:       b.Rebasing,
+		ProbedAt:       b.ProbedAt,
+	}
+}
+
+// riskReport converts a risk screening, nil if the token wasn't screened
+func riskReport(r *risk.Report) *pb.Risk {
+	if r == nil {
+		return nil
+	}
+	return &pb.Risk{
+		Score:          int32(r.Score),
+		Level:          r.Level,
+		Reasons:        r.Reasons,
+		BuyBlocked:     r.BuyBlocked,
+		SellBlocked:    r.SellBlocked,
+		BuyTaxBps:      r.BuyTaxBps,
+		SellTaxBps:     r.SellTaxBps,
+		Blacklist:      r.Blacklist,
+		Upgradeable:    r.Upgradeable,
+		Owner:          r.Owner,
+		OwnerSupplyBps: r.OwnerSupplyBps,
+		ScreenedAt:     r.ScreenedAt,
+		Pool:           r.Pool,
+	}
+}
@@ -0,0 +1,132 @@
+package rpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/api"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/risk"
+	pb "github.com/bitcoinbrisbane/defi-aggregator/internal/rpc/aggregatorv1"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/utils"
+)
+
+func TestQuoteRequest(t *testing.T) {
+	got := quoteRequest(&pb.QuoteRequest{
+		TokenIn:     "0x01",
+		TokenOut:    "0x02",
+		Amount:      "1000",
+		AmountHuman: "0.001",
+		Rank:        "gas",
+		Limit:       3,
+	})
+	want := api.QuoteRequest{TokenIn: "0x01", TokenOut: "0x02", Amount: "1000", AmountHuman: "0.001", Rank: "gas", Limit: 3}
+	if got != want {
+		t.Errorf("quoteRequest = %+v, want %+v", got, want)
+	}
+
+	// Unset fields are zero
+	if got := quoteRequest(nil); got != (api.QuoteRequest{}) {
+		t.Errorf("quoteRequest(nil) = %+v, want the zero request", got)
+	}
+}
+
+func TestAmount(t *testing.T) {
+	tests := []struct {
+		amount utils.Amount
+		want   *pb.Amount
+	}{
+		{utils.NewAmount(big.NewInt(1500000), 6), &pb.Amount{Raw: "1500000", Display: "1.5", Decimals: 6}},
+		{utils.NewAmount(big.NewInt(42), 0), &pb.Amount{Raw: "42", Display: "42", Decimals: 0}},
+		{utils.Amount{}, &pb.Amount{Raw: "0", Display: "0", Decimals: 0}},
+	}
+
+	for _, tt := range tests {
+		got := amount(tt.amount)
+		if got.Raw != tt.want.Raw || got.Display != tt.want.Display || got.Decimals != tt.want.Decimals {
+			t.Errorf("amount(%v) = %v, want %v", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	best := aggregator.RouteQuote{
+		Protocol:  "uniswap_v3",
+		Fee:       3000,
+		AmountIn:  utils.NewAmount(big.NewInt(1000), 6),
+		AmountOut: utils.NewAmount(big.NewInt(2000), 6),
+		Warnings:  []string{"low liquidity"},
+	}
+	response := &api.QuoteResponse{
+		TokenIn:   api.Token{Address: "0x01", Symbol: "IN", Decimals: 6},
+		TokenOut:  api.Token{Address: "0x02", Symbol: "OUT", Decimals: 6},
+		AmountIn:  utils.NewAmount(big.NewInt(1000), 6),
+		BestRoute: best,
+		Routes:    []aggregator.RouteQuote{best, {Protocol: "sushiswap"}},
+		Risk:      api.Risk{TokenOut: &risk.Report{Score: 70, Level: "high", Reasons: []string{"sell tax"}}},
+	}
+
+	got := quote(response)
+	if got.TokenIn.Symbol != "IN" || got.TokenOut.Decimals != 6 || got.AmountIn.Raw != "1000" {
+		t.Errorf("quote tokens and amount = %v %v %v", got.TokenIn, got.TokenOut, got.AmountIn)
+	}
+	if got.BestRoute.Protocol != "uniswap_v3" || got.BestRoute.Fee != 3000 || got.BestRoute.AmountOut.Display != "0.002" {
+		t.Errorf("best route = %v", got.BestRoute)
+	}
+	if len(got.BestRoute.Warnings) != 1 || got.BestRoute.Warnings[0] != "low liquidity" {
+		t.Errorf("best route warnings = %v, want [low liquidity]", got.BestRoute.Warnings)
+	}
+	if len(got.Routes) != 2 || got.Routes[1].Protocol != "sushiswap" {
+		t.Errorf("routes = %v, want uniswap_v3 and sushiswap", got.Routes)
+	}
+	if got.TokenInRisk != nil {
+		t.Errorf("tokenIn risk = %v, want nil for an unscreened token", got.TokenInRisk)
+	}
+	if got.TokenOutRisk == nil || got.TokenOutRisk.Score != 70 || got.TokenOutRisk.Level != "high" {
+		t.Errorf("tokenOut risk = %v, want score 70 high", got.TokenOutRisk)
+	}
+}
+
+func TestStreamResponse(t *testing.T) {
+	quoted := streamResponse(api.StreamMessage{Type: api.StreamQuote, Block: 7, Quote: &api.QuoteResponse{}})
+	if quoted.Block != 7 || quoted.GetQuote() == nil || quoted.GetError() != nil {
+		t.Errorf("quote message = %v, want a block 7 quote", quoted)
+	}
+
+	failed := streamResponse(api.StreamMessage{Type: api.StreamError, Block: 8, Error: &api.Error{Code: api.CodeNoRoute, Message: "No route found"}})
+	if failed.Block != 8 || failed.GetQuote() != nil || failed.GetError().GetCode() != api.CodeNoRoute {
+		t.Errorf("error message = %v, want a block 8 %s error", failed, api.CodeNoRoute)
+	}
+
+	if empty := streamResponse(api.StreamMessage{Block: 9}); empty.Result != nil {
+		t.Errorf("empty message = %v, want no result", empty)
+	}
+}
+
+func TestToken(t *testing.T) {
+	metadata := &models.TokenMetadata{
+		Address:   "0x01",
+		Symbol:    "FEE",
+		Decimals:  18,
+		Behaviour: &tokens.Behaviour{FeeOnTransfer: true, TransferFeeBps: 200},
+		Curated:   true,
+	}
+
+	got := token(metadata)
+	if got.Address != "0x01" || got.Symbol != "FEE" || got.Decimals != 18 || !got.Curated {
+		t.Errorf("token = %v", got)
+	}
+	if got.Behaviour == nil || !got.Behaviour.FeeOnTransfer || got.Behaviour.TransferFeeBps != 200 {
+		t.Errorf("behaviour = %v, want a 200 bps fee on transfer", got.Behaviour)
+	}
+	if got.Risk != nil {
+		t.Errorf("risk = %v, want nil for an unscreened token", got.Risk)
+	}
+
+	// Unprobed tokens have no behaviour
+	if got := token(&models.TokenMetadata{Address: "0x02"}); got.Behaviour != nil {
+		t.Errorf("behaviour = %v, want nil for an unprobed token", got.Behaviour)
+	}
+}
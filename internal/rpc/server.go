@@ -0,0 +1,231 @@
+// Package rpc serves the aggregator over gRPC. Messages are defined in
+// proto/aggregator/v1 and generated into aggregatorv1 with buf generate.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/api"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/auth"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/ratelimit"
+	pb "github.com/bitcoinbrisbane/defi-aggregator/internal/rpc/aggregatorv1"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/stream"
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// requestTimeout bounds each unary call, like the HTTP handlers
+const requestTimeout = 15 * time.Second
+
+// errorDomain is the domain of the ErrorInfo attached to failures
+const errorDomain = "defi-aggregator"
+
+// TokenFunc looks up a token's metadata, returning where it came from
+type TokenFunc func(ctx context.Context, address string) (*models.TokenMetadata, string, *api.Error)
+
+// SwapFunc builds the transaction of the best route
+type SwapFunc func(ctx context.Context, req api.SwapRequest) (*api.SwapResponse, *api.Error)
+
+// Backend is how the server quotes, looks up tokens and builds swaps. The
+// functions are shared with the HTTP API so both validate requests the same way.
+type Backend struct {
+	Quote stream.QuoteFunc
+	Token TokenFunc
+	Swap  SwapFunc
+}
+
+// Server implements the AggregatorService
+type Server struct {
+	pb.UnimplementedAggregatorServiceServer
+
+	backend Backend
+	streams *stream.Service
+	keys    *auth.Authenticator
+	limiter *ratelimit.Limiter
+
+	reflection bool // Serve the reflection API, see EnableReflection
+}
+
+// NewServer creates a gRPC server, quote subscriptions are served by streams.
+// Every call needs an API key with the quote scope, checked by keys, and is
+// limited and metered by limiter.
+func NewServer(backend Backend, streams *stream.Service, keys *auth.Authenticator, limiter *ratelimit.Limiter) *Server {
+	return &Server{backend: backend, streams: streams, keys: keys, limiter: limiter}
+}
+
+// EnableReflection also serves the reflection API, which lists the services
+// and their messages to any caller without a key
+func (s *Server) EnableReflection() {
+	s.reflection = true
+}
+
+// ListenAndServe serves the AggregatorService, and reflection if enabled, on addr
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", addr, err)
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(traceUnary, s.authUnary),
+		grpc.ChainStreamInterceptor(traceStream, s.authStream),
+	)
+	pb.RegisterAggregatorServiceServer(server, s)
+	if s.reflection {
+		reflection.Register(server)
+	}
+	return server.Serve(listener)
+}
+
+// GetQuote quotes every supported protocol and returns the ranked routes
+func (s *Server) GetQuote(ctx context.Context, req *pb.GetQuoteRequest) (*pb.GetQuoteResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	response, quoteErr := s.backend.Quote(ctx, quoteRequest(req.GetQuote()))
+	if quoteErr != nil {
+		return nil, statusError(quoteErr)
+	}
+	return &pb.GetQuoteResponse{Quote: quote(response)}, nil
+}
+
+// SubscribeQuotes sends a quote every new block until the client cancels
+func (s *Server) SubscribeQuotes(req *pb.SubscribeQuotesRequest, srv grpc.ServerStreamingServer[pb.SubscribeQuotesResponse]) error {
+	// Bad addresses would fail every block, so they end the call instead
+	quoteReq := quoteRequest(req.GetQuote())
+	if !common.IsHexAddress(quoteReq.TokenIn) || !common.IsHexAddress(quoteReq.TokenOut) {
+		return statusError(&api.Error{Code: api.CodeInvalidRequest, Message: "Invalid Ethereum address format"})
+	}
+
+	updates := api.UpdatesBlock
+	if req.GetUpdates() == pb.Updates_UPDATES_CHANGE {
+		updates = api.UpdatesChange
+	}
+
+	ctx, cancel := context.WithCancel(srv.Context())
+	defer cancel()
+
+	var sendErr error
+	err := s.streams.Watch(ctx, quoteReq, updates, func(msg api.StreamMessage) {
+		if err := srv.Send(streamResponse(msg)); err != nil {
+			sendErr = err
+			cancel()
+		}
+	})
+	if errors.Is(err, stream.ErrTooManyConnections) {
+		return statusError(&api.Error{Code: api.CodeUnavailable, Message: "Too many streaming connections"})
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	return status.FromContextError(srv.Context().Err()).Err()
+}
+
+// GetToken returns a token's metadata
+func (s *Server) GetToken(ctx context.Context, req *pb.GetTokenRequest) (*pb.GetTokenResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	metadata, source, tokenErr := s.backend.Token(ctx, req.GetAddress())
+	if tokenErr != nil {
+		return nil, statusError(tokenErr)
+	}
+	return &pb.GetTokenResponse{Token: token(metadata), Source: source}, nil
+}
+
+// ListProtocols returns the supported protocols, most preferred first
+func (s *Server) ListProtocols(ctx context.Context, req *pb.ListProtocolsRequest) (*pb.ListProtocolsResponse, error) {
+	list := make([]*pb.Protocol, 0, len(protocols.Protocols))
+	for id, config := range protocols.Protocols {
+		list = append(list, &pb.Protocol{
+			Id:                id,
+			Name:              config.Name,
+			FactoryAddress:    config.FactoryAddress.String(),
+			RouterAddress:     config.RouterAddress.String(),
+			FeeTiers:          config.FeeTiers,
+			UniswapFork:       config.IsUniswapFork,
+			SwapGas:           config.SwapGas,
+			Priority:          int32(config.Priority),
+			SwapRouterAddress: config.SwapRouter(),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Priority != list[j].Priority {
+			return list[i].Priority < list[j].Priority
+		}
+		return list[i].Id < list[j].Id
+	})
+	return &pb.ListProtocolsResponse{Protocols: list}, nil
+}
+
+// BuildSwap builds an unsigned transaction for the best route
+func (s *Server) BuildSwap(ctx context.Context, req *pb.BuildSwapRequest) (*pb.BuildSwapResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	response, swapErr := s.backend.Swap(ctx, api.SwapRequest{
+		TokenIn:     req.GetTokenIn(),
+		TokenOut:    req.GetTokenOut(),
+		Amount:      req.GetAmount(),
+		Recipient:   req.GetRecipient(),
+		SlippageBps: uint64(req.GetSlippageBps()),
+		Rank:        req.GetRank(),
+	})
+	if swapErr != nil {
+		return nil, statusError(swapErr)
+	}
+
+	return &pb.BuildSwapResponse{
+		Route:            route(response.Route),
+		AmountOutMinimum: amount(response.AmountOutMinimum),
+		Transaction: &pb.Transaction{
+			To:    response.Transaction.To,
+			Data:  response.Transaction.Data,
+			Value: response.Transaction.Value,
+		},
+		TokenInRisk:  riskReport(response.Risk.TokenIn),
+		TokenOutRisk: riskReport(response.Risk.TokenOut),
+	}, nil
+}
+
+// statusError converts an API error to a gRPC status, keeping the API's error
+// code as the reason of an ErrorInfo detail
+func statusError(e *api.Error) error {
+	st := status.New(statusCode(e.Code), e.Message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: e.Code, Domain: errorDomain}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// statusCode is the gRPC code for an API error code
+func statusCode(code string) codes.Code {
+	switch code {
+	case api.CodeInvalidRequest, api.CodeInvalidAmount, api.CodeNotAToken:
+		return codes.InvalidArgument
+	case api.CodeNoRoute:
+		return codes.NotFound
+	case api.CodeUpstream, api.CodeUnavailable:
+		return codes.Unavailable
+	case api.CodeLimitExceeded:
+		return codes.ResourceExhausted
+	case api.CodeTimeout:
+		return codes.DeadlineExceeded
+	case api.CodeUnauthorized:
+		return codes.Unauthenticated
+	case api.CodeForbidden:
+		return codes.PermissionDenied
+	}
+	return codes.Internal
+}
@@ -0,0 +1,54 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/api"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want codes.Code
+	}{
+		{api.CodeInvalidRequest, codes.InvalidArgument},
+		{api.CodeInvalidAmount, codes.InvalidArgument},
+		{api.CodeNotAToken, codes.InvalidArgument},
+		{api.CodeNoRoute, codes.NotFound},
+		{api.CodeUpstream, codes.Unavailable},
+		{api.CodeUnavailable, codes.Unavailable},
+		{api.CodeLimitExceeded, codes.ResourceExhausted},
+		{api.CodeTimeout, codes.DeadlineExceeded},
+		{api.CodeUnauthorized, codes.Unauthenticated},
+		{api.CodeForbidden, codes.PermissionDenied},
+		{api.CodeInternal, codes.Internal},
+		{"unknown", codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := statusCode(tt.code); got != tt.want {
+				t.Errorf("statusCode(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	st := status.Convert(statusError(&api.Error{Code: api.CodeNoRoute, Message: "No route found"}))
+	if st.Code() != codes.NotFound || st.Message() != "No route found" {
+		t.Errorf("status = %v %q, want NotFound \"No route found\"", st.Code(), st.Message())
+	}
+
+	details := st.Details()
+	if len(details) != 1 {
+		t.Fatalf("details = %v, want an ErrorInfo", details)
+	}
+	info, ok := details[0].(*errdetails.ErrorInfo)
+	if !ok || info.Reason != api.CodeNoRoute || info.Domain != errorDomain {
+		t.Errorf("details = %v, want reason %s in %s", details[0], api.CodeNoRoute, errorDomain)
+	}
+}
@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// traceIDHeader is the metadata key the trace ID of a call is returned in
+const traceIDHeader = "x-trace-id"
+
+// requestIDHeader is the metadata key of the request ID, taken from the
+// client if it sent one and returned like the trace ID
+const requestIDHeader = "x-request-id"
+
+// maxRequestID is the longest request ID accepted from a client
+const maxRequestID = 128
+
+// metadataCarrier reads trace context propagated in incoming metadata
+type metadataCarrier metadata.MD
+
+// Get returns the first value of key
+func (m metadataCarrier) Get(key string) string {
+	values := metadata.MD(m).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set sets key to value
+func (m metadataCarrier) Set(key, value string) {
+	metadata.MD(m).Set(key, value)
+}
+
+// Keys returns the keys in the metadata
+func (m metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// startCall starts the server span of a call, continuing the client's trace
+// if it sent a traceparent, and tags the call with a request ID for the logs.
+// Both IDs are returned in the header.
+func startCall(ctx context.Context, method string) (context.Context, trace.Span) {
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+		requestID = metadataCarrier(md).Get(requestIDHeader)
+	}
+	if requestID == "" || len(requestID) > maxRequestID {
+		requestID = logging.NewRequestID()
+	}
+	ctx = logging.WithRequestID(ctx, requestID)
+
+	ctx, span := tracing.Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", method),
+		))
+	grpc.SetHeader(ctx, metadata.Pairs(traceIDHeader, tracing.TraceID(ctx), requestIDHeader, requestID))
+	return ctx, span
+}
+
+// endCall ends the span of a call with its status code
+func endCall(span trace.Span, err error) {
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+	tracing.End(span, err)
+}
+
+// traceUnary gives each unary call a span
+func traceUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, span := startCall(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	endCall(span, err)
+	return resp, err
+}
+
+// traceStream gives each streaming call a span, lasting as long as the stream
+func traceStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startCall(ss.Context(), info.FullMethod)
+	err := handler(srv, &tracedStream{ServerStream: ss, ctx: ctx})
+	endCall(span, err)
+	return err
+}
+
+// tracedStream is a server stream carrying the context of its span
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the span
+func (s *tracedStream) Context() context.Context {
+	return s.ctx
+}
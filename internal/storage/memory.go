@@ -0,0 +1,333 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MemoryStore is a Store kept in process memory, for running the service and
+// its tests without Redis. Nothing is persisted across restarts.
+type MemoryStore struct {
+	mu            sync.RWMutex
+	tokens        map[string]entry[models.TokenMetadata]
+	tokenMisses   map[string]entry[string]
+	registry      map[string]bool
+	protocolPairs map[string][]pairs.ProtocolPair
+	quotes        map[string]entry[[]byte]
+	apiKeys       map[string]models.APIKey
+	buckets       map[string]entry[bucket]
+	usage         map[string]entry[map[string]int64]
+}
+
+// bucket is the state of a token bucket when it was last taken from
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// pruneQuotesAt is the number of stored quotes at which expired ones are removed
+const pruneQuotesAt = 10000
+
+// pruneBucketsAt is the number of rate limit buckets at which full ones are removed
+const pruneBucketsAt = 10000
+
+// entry is a stored value and when it expires
+type entry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+// newEntry creates an entry that expires after ttl, or never if ttl is 0
+func newEntry[T any](value T, ttl time.Duration) entry[T] {
+	e := entry[T]{value: value}
+	if ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+	return e
+}
+
+// expired reports whether the entry's ttl has passed
+func (e entry[T]) expired() bool {
+	return !e.expiresAt.IsZero() && time.Now().After(e.expiresAt)
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tokens:        make(map[string]entry[models.TokenMetadata]),
+		tokenMisses:   make(map[string]entry[string]),
+		registry:      make(map[string]bool),
+		protocolPairs: make(map[string][]pairs.ProtocolPair),
+		quotes:        make(map[string]entry[[]byte]),
+		apiKeys:       make(map[string]models.APIKey),
+		buckets:       make(map[string]entry[bucket]),
+		usage:         make(map[string]entry[map[string]int64]),
+	}
+}
+
+// Close does nothing, the store is garbage collected
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// GetToken retrieves token metadata
+func (s *MemoryStore) GetToken(ctx context.Context, address common.Address) (*models.TokenMetadata, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	token, ok := s.tokens[address.String()]
+	if !ok || token.expired() {
+		return nil, nil
+	}
+	return &token.value, nil
+}
+
+// GetTokens retrieves the metadata of many tokens
+func (s *MemoryStore) GetTokens(ctx context.Context, addresses []common.Address) ([]*models.TokenMetadata, error) {
+	found := make([]*models.TokenMetadata, len(addresses))
+	for i, address := range addresses {
+		found[i], _ = s.GetToken(ctx, address)
+	}
+	return found, nil
+}
+
+// SaveToken saves token metadata
+func (s *MemoryStore) SaveToken(ctx context.Context, token models.TokenMetadata, ttl time.Duration) error {
+	return s.SaveTokens(ctx, []models.TokenMetadata{token}, ttl)
+}
+
+// SaveTokens saves the metadata of many tokens
+func (s *MemoryStore) SaveTokens(ctx context.Context, tokens []models.TokenMetadata, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range tokens {
+		s.tokens[token.Address] = newEntry(token, ttl)
+		delete(s.tokenMisses, token.Address)
+	}
+	return nil
+}
+
+// DeleteToken removes the metadata of a token, or that it isn't a token.
+// Registry membership is kept.
+func (s *MemoryStore) DeleteToken(ctx context.Context, address common.Address) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, address.String())
+	delete(s.tokenMisses, address.String())
+	return nil
+}
+
+// GetTokenMisses retrieves why addresses aren't tokens
+func (s *MemoryStore) GetTokenMisses(ctx context.Context, addresses []common.Address) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reasons := make([]string, len(addresses))
+	for i, address := range addresses {
+		if miss, ok := s.tokenMisses[address.String()]; ok && !miss.expired() {
+			reasons[i] = miss.value
+		}
+	}
+	return reasons, nil
+}
+
+// SaveTokenMiss saves why an address isn't a token until ttl passes
+func (s *MemoryStore) SaveTokenMiss(ctx context.Context, address common.Address, reason string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokenMisses[address.String()] = newEntry(reason, ttl)
+	return nil
+}
+
+// SaveRegistryTokens saves tokens and adds them to the registry
+func (s *MemoryStore) SaveRegistryTokens(ctx context.Context, tokens []models.TokenMetadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range tokens {
+		s.tokens[token.Address] = newEntry(token, 0)
+		delete(s.tokenMisses, token.Address)
+		s.registry[token.Address] = true
+	}
+	return nil
+}
+
+// GetRegistryTokens retrieves every token in the registry, ordered by address
+func (s *MemoryStore) GetRegistryTokens(ctx context.Context) ([]models.TokenMetadata, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	registry := make([]models.TokenMetadata, 0, len(s.registry))
+	for address := range s.registry {
+		if token, ok := s.tokens[address]; ok && !token.expired() {
+			registry = append(registry, token.value)
+		}
+	}
+	sort.Slice(registry, func(i, j int) bool { return registry[i].Address < registry[j].Address })
+	return registry, nil
+}
+
+// AddProtocolPair adds a protocol pair to the set for its token pair
+func (s *MemoryStore) AddProtocolPair(ctx context.Context, pairKey string, pair pairs.ProtocolPair) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.protocolPairs[pairKey] {
+		if existing.ProtocolName == pair.ProtocolName && existing.ContractAddress == pair.ContractAddress {
+			return nil
+		}
+	}
+	s.protocolPairs[pairKey] = append(s.protocolPairs[pairKey], pair)
+	return nil
+}
+
+// GetProtocolPairs retrieves the protocol pairs of a token pair
+func (s *MemoryStore) GetProtocolPairs(ctx context.Context, pairKey string) ([]pairs.ProtocolPair, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]pairs.ProtocolPair(nil), s.protocolPairs[pairKey]...), nil
+}
+
+// GetAllProtocolPairs retrieves the protocol pairs of every token pair
+func (s *MemoryStore) GetAllProtocolPairs(ctx context.Context) (map[string][]pairs.ProtocolPair, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all := make(map[string][]pairs.ProtocolPair, len(s.protocolPairs))
+	for pairKey, protocolPairs := range s.protocolPairs {
+		all[pairKey] = append([]pairs.ProtocolPair(nil), protocolPairs...)
+	}
+	return all, nil
+}
+
+// GetQuote retrieves an encoded quote unless it has expired
+func (s *MemoryStore) GetQuote(ctx context.Context, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	quote, ok := s.quotes[key]
+	if !ok || quote.expired() {
+		return nil, nil
+	}
+	return quote.value, nil
+}
+
+// SaveQuote saves an encoded quote until ttl passes
+func (s *MemoryStore) SaveQuote(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop expired quotes once the map grows, nothing else removes them
+	if len(s.quotes) >= pruneQuotesAt {
+		for k, quote := range s.quotes {
+			if quote.expired() {
+				delete(s.quotes, k)
+			}
+		}
+	}
+
+	s.quotes[key] = newEntry(data, ttl)
+	return nil
+}
+
+// GetAPIKey retrieves an API key
+func (s *MemoryStore) GetAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+// SaveAPIKey saves an API key
+func (s *MemoryStore) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiKeys[key.ID] = key
+	return nil
+}
+
+// ListAPIKeys retrieves every issued API key
+func (s *MemoryStore) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]models.APIKey, 0, len(s.apiKeys))
+	for _, key := range s.apiKeys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// TakeToken takes a token from a bucket
+func (s *MemoryStore) TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Buckets expire once they'd be full again, so expired ones can be dropped
+	if len(s.buckets) >= pruneBucketsAt {
+		for k, b := range s.buckets {
+			if b.expired() {
+				delete(s.buckets, k)
+			}
+		}
+	}
+
+	tokens := float64(burst)
+	if b, ok := s.buckets[key]; ok && !b.expired() {
+		elapsed := max(now.Sub(b.value.at).Seconds(), 0)
+		tokens = min(float64(burst), b.value.tokens+elapsed*rate)
+	}
+
+	taken := tokens >= 1
+	if taken {
+		tokens--
+	}
+	s.buckets[key] = newEntry(bucket{tokens: tokens, at: now}, bucketTTL(rate, burst))
+	return taken, tokens, nil
+}
+
+// IncrementUsage increments a usage counter
+func (s *MemoryStore) IncrementUsage(ctx context.Context, keyID, day, field string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := usageKey(keyID, day)
+	counters, ok := s.usage[key]
+	if !ok || counters.expired() {
+		counters = newEntry(make(map[string]int64), ttl)
+		s.usage[key] = counters
+	}
+	counters.value[field]++
+	return nil
+}
+
+// GetUsage retrieves an API key's usage counters for a day
+func (s *MemoryStore) GetUsage(ctx context.Context, keyID, day string) (map[string]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usage := make(map[string]int64)
+	counters, ok := s.usage[usageKey(keyID, day)]
+	if !ok || counters.expired() {
+		return usage, nil
+	}
+	for field, count := range counters.value {
+		usage[field] = count
+	}
+	return usage, nil
+}
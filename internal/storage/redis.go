@@ -0,0 +1,495 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-redis/redis/v8"
+)
+
+var logger = logging.For("storage")
+
+// tokenRegistryKey is the Redis set of addresses imported from token lists
+const tokenRegistryKey = "tokens:registry"
+
+// pairIndexKey is the Redis set of pair keys that have pools
+const pairIndexKey = "pairs:index"
+
+// pairsMigratedKey marks that pools stored under bare pair keys, before pools
+// were indexed, have been moved to their pairSetKey
+const pairsMigratedKey = "pairs:migrated"
+
+// legacyPairPattern matches the bare pair keys pools used to be stored under
+const legacyPairPattern = "0x*-0x*"
+
+// apiKeyIndexKey is the Redis set of issued API key IDs
+const apiKeyIndexKey = "apikeys:index"
+
+// takeTokenScript refills and takes from a token bucket atomically, so
+// instances sharing Redis share the limit. Tokens are returned as a string
+// because Lua numbers are truncated to integers in replies.
+var takeTokenScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(state[1])
+local at = tonumber(state[2])
+if tokens == nil or at == nil then
+	tokens = burst
+	at = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - at) / 1000 * rate)
+local taken = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	taken = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(now))
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {taken, tostring(tokens)}
+`)
+
+// RedisStore is a Store backed by a pooled Redis client
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore connects to Redis and verifies the connection
+func NewRedisStore(redisURL, password string) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     redisURL,
+		Password: password,
+		DB:       0,
+	})
+	client.AddHook(redisTracer{addr: redisURL})
+
+	// Verify connection
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %v", err)
+	}
+
+	store := &RedisStore{client: client}
+	if err := store.migrateProtocolPairs(context.Background()); err != nil {
+		// Tried again on the next start
+		logger.Error("Failed to migrate pools", "error", err)
+	}
+	return store, nil
+}
+
+// migrateProtocolPairs moves pools stored under bare pair keys into their
+// pairSetKey and indexes them. It only runs until it has succeeded once.
+func (s *RedisStore) migrateProtocolPairs(ctx context.Context) error {
+	migrated, err := s.client.Exists(ctx, pairsMigratedKey).Result()
+	if err != nil {
+		return err
+	}
+	if migrated > 0 {
+		return nil
+	}
+
+	moved := 0
+	iter := s.client.Scan(ctx, 0, legacyPairPattern, 500).Iterator()
+	for iter.Next(ctx) {
+		pairKey := iter.Val()
+		if kind, err := s.client.Type(ctx, pairKey).Result(); err != nil {
+			return err
+		} else if kind != "set" {
+			continue
+		}
+
+		pipe := s.client.TxPipeline()
+		pipe.SUnionStore(ctx, pairSetKey(pairKey), pairSetKey(pairKey), pairKey)
+		pipe.SAdd(ctx, pairIndexKey, pairKey)
+		pipe.Del(ctx, pairKey)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to move pools of %s: %v", pairKey, err)
+		}
+		moved++
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	if moved > 0 {
+		logger.Info("Migrated pools", "pairs", moved)
+	}
+	return s.client.Set(ctx, pairsMigratedKey, 1, 0).Err()
+}
+
+// Close closes the connection pool
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// GetToken retrieves token metadata from Redis
+func (s *RedisStore) GetToken(ctx context.Context, address common.Address) (*models.TokenMetadata, error) {
+	data, err := s.client.Get(ctx, tokenKey(address.String())).Result()
+	if err == redis.Nil {
+		return nil, nil // Key does not exist
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get token metadata from Redis: %v", err)
+	}
+
+	var token models.TokenMetadata
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token metadata: %v", err)
+	}
+	return &token, nil
+}
+
+// GetTokens retrieves the metadata of many tokens with one MGET
+func (s *RedisStore) GetTokens(ctx context.Context, addresses []common.Address) ([]*models.TokenMetadata, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(addresses))
+	for i, address := range addresses {
+		keys[i] = tokenKey(address.String())
+	}
+	return s.getTokens(ctx, keys)
+}
+
+// SaveToken saves token metadata to Redis
+func (s *RedisStore) SaveToken(ctx context.Context, token models.TokenMetadata, ttl time.Duration) error {
+	return s.SaveTokens(ctx, []models.TokenMetadata{token}, ttl)
+}
+
+// SaveTokens saves the metadata of many tokens in one pipeline
+func (s *RedisStore) SaveTokens(ctx context.Context, tokens []models.TokenMetadata, ttl time.Duration) error {
+	pipe := s.client.Pipeline()
+	for _, token := range tokens {
+		jsonData, err := json.Marshal(token)
+		if err != nil {
+			return fmt.Errorf("failed to marshal token metadata: %v", err)
+		}
+		pipe.Set(ctx, tokenKey(token.Address), jsonData, ttl)
+		pipe.Del(ctx, tokenMissKey(token.Address))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save token metadata to Redis: %v", err)
+	}
+	return nil
+}
+
+// DeleteToken removes the metadata of a token, or that it isn't a token, from
+// Redis. Registry membership is kept.
+func (s *RedisStore) DeleteToken(ctx context.Context, address common.Address) error {
+	if err := s.client.Del(ctx, tokenKey(address.String()), tokenMissKey(address.String())).Err(); err != nil {
+		return fmt.Errorf("failed to delete token metadata from Redis: %v", err)
+	}
+	return nil
+}
+
+// GetTokenMisses retrieves why addresses aren't tokens from Redis with one MGET
+func (s *RedisStore) GetTokenMisses(ctx context.Context, addresses []common.Address) ([]string, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(addresses))
+	for i, address := range addresses {
+		keys[i] = tokenMissKey(address.String())
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token misses from Redis: %v", err)
+	}
+
+	reasons := make([]string, len(addresses))
+	for i, value := range values {
+		reasons[i], _ = value.(string)
+	}
+	return reasons, nil
+}
+
+// SaveTokenMiss saves why an address isn't a token to Redis until ttl passes
+func (s *RedisStore) SaveTokenMiss(ctx context.Context, address common.Address, reason string, ttl time.Duration) error {
+	if err := s.client.Set(ctx, tokenMissKey(address.String()), reason, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save token miss to Redis: %v", err)
+	}
+	return nil
+}
+
+// SaveRegistryTokens saves tokens and adds them to the registry in one transaction
+func (s *RedisStore) SaveRegistryTokens(ctx context.Context, tokens []models.TokenMetadata) error {
+	pipe := s.client.TxPipeline()
+	for _, token := range tokens {
+		jsonData, err := json.Marshal(token)
+		if err != nil {
+			return fmt.Errorf("failed to marshal token metadata: %v", err)
+		}
+		pipe.Set(ctx, tokenKey(token.Address), jsonData, 0) // 0 = no expiration
+		pipe.Del(ctx, tokenMissKey(token.Address))
+		pipe.SAdd(ctx, tokenRegistryKey, token.Address)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save token registry to Redis: %v", err)
+	}
+	return nil
+}
+
+// GetRegistryTokens retrieves every token in the registry
+func (s *RedisStore) GetRegistryTokens(ctx context.Context) ([]models.TokenMetadata, error) {
+	addresses, err := s.client.SMembers(ctx, tokenRegistryKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token registry from Redis: %v", err)
+	}
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(addresses))
+	for i, address := range addresses {
+		keys[i] = tokenKey(address)
+	}
+	found, err := s.getTokens(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := make([]models.TokenMetadata, 0, len(found))
+	for _, token := range found {
+		if token != nil {
+			registry = append(registry, *token)
+		}
+	}
+	return registry, nil
+}
+
+// getTokens retrieves token metadata by key with one MGET
+func (s *RedisStore) getTokens(ctx context.Context, keys []string) ([]*models.TokenMetadata, error) {
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token metadata from Redis: %v", err)
+	}
+
+	found := make([]*models.TokenMetadata, len(keys))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue // Key does not exist
+		}
+		var token models.TokenMetadata
+		if err := json.Unmarshal([]byte(data), &token); err != nil {
+			logger.WarnContext(ctx, "Failed to unmarshal token metadata", "key", keys[i], "error", err)
+			continue
+		}
+		found[i] = &token
+	}
+	return found, nil
+}
+
+// AddProtocolPair adds a protocol pair to the set for its token pair
+func (s *RedisStore) AddProtocolPair(ctx context.Context, pairKey string, pair pairs.ProtocolPair) error {
+	data, err := json.Marshal(pair)
+	if err != nil {
+		return fmt.Errorf("failed to marshal protocol pair: %v", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.SAdd(ctx, pairSetKey(pairKey), data)
+	pipe.SAdd(ctx, pairIndexKey, pairKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add protocol pair to Redis: %v", err)
+	}
+	return nil
+}
+
+// GetProtocolPairs retrieves the protocol pairs of a token pair
+func (s *RedisStore) GetProtocolPairs(ctx context.Context, pairKey string) ([]pairs.ProtocolPair, error) {
+	members, err := s.client.SMembers(ctx, pairSetKey(pairKey)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get protocol pairs from Redis: %v", err)
+	}
+	return decodeProtocolPairs(members), nil
+}
+
+// GetAllProtocolPairs retrieves the protocol pairs of every token pair
+func (s *RedisStore) GetAllProtocolPairs(ctx context.Context) (map[string][]pairs.ProtocolPair, error) {
+	pairKeys, err := s.client.SMembers(ctx, pairIndexKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pair index from Redis: %v", err)
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, len(pairKeys))
+	for i, pairKey := range pairKeys {
+		cmds[i] = pipe.SMembers(ctx, pairSetKey(pairKey))
+	}
+	if len(pairKeys) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get protocol pairs from Redis: %v", err)
+		}
+	}
+
+	all := make(map[string][]pairs.ProtocolPair, len(pairKeys))
+	for i, pairKey := range pairKeys {
+		all[pairKey] = decodeProtocolPairs(cmds[i].Val())
+	}
+	return all, nil
+}
+
+// decodeProtocolPairs decodes the members of a pair's set, skipping bad ones
+func decodeProtocolPairs(members []string) []pairs.ProtocolPair {
+	protocolPairs := make([]pairs.ProtocolPair, 0, len(members))
+	for _, member := range members {
+		var pair pairs.ProtocolPair
+		if err := json.Unmarshal([]byte(member), &pair); err != nil {
+			logger.Warn("Failed to unmarshal protocol pair", "error", err)
+			continue
+		}
+		protocolPairs = append(protocolPairs, pair)
+	}
+	return protocolPairs
+}
+
+// GetQuote retrieves an encoded quote from Redis
+func (s *RedisStore) GetQuote(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.client.Get(ctx, quoteKey(key)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get quote from Redis: %v", err)
+	}
+	return data, nil
+}
+
+// SaveQuote saves an encoded quote to Redis until ttl passes
+func (s *RedisStore) SaveQuote(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	if err := s.client.Set(ctx, quoteKey(key), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save quote to Redis: %v", err)
+	}
+	return nil
+}
+
+// GetAPIKey retrieves an API key from Redis
+func (s *RedisStore) GetAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	data, err := s.client.Get(ctx, apiKeyKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get API key from Redis: %v", err)
+	}
+
+	var key models.APIKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal API key: %v", err)
+	}
+	return &key, nil
+}
+
+// SaveAPIKey saves an API key and adds it to the index in one transaction
+func (s *RedisStore) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	jsonData, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key: %v", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, apiKeyKey(key.ID), jsonData, 0) // Kept after expiry for auditing
+	pipe.SAdd(ctx, apiKeyIndexKey, key.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save API key to Redis: %v", err)
+	}
+	return nil
+}
+
+// ListAPIKeys retrieves every issued API key
+func (s *RedisStore) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	ids, err := s.client.SMembers(ctx, apiKeyIndexKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key index from Redis: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = apiKeyKey(id)
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API keys from Redis: %v", err)
+	}
+
+	apiKeys := make([]models.APIKey, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue // Removed from Redis but still in the index
+		}
+		var key models.APIKey
+		if err := json.Unmarshal([]byte(data), &key); err != nil {
+			logger.WarnContext(ctx, "Skipping malformed API key", "key", ids[i], "error", err)
+			continue
+		}
+		apiKeys = append(apiKeys, key)
+	}
+	return apiKeys, nil
+}
+
+// TakeToken takes a token from a bucket in Redis
+func (s *RedisStore) TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, float64, error) {
+	reply, err := takeTokenScript.Run(ctx, s.client, []string{bucketKey(key)},
+		rate, burst, now.UnixMilli(), bucketTTL(rate, burst).Milliseconds()).Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take rate limit token from Redis: %v", err)
+	}
+	if len(reply) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit reply from Redis: %v", reply)
+	}
+
+	taken, _ := reply[0].(int64)
+	text, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("unexpected rate limit tokens from Redis: %q", text)
+	}
+	return taken == 1, tokens, nil
+}
+
+// IncrementUsage increments a usage counter in Redis
+func (s *RedisStore) IncrementUsage(ctx context.Context, keyID, day, field string, ttl time.Duration) error {
+	key := usageKey(keyID, day)
+	pipe := s.client.TxPipeline()
+	pipe.HIncrBy(ctx, key, field, 1)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to increment usage in Redis: %v", err)
+	}
+	return nil
+}
+
+// GetUsage retrieves an API key's usage counters for a day from Redis
+func (s *RedisStore) GetUsage(ctx context.Context, keyID, day string) (map[string]int64, error) {
+	values, err := s.client.HGetAll(ctx, usageKey(keyID, day)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage from Redis: %v", err)
+	}
+
+	usage := make(map[string]int64, len(values))
+	for field, value := range values {
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		usage[field] = count
+	}
+	return usage, nil
+}
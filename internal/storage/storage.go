@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/config"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Storage backends
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+)
+
+// TokenRepository stores token metadata, addresses known not to be tokens,
+// and the registry of curated tokens. Lookups return nil (or "") without an
+// error for tokens that aren't stored. A ttl of 0 never expires.
+type TokenRepository interface {
+	GetToken(ctx context.Context, address common.Address) (*models.TokenMetadata, error)
+	GetTokens(ctx context.Context, addresses []common.Address) ([]*models.TokenMetadata, error)
+	SaveToken(ctx context.Context, token models.TokenMetadata, ttl time.Duration) error
+	SaveTokens(ctx context.Context, tokens []models.TokenMetadata, ttl time.Duration) error
+	DeleteToken(ctx context.Context, address common.Address) error
+	GetTokenMisses(ctx context.Context, addresses []common.Address) ([]string, error)
+	SaveTokenMiss(ctx context.Context, address common.Address, reason string, ttl time.Duration) error
+	SaveRegistryTokens(ctx context.Context, tokens []models.TokenMetadata) error
+	GetRegistryTokens(ctx context.Context) ([]models.TokenMetadata, error)
+}
+
+// PairRepository stores the protocol pairs found for each token pair
+type PairRepository interface {
+	pairs.Store
+}
+
+// QuoteRepository stores encoded quotes by key until they expire
+type QuoteRepository interface {
+	GetQuote(ctx context.Context, key string) ([]byte, error)
+	SaveQuote(ctx context.Context, key string, data []byte, ttl time.Duration) error
+}
+
+// KeyRepository stores API keys by ID. GetAPIKey returns nil without an error
+// for keys that aren't stored. Keys are kept after they expire or are revoked.
+type KeyRepository interface {
+	GetAPIKey(ctx context.Context, id string) (*models.APIKey, error)
+	SaveAPIKey(ctx context.Context, key models.APIKey) error
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+}
+
+// LimitRepository keeps the token buckets of rate limits and the daily usage
+// counters of API keys, so every instance sharing the store shares them.
+// TakeToken refills a bucket of up to burst tokens at rate tokens a second and
+// takes one if there is one, returning whether it did and the tokens left.
+// Usage is counted per key, day and field, and kept for ttl.
+type LimitRepository interface {
+	TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, float64, error)
+	IncrementUsage(ctx context.Context, keyID, day, field string, ttl time.Duration) error
+	GetUsage(ctx context.Context, keyID, day string) (map[string]int64, error)
+}
+
+// Store is the storage layer shared by the handlers
+type Store interface {
+	TokenRepository
+	PairRepository
+	QuoteRepository
+	KeyRepository
+	LimitRepository
+	Close() error
+}
+
+// New creates the store for the configured backend
+func New(cfg config.Config) (Store, error) {
+	switch cfg.StorageBackend {
+	case BackendRedis:
+		return NewRedisStore(cfg.RedisURL, cfg.RedisPassword)
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.StorageBackend)
+	}
+}
+
+// tokenKey is the key token metadata is stored under
+func tokenKey(address string) string {
+	return "token:" + address
+}
+
+// tokenMissKey is the key the reason an address isn't a token is stored under
+func tokenMissKey(address string) string {
+	return "token:miss:" + address
+}
+
+// pairSetKey is the key of the set of pools for a token pair
+func pairSetKey(pairKey string) string {
+	return "pairs:" + pairKey
+}
+
+// quoteKey is the key a quote is stored under
+func quoteKey(key string) string {
+	return "quote:" + key
+}
+
+// apiKeyKey is the key an API key is stored under
+func apiKeyKey(id string) string {
+	return "apikey:" + id
+}
+
+// bucketKey is the key a rate limit's token bucket is stored under
+func bucketKey(key string) string {
+	return "ratelimit:" + key
+}
+
+// usageKey is the key of an API key's usage counters for a day
+func usageKey(keyID, day string) string {
+	return "usage:" + keyID + ":" + day
+}
+
+// bucketTTL is how long until an untouched bucket is full again, after which
+// it can be dropped
+func bucketTTL(rate float64, burst int) time.Duration {
+	return time.Duration(float64(burst)/rate*float64(time.Second)) + time.Second
+}
@@ -0,0 +1,376 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/pairs"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fixture is the data a contract test writes. Names are unique to the run so
+// tests against a shared Redis don't collide with other data.
+type fixture struct {
+	run       string
+	token     common.Address
+	other     common.Address
+	missing   common.Address
+	registry  common.Address
+	pairKey   string
+	quoteKey  string
+	expiryKey string
+	apiKeyID  string
+	bucketKey string
+	usageKey  string
+}
+
+func newFixture() fixture {
+	seed := time.Now().UnixNano()
+	address := func(i int64) common.Address { return common.BigToAddress(big.NewInt(seed + i)) }
+	run := fmt.Sprintf("test-%d", seed)
+	return fixture{
+		run:       run,
+		token:     address(1),
+		other:     address(2),
+		missing:   address(3),
+		registry:  address(4),
+		pairKey:   run + ":pair",
+		quoteKey:  run + ":quote",
+		expiryKey: run + ":expiry",
+		apiKeyID:  run + ":key",
+		bucketKey: run + ":bucket",
+		usageKey:  run + ":usage",
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore(), newFixture())
+}
+
+// newTestRedisStore connects to the Redis at REDIS_URL, or localhost:6379,
+// and skips the test if it can't
+func newTestRedisStore(t *testing.T) *RedisStore {
+	addr := os.Getenv("REDIS_URL")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	store, err := NewRedisStore(addr, os.Getenv("REDIS_PASSWORD"))
+	if err != nil {
+		t.Skipf("Redis is not available: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestRedisStore(t *testing.T) {
+	store := newTestRedisStore(t)
+	f := newFixture()
+	t.Cleanup(func() {
+		ctx := context.Background()
+		for _, address := range []common.Address{f.token, f.other, f.missing, f.registry} {
+			store.client.Del(ctx, tokenKey(address.String()), tokenMissKey(address.String()))
+		}
+		store.client.SRem(ctx, tokenRegistryKey, f.registry.String())
+		store.client.SRem(ctx, pairIndexKey, f.pairKey)
+		store.client.SRem(ctx, apiKeyIndexKey, f.apiKeyID)
+		store.client.Del(ctx, pairSetKey(f.pairKey), quoteKey(f.quoteKey), quoteKey(f.expiryKey),
+			apiKeyKey(f.apiKeyID), bucketKey(f.bucketKey), usageKey(f.usageKey, "2026-01-01"))
+	})
+	testStore(t, store, f)
+}
+
+func TestRedisMigrateProtocolPairs(t *testing.T) {
+	store := newTestRedisStore(t)
+	ctx := context.Background()
+
+	// Bare pair keys like pools were stored under before they were indexed
+	f := newFixture()
+	legacyKey := f.token.Hex() + "-" + f.other.Hex()
+	laterKey := f.missing.Hex() + "-" + f.registry.Hex()
+	pool, _ := json.Marshal(pairs.ProtocolPair{ProtocolName: "Uniswap V3", ContractAddress: f.token.Hex(), Fee: 500})
+	t.Cleanup(func() {
+		store.client.SRem(ctx, pairIndexKey, legacyKey, laterKey)
+		store.client.Del(ctx, legacyKey, laterKey, pairSetKey(legacyKey), pairSetKey(laterKey))
+	})
+	if err := store.client.SAdd(ctx, legacyKey, pool).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend this store has never migrated, the migration is idempotent so
+	// other data in a shared Redis isn't harmed
+	if err := store.client.Del(ctx, pairsMigratedKey).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.migrateProtocolPairs(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := store.GetProtocolPairs(ctx, legacyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrated) != 1 || migrated[0].Fee != 500 {
+		t.Errorf("GetProtocolPairs = %+v, want the legacy pool", migrated)
+	}
+	if exists, _ := store.client.Exists(ctx, legacyKey).Result(); exists != 0 {
+		t.Error("legacy key wasn't deleted")
+	}
+	if indexed, _ := store.client.SIsMember(ctx, pairIndexKey, legacyKey).Result(); !indexed {
+		t.Error("pair wasn't indexed")
+	}
+
+	// It only runs once
+	if err := store.client.SAdd(ctx, laterKey, pool).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.migrateProtocolPairs(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := store.client.Exists(ctx, laterKey).Result(); exists == 0 {
+		t.Error("migration ran again")
+	}
+}
+
+// testStore checks the behaviour every Store backend must share
+func testStore(t *testing.T, store Store, f fixture) {
+	ctx := context.Background()
+
+	t.Run("tokens", func(t *testing.T) {
+		if token, err := store.GetToken(ctx, f.missing); err != nil || token != nil {
+			t.Fatalf("GetToken(missing) = %v, %v, want nil, nil", token, err)
+		}
+
+		saved := models.TokenMetadata{
+			Address:   f.token.String(),
+			Name:      "Token",
+			Symbol:    "TKN",
+			Decimals:  6,
+			Behaviour: &models.TokenBehaviour{FeeOnTransfer: true, TransferFeeBps: 100},
+			Risk:      &models.RiskReport{Score: 30, Level: "medium", Reasons: []string{"sell tax of 10%"}},
+			Inferred:  []string{models.FieldName},
+		}
+		if err := store.SaveToken(ctx, saved, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		token, err := store.GetToken(ctx, f.token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token == nil || token.Symbol != "TKN" || token.Decimals != 6 ||
+			token.Behaviour == nil || token.Behaviour.TransferFeeBps != 100 ||
+			token.Risk == nil || token.Risk.Score != 30 || !slices.Equal(token.Inferred, saved.Inferred) {
+			t.Errorf("GetToken = %+v, want %+v", token, saved)
+		}
+
+		if err := store.SaveTokens(ctx, []models.TokenMetadata{{Address: f.other.String(), Symbol: "OTH"}}, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		found, err := store.GetTokens(ctx, []common.Address{f.other, f.missing, f.token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != 3 || found[0] == nil || found[0].Symbol != "OTH" || found[1] != nil || found[2] == nil || found[2].Symbol != "TKN" {
+			t.Errorf("GetTokens = %v, want OTH, nil, TKN", found)
+		}
+
+		if err := store.DeleteToken(ctx, f.other); err != nil {
+			t.Fatal(err)
+		}
+		if token, err := store.GetToken(ctx, f.other); err != nil || token != nil {
+			t.Errorf("GetToken after DeleteToken = %v, %v, want nil, nil", token, err)
+		}
+	})
+
+	t.Run("token misses", func(t *testing.T) {
+		if err := store.SaveTokenMiss(ctx, f.missing, "no code", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		reasons, err := store.GetTokenMisses(ctx, []common.Address{f.token, f.missing})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(reasons, []string{"", "no code"}) {
+			t.Errorf("GetTokenMisses = %q, want [\"\" \"no code\"]", reasons)
+		}
+
+		// Saving the token clears the miss
+		if err := store.SaveToken(ctx, models.TokenMetadata{Address: f.missing.String()}, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		reasons, err = store.GetTokenMisses(ctx, []common.Address{f.missing})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(reasons, []string{""}) {
+			t.Errorf("GetTokenMisses after SaveToken = %q, want [\"\"]", reasons)
+		}
+	})
+
+	t.Run("registry", func(t *testing.T) {
+		if err := store.SaveTokenMiss(ctx, f.registry, "no code", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		curated := models.TokenMetadata{Address: f.registry.String(), Symbol: "REG", Curated: true}
+		if err := store.SaveRegistryTokens(ctx, []models.TokenMetadata{curated}); err != nil {
+			t.Fatal(err)
+		}
+
+		registry, err := store.GetRegistryTokens(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.ContainsFunc(registry, func(token models.TokenMetadata) bool {
+			return token.Address == curated.Address && token.Symbol == "REG" && token.Curated
+		}) {
+			t.Errorf("GetRegistryTokens = %v, missing %s", registry, curated.Address)
+		}
+		if slices.ContainsFunc(registry, func(token models.TokenMetadata) bool { return token.Address == f.token.String() }) {
+			t.Errorf("GetRegistryTokens has %s, which wasn't imported", f.token)
+		}
+
+		reasons, err := store.GetTokenMisses(ctx, []common.Address{f.registry})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reasons[0] != "" {
+			t.Errorf("miss %q kept after the token was imported", reasons[0])
+		}
+	})
+
+	t.Run("protocol pairs", func(t *testing.T) {
+		pool := pairs.ProtocolPair{ProtocolName: "Uniswap V3", ContractAddress: f.token.String(), Fee: 3000}
+		other := pairs.ProtocolPair{ProtocolName: "PancakeSwap V3", ContractAddress: f.other.String(), Fee: 500}
+		for _, pair := range []pairs.ProtocolPair{pool, pool, other} {
+			if err := store.AddProtocolPair(ctx, f.pairKey, pair); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		found, err := store.GetProtocolPairs(ctx, f.pairKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != 2 || !slices.Contains(found, pool) || !slices.Contains(found, other) {
+			t.Errorf("GetProtocolPairs = %v, want %v and %v once each", found, pool, other)
+		}
+
+		all, err := store.GetAllProtocolPairs(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all[f.pairKey]) != 2 {
+			t.Errorf("GetAllProtocolPairs[%s] = %v, want 2 pools", f.pairKey, all[f.pairKey])
+		}
+
+		if found, err := store.GetProtocolPairs(ctx, f.run+":none"); err != nil || len(found) != 0 {
+			t.Errorf("GetProtocolPairs(unknown) = %v, %v, want none", found, err)
+		}
+	})
+
+	t.Run("quotes", func(t *testing.T) {
+		if data, err := store.GetQuote(ctx, f.quoteKey); err != nil || data != nil {
+			t.Fatalf("GetQuote(missing) = %q, %v, want nil, nil", data, err)
+		}
+		if err := store.SaveQuote(ctx, f.quoteKey, []byte(`{"amountOut":"1"}`), time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		if data, err := store.GetQuote(ctx, f.quoteKey); err != nil || string(data) != `{"amountOut":"1"}` {
+			t.Errorf("GetQuote = %q, %v", data, err)
+		}
+
+		if err := store.SaveQuote(ctx, f.expiryKey, []byte("stale"), 50*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(150 * time.Millisecond)
+		if data, err := store.GetQuote(ctx, f.expiryKey); err != nil || data != nil {
+			t.Errorf("GetQuote after ttl = %q, %v, want nil, nil", data, err)
+		}
+	})
+
+	t.Run("API keys", func(t *testing.T) {
+		if key, err := store.GetAPIKey(ctx, f.apiKeyID); err != nil || key != nil {
+			t.Fatalf("GetAPIKey(missing) = %v, %v, want nil, nil", key, err)
+		}
+
+		saved := models.APIKey{ID: f.apiKeyID, Hash: "abc", Owner: "tests", Scopes: []string{"quote"}, CreatedAt: 1700000000}
+		if err := store.SaveAPIKey(ctx, saved); err != nil {
+			t.Fatal(err)
+		}
+		key, err := store.GetAPIKey(ctx, f.apiKeyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key == nil || key.Owner != "tests" || !slices.Equal(key.Scopes, saved.Scopes) {
+			t.Errorf("GetAPIKey = %+v, want %+v", key, saved)
+		}
+
+		saved.RevokedAt = 1700000001
+		if err := store.SaveAPIKey(ctx, saved); err != nil {
+			t.Fatal(err)
+		}
+		keys, err := store.ListAPIKeys(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var listed []models.APIKey
+		for _, key := range keys {
+			if key.ID == f.apiKeyID {
+				listed = append(listed, key)
+			}
+		}
+		if len(listed) != 1 || listed[0].RevokedAt != saved.RevokedAt {
+			t.Errorf("ListAPIKeys has %v, want the revoked key once", listed)
+		}
+	})
+
+	t.Run("token buckets", func(t *testing.T) {
+		now := time.Now()
+		steps := []struct {
+			at    time.Duration
+			taken bool
+			left  float64
+		}{
+			{0, true, 1},
+			{0, true, 0},
+			{0, false, 0},
+			{500 * time.Millisecond, false, 0.5}, // Half a token refilled
+			{time.Second, true, 0},
+		}
+		for i, step := range steps {
+			taken, left, err := store.TakeToken(ctx, f.bucketKey, 1, 2, now.Add(step.at))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if taken != step.taken || left != step.left {
+				t.Errorf("take %d = %v, %v, want %v, %v", i, taken, left, step.taken, step.left)
+			}
+		}
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		const day = "2026-01-01"
+		for _, field := range []string{"quote", "quote", "swap"} {
+			if err := store.IncrementUsage(ctx, f.usageKey, day, field, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+		}
+		usage, err := store.GetUsage(ctx, f.usageKey, day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(usage) != 2 || usage["quote"] != 2 || usage["swap"] != 1 {
+			t.Errorf("GetUsage = %v, want quote 2 and swap 1", usage)
+		}
+
+		if usage, err := store.GetUsage(ctx, f.usageKey, "2026-01-02"); err != nil || len(usage) != 0 {
+			t.Errorf("GetUsage(other day) = %v, %v, want none", usage, err)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/tracing"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanKey holds the span of a Redis command in its context
+type spanKey struct{}
+
+// redisTracer is a Redis hook giving each command or pipeline made as part of
+// a trace a span
+type redisTracer struct {
+	addr string
+}
+
+// start starts the span of a command or pipeline, if ctx is traced
+func (t redisTracer) start(ctx context.Context, name string, attrs ...attribute.KeyValue) context.Context {
+	if !tracing.Traced(ctx) {
+		return ctx
+	}
+	attrs = append(attrs,
+		attribute.String("db.system", "redis"),
+		attribute.String("server.address", t.addr),
+	)
+	ctx, span := tracing.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// end ends the span started for a command or pipeline. redis.Nil is a
+// missing key, not a failure.
+func (t redisTracer) end(ctx context.Context, err error) {
+	span, ok := ctx.Value(spanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if err == redis.Nil {
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
+// BeforeProcess starts the span of a command
+func (t redisTracer) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return t.start(ctx, "redis "+cmd.Name(), attribute.String("db.operation.name", cmd.Name())), nil
+}
+
+// AfterProcess ends the span of a command
+func (t redisTracer) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	t.end(ctx, cmd.Err())
+	return nil
+}
+
+// BeforeProcessPipeline starts the span of a pipeline or transaction
+func (t redisTracer) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return t.start(ctx, "redis pipeline", attribute.Int("db.operation.batch.size", len(cmds))), nil
+}
+
+// AfterProcessPipeline ends the span of a pipeline, failed if any command was
+func (t redisTracer) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmdErr := cmd.Err(); cmdErr != nil && cmdErr != redis.Nil {
+			err = cmdErr
+			break
+		}
+	}
+	t.end(ctx, err)
+	return nil
+}
@@ -0,0 +1,196 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/lmittmann/w3"
+	"github.com/lmittmann/w3/module/eth"
+)
+
+// resubscribeDelay is how long the node is polled after a newHeads
+// subscription fails before subscribing again
+const resubscribeDelay = 30 * time.Second
+
+// Delays between attempts to connect to the node for polling, doubling from
+// the first to the longest
+const (
+	dialRetryDelay    = time.Second
+	maxDialRetryDelay = 30 * time.Second
+)
+
+// Heads broadcasts new block numbers. Blocks come from a newHeads subscription
+// over wsURL; without one, or while the subscription is down, the node is
+// polled every pollInterval.
+type Heads struct {
+	wsURL        string
+	nodeURL      string
+	pollInterval time.Duration
+
+	mu          sync.Mutex
+	latest      uint64
+	subscribers map[chan uint64]struct{}
+}
+
+// NewHeads creates a block watcher, wsURL may be empty to only poll
+func NewHeads(wsURL, nodeURL string, pollInterval time.Duration) *Heads {
+	return &Heads{
+		wsURL:        wsURL,
+		nodeURL:      nodeURL,
+		pollInterval: pollInterval,
+		subscribers:  make(map[chan uint64]struct{}),
+	}
+}
+
+// Run watches for new blocks until ctx is done
+func (h *Heads) Run(ctx context.Context) {
+	if h.wsURL == "" {
+		h.poll(ctx, 0)
+		return
+	}
+
+	for {
+		err := h.subscribe(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.WarnContext(ctx, "newHeads subscription failed, polling", "duration", resubscribeDelay.String(), "error", err)
+		h.poll(ctx, resubscribeDelay)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// Latest returns the latest block seen, 0 if none has been seen yet
+func (h *Heads) Latest() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.latest
+}
+
+// Subscribe returns a channel of new block numbers and a function to stop
+// receiving them. Slow receivers only get the latest block, older blocks they
+// haven't read are dropped.
+func (h *Heads) Subscribe() (<-chan uint64, func()) {
+	ch := make(chan uint64, 1)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subscribers, ch)
+	}
+}
+
+// publish sends a block to every subscriber if it is newer than the latest
+func (h *Heads) publish(block uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if block <= h.latest {
+		return
+	}
+	h.latest = block
+
+	for ch := range h.subscribers {
+		// Replace a block the subscriber hasn't read yet
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- block
+	}
+}
+
+// subscribe publishes blocks from a newHeads subscription until it fails or
+// ctx is done
+func (h *Heads) subscribe(ctx context.Context) error {
+	client, err := node.Dial(h.wsURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to node: %v", err)
+	}
+	defer client.Close()
+
+	headers := make(chan *types.Header, 16)
+	sub, err := client.SubscribeCtx(ctx, eth.NewHeads(headers))
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to new heads: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			return err
+		case header := <-headers:
+			h.publish(header.Number.Uint64())
+		}
+	}
+}
+
+// poll publishes the node's block number every pollInterval, for duration or
+// until ctx is done if duration is 0
+func (h *Heads) poll(ctx context.Context, duration time.Duration) {
+	var deadline <-chan time.Time
+	if duration > 0 {
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	// Keep trying to connect, backing off between attempts
+	var client *w3.Client
+	for delay := dialRetryDelay; ; delay = min(2*delay, maxDialRetryDelay) {
+		var err error
+		client, err = node.Dial(h.nodeURL)
+		if err == nil {
+			break
+		}
+		logger.ErrorContext(ctx, "Failed to connect to node, retrying", "delay", delay.String(), "error", err)
+
+		retry := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			retry.Stop()
+			return
+		case <-deadline:
+			retry.Stop()
+			return
+		case <-retry.C:
+		}
+	}
+	defer client.Close()
+
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		var block *big.Int
+		if err := client.CallCtx(ctx, eth.BlockNumber().Returns(&block)); err != nil {
+			if ctx.Err() == nil {
+				logger.WarnContext(ctx, "Failed to get block number", "error", err)
+			}
+		} else {
+			h.publish(block.Uint64())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline:
+			return
+		case <-ticker.C:
+		}
+	}
+}
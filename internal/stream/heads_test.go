@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node/nodetest"
+)
+
+// nextBlock waits for a block on blocks
+func nextBlock(t *testing.T, blocks <-chan uint64) uint64 {
+	t.Helper()
+	select {
+	case block := <-blocks:
+		return block
+	case <-time.After(5 * time.Second):
+		t.Fatal("no block received")
+		return 0
+	}
+}
+
+func TestHeadsPolling(t *testing.T) {
+	tests := []struct {
+		name  string
+		wsURL func(node *nodetest.Node) string
+	}{
+		{"without a WebSocket", func(*nodetest.Node) string { return "" }},
+		// Nothing listens on port 1, so the subscription fails and heads polls
+		{"subscription down", func(*nodetest.Node) string { return "ws://127.0.0.1:1" }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := nodetest.New(t)
+			node.SetBlock(5)
+
+			heads := NewHeads(test.wsURL(node), node.URL, 10*time.Millisecond)
+			blocks, stop := heads.Subscribe()
+			defer stop()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go heads.Run(ctx)
+
+			if block := nextBlock(t, blocks); block != 5 {
+				t.Fatalf("block = %d, want 5", block)
+			}
+			node.SetBlock(7)
+			if block := nextBlock(t, blocks); block != 7 {
+				t.Errorf("block = %d, want 7", block)
+			}
+			if latest := heads.Latest(); latest != 7 {
+				t.Errorf("Latest = %d, want 7", latest)
+			}
+		})
+	}
+}
+
+func TestHeadsPublish(t *testing.T) {
+	heads := NewHeads("", "", time.Hour)
+	blocks, stop := heads.Subscribe()
+
+	// A slow subscriber only gets the latest block, and old blocks are ignored
+	heads.publish(3)
+	heads.publish(4)
+	heads.publish(2)
+	if block := nextBlock(t, blocks); block != 4 {
+		t.Errorf("block = %d, want 4", block)
+	}
+	select {
+	case block := <-blocks:
+		t.Errorf("unexpected block %d", block)
+	default:
+	}
+
+	// Stopped subscribers get nothing
+	stop()
+	heads.publish(5)
+	select {
+	case block := <-blocks:
+		t.Errorf("block %d after stop", block)
+	default:
+	}
+}
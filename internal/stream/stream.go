@@ -0,0 +1,404 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/api"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/gorilla/websocket"
+)
+
+var logger = logging.For("stream")
+
+const (
+	quoteTimeout = 15 * time.Second // Bounds each quote
+	writeTimeout = 10 * time.Second // A client that can't take a message in time is disconnected
+	pingInterval = 30 * time.Second // WebSocket keepalive
+	pongTimeout  = 60 * time.Second // A WebSocket client that doesn't answer pings is disconnected
+	maxMessage   = 4096             // Largest message accepted from a WebSocket client
+)
+
+// ErrTooManyConnections is returned when the connection limit is reached
+var ErrTooManyConnections = errors.New("too many streaming connections")
+
+// QuoteFunc quotes a request at the latest block
+type QuoteFunc func(ctx context.Context, req api.QuoteRequest) (*api.QuoteResponse, *api.Error)
+
+// Config limits streaming
+type Config struct {
+	MaxConnections   int // Open WebSocket and SSE connections
+	MaxSubscriptions int // Subscriptions per WebSocket connection
+}
+
+// Service streams quotes to clients, quoting each subscription again every
+// new block
+type Service struct {
+	heads *Heads
+	quote QuoteFunc
+	cfg   Config
+
+	mu          sync.Mutex
+	connections int
+}
+
+// NewService creates a streaming service
+func NewService(heads *Heads, quote QuoteFunc, cfg Config) *Service {
+	return &Service{heads: heads, quote: quote, cfg: cfg}
+}
+
+// acquire reserves a connection, returning false if the limit is reached
+func (s *Service) acquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cfg.MaxConnections > 0 && s.connections >= s.cfg.MaxConnections {
+		return false
+	}
+	s.connections++
+	return true
+}
+
+// release frees a connection reserved by acquire
+func (s *Service) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connections--
+}
+
+// watch quotes req now and after every new block until ctx is done, passing
+// each update to send. With updates set to api.UpdatesChange a quote is only
+// sent when the best route differs from the last one sent. Blocks that
+// arrive while a quote is running are skipped, so slow quotes don't queue up.
+func (s *Service) watch(ctx context.Context, id string, req api.QuoteRequest, updates string, send func(api.StreamMessage)) {
+	blocks, stop := s.heads.Subscribe()
+	defer stop()
+
+	var last *api.QuoteResponse
+	block := s.heads.Latest()
+	for {
+		quoteCtx, cancel := context.WithTimeout(ctx, quoteTimeout)
+		response, quoteErr := s.quote(quoteCtx, req)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case quoteErr != nil:
+			send(api.StreamMessage{Type: api.StreamError, ID: id, Block: block, Error: quoteErr})
+			last = nil
+		case updates != api.UpdatesChange || !sameBestRoute(last, response):
+			send(api.StreamMessage{Type: api.StreamQuote, ID: id, Block: block, Quote: response})
+			last = response
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case block = <-blocks:
+		}
+	}
+}
+
+// Watch streams quotes for a single request to send until ctx is done,
+// counting as a connection. send may block, blocks that arrive meanwhile are
+// skipped.
+func (s *Service) Watch(ctx context.Context, req api.QuoteRequest, updates string, send func(api.StreamMessage)) error {
+	if !s.acquire() {
+		return ErrTooManyConnections
+	}
+	defer s.release()
+
+	s.watch(ctx, "", req, updates, send)
+	return nil
+}
+
+// ServeWebSocket streams quotes over a WebSocket. Clients send StreamRequest
+// messages to subscribe and unsubscribe and receive StreamMessage updates.
+func (s *Service) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !s.acquire() {
+		writeError(w, http.StatusServiceUnavailable, api.CodeUnavailable, "Too many streaming connections")
+		return
+	}
+	defer s.release()
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already replied
+		logger.WarnContext(r.Context(), "Failed to upgrade quote stream", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	out := newOutbox()
+	go func() {
+		defer cancel()
+		if err := writeWebSocket(ctx, conn, out); err != nil {
+			logger.DebugContext(ctx, "Quote stream closed", "error", err)
+		}
+	}()
+
+	conn.SetReadLimit(maxMessage)
+	conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	subscriptions := make(map[string]context.CancelFunc)
+	defer func() {
+		for _, stop := range subscriptions {
+			stop()
+		}
+	}()
+
+	for {
+		var req api.StreamRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			// Malformed messages are reported, anything else closed the connection
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				out.put("error:", errorMessage("", api.CodeInvalidRequest, "Invalid message: "+err.Error()))
+				continue
+			}
+			return
+		}
+
+		switch req.Type {
+		case api.StreamSubscribe:
+			if req.ID == "" || req.TokenIn == "" || req.TokenOut == "" {
+				out.put("error:"+req.ID, errorMessage(req.ID, api.CodeInvalidRequest, "Missing required field: id, tokenIn or tokenOut"))
+				continue
+			}
+			if req.Updates != "" && req.Updates != api.UpdatesBlock && req.Updates != api.UpdatesChange {
+				out.put("error:"+req.ID, errorMessage(req.ID, api.CodeInvalidRequest, "Invalid updates: must be block or change"))
+				continue
+			}
+			if _, ok := subscriptions[req.ID]; ok {
+				out.put("error:"+req.ID, errorMessage(req.ID, api.CodeInvalidRequest, "Subscription id is already in use"))
+				continue
+			}
+			if s.cfg.MaxSubscriptions > 0 && len(subscriptions) >= s.cfg.MaxSubscriptions {
+				out.put("error:"+req.ID, errorMessage(req.ID, api.CodeLimitExceeded, fmt.Sprintf("At most %d subscriptions per connection", s.cfg.MaxSubscriptions)))
+				continue
+			}
+
+			subCtx, stop := context.WithCancel(ctx)
+			subscriptions[req.ID] = stop
+			out.put("ack:"+req.ID, api.StreamMessage{Type: api.StreamSubscribed, ID: req.ID})
+
+			wg.Add(1)
+			go func(id string, req api.StreamRequest) {
+				defer wg.Done()
+				s.watch(subCtx, id, req.QuoteRequest, req.Updates, func(msg api.StreamMessage) {
+					// Nothing is sent once the client has unsubscribed
+					if subCtx.Err() == nil {
+						out.put("quote:"+id, msg)
+					}
+				})
+			}(req.ID, req)
+
+		case api.StreamUnsubscribe:
+			stop, ok := subscriptions[req.ID]
+			if !ok {
+				out.put("error:"+req.ID, errorMessage(req.ID, api.CodeInvalidRequest, "Unknown subscription id"))
+				continue
+			}
+			stop()
+			delete(subscriptions, req.ID)
+			out.drop("quote:" + req.ID)
+			out.put("ack:"+req.ID, api.StreamMessage{Type: api.StreamUnsubscribed, ID: req.ID})
+
+		default:
+			out.put("error:"+req.ID, errorMessage(req.ID, api.CodeInvalidRequest, fmt.Sprintf("Unknown message type %q", req.Type)))
+		}
+	}
+}
+
+// ServeSSE streams quotes for a single request as server-sent events. Each
+// event is named after its StreamMessage type.
+func (s *Service) ServeSSE(w http.ResponseWriter, r *http.Request, req api.QuoteRequest, updates string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, api.CodeInternal, "Streaming is not supported")
+		return
+	}
+	if !s.acquire() {
+		writeError(w, http.StatusServiceUnavailable, api.CodeUnavailable, "Too many streaming connections")
+		return
+	}
+	defer s.release()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// The connection is released once watch has returned
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	out := newOutbox()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.watch(ctx, "", req, updates, func(msg api.StreamMessage) {
+			out.put("quote", msg)
+		})
+	}()
+
+	// Comments keep proxies from closing an idle stream
+	keepalive := time.NewTicker(pingInterval)
+	defer keepalive.Stop()
+
+	rc := http.NewResponseController(w)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepalive.C:
+			rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-out.ready:
+			for _, msg := range out.drain() {
+				data, err := json.Marshal(msg)
+				if err != nil {
+					logger.ErrorContext(ctx, "Failed to encode stream message", "error", err)
+					continue
+				}
+				rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Type, data); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// upgrader accepts WebSocket connections from any origin, the API doesn't
+// use cookies so there is nothing for another site to ride on
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// writeWebSocket sends queued messages and keepalive pings until ctx is done
+// or a write fails
+func writeWebSocket(ctx context.Context, conn *websocket.Conn, out *outbox) error {
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return err
+			}
+		case <-out.ready:
+			for _, msg := range out.drain() {
+				conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+				if err := conn.WriteJSON(msg); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// outbox queues messages for a connection without blocking the sender. Each
+// key holds one pending message, so a newer quote replaces one the client
+// hasn't received yet instead of queueing behind it.
+type outbox struct {
+	mu      sync.Mutex
+	pending map[string]api.StreamMessage
+	order   []string
+	ready   chan struct{}
+}
+
+// newOutbox creates an empty outbox
+func newOutbox() *outbox {
+	return &outbox{
+		pending: make(map[string]api.StreamMessage),
+		ready:   make(chan struct{}, 1),
+	}
+}
+
+// put queues msg under key, replacing any message pending under it
+func (o *outbox) put(key string, msg api.StreamMessage) {
+	o.mu.Lock()
+	if _, ok := o.pending[key]; !ok {
+		o.order = append(o.order, key)
+	}
+	o.pending[key] = msg
+	o.mu.Unlock()
+
+	select {
+	case o.ready <- struct{}{}:
+	default:
+	}
+}
+
+// drop discards the message pending under key
+func (o *outbox) drop(key string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.pending, key)
+}
+
+// drain returns the pending messages in the order they were first queued
+func (o *outbox) drain() []api.StreamMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	messages := make([]api.StreamMessage, 0, len(o.pending))
+	for _, key := range o.order {
+		if msg, ok := o.pending[key]; ok {
+			messages = append(messages, msg)
+		}
+	}
+	o.pending = make(map[string]api.StreamMessage)
+	o.order = o.order[:0]
+	return messages
+}
+
+// errorMessage builds an error message for a subscription
+func errorMessage(id, code, message string) api.StreamMessage {
+	return api.StreamMessage{Type: api.StreamError, ID: id, Error: &api.Error{Code: code, Message: message}}
+}
+
+// writeError replies with the API's error envelope before a stream starts
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(api.NewErrorResponse(code, message))
+}
+
+// sameBestRoute reports whether two quotes have the same best route and output
+func sameBestRoute(a, b *api.QuoteResponse) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	return a.BestRoute.Protocol == b.BestRoute.Protocol &&
+		a.BestRoute.PoolAddress == b.BestRoute.PoolAddress &&
+		a.BestRoute.Fee == b.BestRoute.Fee &&
+		a.BestRoute.AmountOut.Cmp(b.BestRoute.AmountOut) == 0
+}
@@ -0,0 +1,190 @@
+package stream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/api"
+	"github.com/gorilla/websocket"
+)
+
+// quoteNothing answers every quote with an empty response
+func quoteNothing(ctx context.Context, req api.QuoteRequest) (*api.QuoteResponse, *api.Error) {
+	return &api.QuoteResponse{}, nil
+}
+
+func TestOutbox(t *testing.T) {
+	out := newOutbox()
+	out.put("quote:a", api.StreamMessage{Type: api.StreamQuote, ID: "a", Block: 1})
+	out.put("ack:b", api.StreamMessage{Type: api.StreamSubscribed, ID: "b"})
+	out.put("quote:a", api.StreamMessage{Type: api.StreamQuote, ID: "a", Block: 2})
+	out.put("quote:c", api.StreamMessage{Type: api.StreamQuote, ID: "c", Block: 2})
+	out.drop("quote:c")
+
+	// Puts signal once until the outbox is drained
+	select {
+	case <-out.ready:
+	default:
+		t.Fatal("outbox isn't ready")
+	}
+	select {
+	case <-out.ready:
+		t.Fatal("outbox signalled twice")
+	default:
+	}
+
+	// A newer quote replaces the pending one in its place
+	messages := out.drain()
+	if len(messages) != 2 {
+		t.Fatalf("drain = %+v, want 2 messages", messages)
+	}
+	if messages[0].ID != "a" || messages[0].Block != 2 {
+		t.Errorf("first message = %+v, want the block 2 quote of a", messages[0])
+	}
+	if messages[1].Type != api.StreamSubscribed || messages[1].ID != "b" {
+		t.Errorf("second message = %+v, want the ack of b", messages[1])
+	}
+
+	if messages := out.drain(); len(messages) != 0 {
+		t.Errorf("second drain = %+v, want nothing", messages)
+	}
+	out.put("quote:c", api.StreamMessage{Type: api.StreamQuote, ID: "c"})
+	if messages := out.drain(); len(messages) != 1 || messages[0].ID != "c" {
+		t.Errorf("drain after put = %+v, want the quote of c", messages)
+	}
+}
+
+// dialStream connects to a WebSocket stream served by s
+func dialStream(t *testing.T, s *Service) *websocket.Conn {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(s.ServeWebSocket))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// subscribe sends a subscription and returns the reply to it, skipping quotes
+func subscribe(t *testing.T, conn *websocket.Conn, req api.StreamRequest) api.StreamMessage {
+	t.Helper()
+	if err := conn.WriteJSON(req); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg api.StreamMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Type != api.StreamQuote && msg.ID == req.ID {
+			return msg
+		}
+	}
+}
+
+// subscription builds a subscribe or unsubscribe request
+func subscription(kind, id, updates string) api.StreamRequest {
+	return api.StreamRequest{
+		Type:         kind,
+		ID:           id,
+		Updates:      updates,
+		QuoteRequest: api.QuoteRequest{TokenIn: "0x01", TokenOut: "0x02"},
+	}
+}
+
+func TestSubscriptionLimit(t *testing.T) {
+	s := NewService(NewHeads("", "", time.Hour), quoteNothing, Config{MaxSubscriptions: 2})
+	conn := dialStream(t, s)
+
+	for _, id := range []string{"a", "b"} {
+		if msg := subscribe(t, conn, subscription(api.StreamSubscribe, id, "")); msg.Type != api.StreamSubscribed {
+			t.Fatalf("subscribe %s = %+v, want subscribed", id, msg)
+		}
+	}
+
+	msg := subscribe(t, conn, subscription(api.StreamSubscribe, "c", ""))
+	if msg.Type != api.StreamError || msg.Error == nil || msg.Error.Code != api.CodeLimitExceeded {
+		t.Fatalf("third subscription = %+v, want a %s error", msg, api.CodeLimitExceeded)
+	}
+
+	// Unsubscribing makes room
+	if msg := subscribe(t, conn, subscription(api.StreamUnsubscribe, "a", "")); msg.Type != api.StreamUnsubscribed {
+		t.Fatalf("unsubscribe = %+v, want unsubscribed", msg)
+	}
+	if msg := subscribe(t, conn, subscription(api.StreamSubscribe, "c", "")); msg.Type != api.StreamSubscribed {
+		t.Errorf("subscription after unsubscribing = %+v, want subscribed", msg)
+	}
+}
+
+func TestSubscriptionUpdates(t *testing.T) {
+	tests := []struct {
+		updates string
+		valid   bool
+	}{
+		{"", true},
+		{api.UpdatesBlock, true},
+		{api.UpdatesChange, true},
+		{"sometimes", false},
+		{"Block", false},
+	}
+
+	s := NewService(NewHeads("", "", time.Hour), quoteNothing, Config{})
+	conn := dialStream(t, s)
+	for i, test := range tests {
+		t.Run(test.updates, func(t *testing.T) {
+			id := string(rune('a' + i))
+			msg := subscribe(t, conn, subscription(api.StreamSubscribe, id, test.updates))
+			if test.valid && msg.Type != api.StreamSubscribed {
+				t.Errorf("subscribe = %+v, want subscribed", msg)
+			}
+			if !test.valid && (msg.Type != api.StreamError || msg.Error == nil || msg.Error.Code != api.CodeInvalidRequest) {
+				t.Errorf("subscribe = %+v, want an %s error", msg, api.CodeInvalidRequest)
+			}
+		})
+	}
+}
+
+func TestServeSSEReleasesAfterWatch(t *testing.T) {
+	// Quotes take a while to notice they were cancelled
+	var running atomic.Int32
+	started := make(chan struct{}, 1)
+	slowQuote := func(ctx context.Context, req api.QuoteRequest) (*api.QuoteResponse, *api.Error) {
+		running.Add(1)
+		defer running.Add(-1)
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		return nil, &api.Error{Code: api.CodeInternal, Message: "cancelled"}
+	}
+	s := NewService(NewHeads("", "", time.Hour), slowQuote, Config{MaxConnections: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/quote/stream", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.ServeSSE(httptest.NewRecorder(), r, api.QuoteRequest{TokenIn: "0x01", TokenOut: "0x02"}, api.UpdatesBlock)
+	}()
+
+	<-started
+	cancel()
+	<-done
+	if n := running.Load(); n != 0 {
+		t.Errorf("%d quotes still running after ServeSSE returned", n)
+	}
+	if !s.acquire() {
+		t.Error("connection wasn't released")
+	}
+}
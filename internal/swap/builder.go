@@ -0,0 +1,150 @@
+package swap
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/lmittmann/w3"
+)
+
+// Function signatures for SwapRouter02-compatible routers and the wrapped native token
+var (
+	funcExactInputSingle = w3.MustNewFunc("exactInputSingle((address tokenIn, address tokenOut, uint24 fee, address recipient, uint256 amountIn, uint256 amountOutMinimum, uint160 sqrtPriceLimitX96) params)", "uint256 amountOut")
+	funcUnwrapWETH9      = w3.MustNewFunc("unwrapWETH9(uint256 amountMinimum, address recipient)", "")
+	funcMulticall        = w3.MustNewFunc("multicall(uint256 deadline, bytes[] data)", "bytes[] results")
+	funcDeposit          = w3.MustNewFunc("deposit()", "")
+	funcWithdraw         = w3.MustNewFunc("withdraw(uint256 wad)", "")
+)
+
+// exactInputSingleParams mirrors ISwapRouter02.ExactInputSingleParams
+type exactInputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	AmountIn          *big.Int
+	AmountOutMinimum  *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+// Request describes the swap to build
+type Request struct {
+	Route            aggregator.RouteQuote // Route returned by the aggregator
+	TokenIn          common.Address        // Input token as requested (may be the native placeholder)
+	TokenOut         common.Address        // Output token as requested (may be the native placeholder)
+	AmountIn         *big.Int              // Raw input amount
+	AmountOutMinimum *big.Int              // Raw minimum output after slippage
+	Recipient        common.Address        // Receiver of the output tokens
+	Deadline         *big.Int              // Unix timestamp after which the swap reverts
+}
+
+// Transaction is an unsigned transaction for the user's wallet to sign
+type Transaction struct {
+	To    string `json:"to"`    // Contract to call
+	Data  string `json:"data"`  // Hex encoded calldata
+	Value string `json:"value"` // Native amount to send in wei
+}
+
+// Build encodes the transaction that executes the route. Native input is sent
+// as the transaction value and wrapped by the router; native output is swapped
+// to the router and then unwrapped to the recipient.
+func Build(req Request) (*Transaction, error) {
+	if req.AmountIn == nil || req.AmountIn.Sign() <= 0 {
+		return nil, fmt.Errorf("amount in must be positive")
+	}
+
+	if req.Route.Protocol == aggregator.NativeWrapperProtocol {
+		return buildWrap(req)
+	}
+
+	router := common.HexToAddress(req.Route.Router)
+	if router == (common.Address{}) {
+		return nil, fmt.Errorf("no swap router configured for %s", req.Route.Protocol)
+	}
+
+	amountOutMinimum := req.AmountOutMinimum
+	if amountOutMinimum == nil {
+		amountOutMinimum = big.NewInt(0)
+	}
+
+	// When unwrapping, the router receives the wrapped token and forwards native
+	swapRecipient := req.Recipient
+	if req.Route.UnwrapNative {
+		swapRecipient = router
+	}
+
+	swapData, err := funcExactInputSingle.EncodeArgs(&exactInputSingleParams{
+		TokenIn:           tokens.QuoteAddress(req.TokenIn),
+		TokenOut:          tokens.QuoteAddress(req.TokenOut),
+		Fee:               new(big.Int).SetUint64(req.Route.Fee),
+		Recipient:         swapRecipient,
+		AmountIn:          req.AmountIn,
+		AmountOutMinimum:  amountOutMinimum,
+		SqrtPriceLimitX96: big.NewInt(0),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode swap: %v", err)
+	}
+
+	calls := [][]byte{swapData}
+
+	if req.Route.UnwrapNative {
+		unwrapData, err := funcUnwrapWETH9.EncodeArgs(amountOutMinimum, req.Recipient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode unwrap: %v", err)
+		}
+		calls = append(calls, unwrapData)
+	}
+
+	deadline := req.Deadline
+	if deadline == nil {
+		deadline = big.NewInt(0)
+	}
+
+	data, err := funcMulticall.EncodeArgs(deadline, calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode multicall: %v", err)
+	}
+
+	value := big.NewInt(0)
+	if req.Route.WrapNative {
+		value = req.AmountIn
+	}
+
+	return &Transaction{
+		To:    router.String(),
+		Data:  hexutil.Encode(data),
+		Value: value.String(),
+	}, nil
+}
+
+// buildWrap encodes a direct deposit or withdrawal on the wrapped native token
+func buildWrap(req Request) (*Transaction, error) {
+	wrapped := common.HexToAddress(req.Route.Router)
+
+	if req.Route.WrapNative {
+		data, err := funcDeposit.EncodeArgs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode deposit: %v", err)
+		}
+		return &Transaction{
+			To:    wrapped.String(),
+			Data:  hexutil.Encode(data),
+			Value: req.AmountIn.String(),
+		}, nil
+	}
+
+	data, err := funcWithdraw.EncodeArgs(req.AmountIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode withdraw: %v", err)
+	}
+	return &Transaction{
+		To:    wrapped.String(),
+		Data:  hexutil.Encode(data),
+		Value: "0",
+	}, nil
+}
@@ -0,0 +1,145 @@
+package swap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/aggregator"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/protocols"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	tokenA    = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tokenB    = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	recipient = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	router    = common.HexToAddress("0x00000000000000000000000000000000000000dd")
+)
+
+// decodeMulticall decodes the calls of a multicall transaction
+func decodeMulticall(t *testing.T, tx *Transaction) (*big.Int, [][]byte) {
+	t.Helper()
+	data, err := hexutil.Decode(tx.Data)
+	if err != nil {
+		t.Fatalf("data is not hex: %v", err)
+	}
+	var (
+		deadline *big.Int
+		calls    [][]byte
+	)
+	if err := funcMulticall.DecodeArgs(data, &deadline, &calls); err != nil {
+		t.Fatalf("data is not a multicall: %v", err)
+	}
+	return deadline, calls
+}
+
+func TestBuildSendsToSwapRouter(t *testing.T) {
+	saved := protocols.Protocols["uniswapv3"]
+	t.Cleanup(func() { protocols.Protocols["uniswapv3"] = saved })
+	if err := protocols.SetSwapRouters("uniswapv3=" + router.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	protocol := protocols.Protocols["uniswapv3"]
+
+	tx, err := Build(Request{
+		Route:            aggregator.RouteQuote{Protocol: protocol.Name, Fee: 3000, Router: protocol.SwapRouter()},
+		TokenIn:          tokenA,
+		TokenOut:         tokenB,
+		AmountIn:         big.NewInt(1000),
+		AmountOutMinimum: big.NewInt(990),
+		Recipient:        recipient,
+		Deadline:         big.NewInt(1700000000),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if common.HexToAddress(tx.To) != router {
+		t.Errorf("to = %s, want swap router %s", tx.To, router)
+	}
+	if common.HexToAddress(tx.To) == protocol.RouterAddress {
+		t.Errorf("to = %s, the quoter", tx.To)
+	}
+	if tx.Value != "0" {
+		t.Errorf("value = %s, want 0", tx.Value)
+	}
+
+	deadline, calls := decodeMulticall(t, tx)
+	if deadline.Int64() != 1700000000 {
+		t.Errorf("deadline = %s, want 1700000000", deadline)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("got %d calls, want 1", len(calls))
+	}
+
+	var params exactInputSingleParams
+	if err := funcExactInputSingle.DecodeArgs(calls[0], &params); err != nil {
+		t.Fatalf("call is not exactInputSingle: %v", err)
+	}
+	want := exactInputSingleParams{
+		TokenIn:           tokenA,
+		TokenOut:          tokenB,
+		Fee:               big.NewInt(3000),
+		Recipient:         recipient,
+		AmountIn:          big.NewInt(1000),
+		AmountOutMinimum:  big.NewInt(990),
+		SqrtPriceLimitX96: big.NewInt(0),
+	}
+	if params.TokenIn != want.TokenIn || params.TokenOut != want.TokenOut || params.Recipient != want.Recipient ||
+		params.Fee.Cmp(want.Fee) != 0 || params.AmountIn.Cmp(want.AmountIn) != 0 ||
+		params.AmountOutMinimum.Cmp(want.AmountOutMinimum) != 0 || params.SqrtPriceLimitX96.Sign() != 0 {
+		t.Errorf("params = %+v, want %+v", params, want)
+	}
+}
+
+func TestBuildUnwrapsThroughRouter(t *testing.T) {
+	tx, err := Build(Request{
+		Route:            aggregator.RouteQuote{Protocol: "Uniswap V3", Fee: 500, Router: router.Hex(), UnwrapNative: true},
+		TokenIn:          tokenA,
+		TokenOut:         tokenB,
+		AmountIn:         big.NewInt(1000),
+		AmountOutMinimum: big.NewInt(990),
+		Recipient:        recipient,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, calls := decodeMulticall(t, tx)
+	if len(calls) != 2 {
+		t.Fatalf("got %d calls, want swap and unwrap", len(calls))
+	}
+
+	var params exactInputSingleParams
+	if err := funcExactInputSingle.DecodeArgs(calls[0], &params); err != nil {
+		t.Fatalf("first call is not exactInputSingle: %v", err)
+	}
+	if params.Recipient != router {
+		t.Errorf("swap recipient = %s, want router %s", params.Recipient, router)
+	}
+
+	var (
+		amountMinimum *big.Int
+		to            common.Address
+	)
+	if err := funcUnwrapWETH9.DecodeArgs(calls[1], &amountMinimum, &to); err != nil {
+		t.Fatalf("second call is not unwrapWETH9: %v", err)
+	}
+	if to != recipient || amountMinimum.Int64() != 990 {
+		t.Errorf("unwrap = (%s, %s), want (990, %s)", amountMinimum, to, recipient)
+	}
+}
+
+func TestBuildWithoutSwapRouter(t *testing.T) {
+	_, err := Build(Request{
+		Route:     aggregator.RouteQuote{Protocol: "Uniswap V3", Fee: 3000},
+		TokenIn:   tokenA,
+		TokenOut:  tokenB,
+		AmountIn:  big.NewInt(1000),
+		Recipient: recipient,
+	})
+	if err == nil {
+		t.Fatal("built a swap for a route without a swap router")
+	}
+}
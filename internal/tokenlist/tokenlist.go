@@ -0,0 +1,298 @@
+package tokenlist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var logger = logging.For("tokenlist")
+
+// fetchTimeout bounds downloading a list from a URL
+const fetchTimeout = 30 * time.Second
+
+// maxListSize is the largest list accepted, to avoid reading unbounded responses
+const maxListSize = 10 << 20
+
+var (
+	tagIDPattern  = regexp.MustCompile(`^\w+$`)
+	symbolPattern = regexp.MustCompile(`^\S+$`)
+)
+
+// List is a token list in the Uniswap Token List format (https://tokenlists.org)
+type List struct {
+	Name      string         `json:"name"`
+	Timestamp string         `json:"timestamp"`
+	Version   Version        `json:"version"`
+	Tokens    []Token        `json:"tokens"`
+	Tags      map[string]Tag `json:"tags,omitempty"`
+	LogoURI   string         `json:"logoURI,omitempty"`
+	Keywords  []string       `json:"keywords,omitempty"`
+}
+
+// Version is the semantic version of a list
+type Version struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+// String returns the version as major.minor.patch
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Tag describes a tag id used by the tokens of a list
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Token is a token entry of a list
+type Token struct {
+	ChainID    uint64                 `json:"chainId"`
+	Address    string                 `json:"address"`
+	Name       string                 `json:"name"`
+	Symbol     string                 `json:"symbol"`
+	Decimals   uint8                  `json:"decimals"`
+	LogoURI    string                 `json:"logoURI,omitempty"`
+	Tags       []string               `json:"tags,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Entry is a list token that matches its on-chain metadata
+type Entry struct {
+	Token    Token
+	Address  common.Address
+	Metadata *tokens.Metadata // Metadata read from the token contract
+	Warnings []string         // Differences from the chain that didn't reject the token
+}
+
+// Rejection is a list token that failed validation
+type Rejection struct {
+	Address string `json:"address"`
+	Symbol  string `json:"symbol"`
+	Reason  string `json:"reason"`
+}
+
+// Fetch loads a list from url, keeping a copy at path for offline use. When
+// offline is set or no URL is configured the list is read from path instead,
+// and if the download fails the last copy at path is used.
+func Fetch(ctx context.Context, url, path string, offline bool) (*List, error) {
+	if offline || url == "" {
+		if path == "" {
+			return nil, fmt.Errorf("no token list path configured")
+		}
+		return LoadFile(path)
+	}
+
+	data, err := download(ctx, url)
+	if err != nil {
+		if path == "" {
+			return nil, err
+		}
+		logger.WarnContext(ctx, "Failed to download token list, using saved copy", "url", url, "path", path, "error", err)
+		return LoadFile(path)
+	}
+
+	list, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the last good list for offline mode
+	if path != "" {
+		if err := save(path, data); err != nil {
+			logger.ErrorContext(ctx, "Failed to save token list", "path", path, "error", err)
+		}
+	}
+
+	return list, nil
+}
+
+// LoadFile loads a list from disk
+func LoadFile(path string) (*List, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token list: %v", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a list
+func Parse(data []byte) (*List, error) {
+	var list List
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse token list: %v", err)
+	}
+	if err := list.Validate(); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Validate checks the list's required fields. Individual tokens are checked
+// by Verify so one bad entry doesn't reject the whole list.
+func (l *List) Validate() error {
+	if strings.TrimSpace(l.Name) == "" {
+		return fmt.Errorf("invalid token list: missing name")
+	}
+	if len(l.Tokens) == 0 {
+		return fmt.Errorf("invalid token list: no tokens")
+	}
+	for id := range l.Tags {
+		if !tagIDPattern.MatchString(id) {
+			return fmt.Errorf("invalid token list: invalid tag id %q", id)
+		}
+	}
+	return nil
+}
+
+// Verify checks the list's tokens on chainID against their on-chain metadata.
+// Tokens on other chains are skipped. A token is rejected if its entry is
+// malformed or duplicated, it has no contract, or its decimals differ from
+// the chain; a different name or symbol is only a warning since lists often
+// use a display name (e.g. USDC.e).
+func Verify(ctx context.Context, list *List, chainID uint64, nodeURL string) ([]Entry, []Rejection, error) {
+	var (
+		candidates []Token
+		addresses  []common.Address
+		rejected   []Rejection
+		seen       = make(map[common.Address]bool)
+	)
+
+	for _, token := range list.Tokens {
+		if token.ChainID != chainID {
+			continue
+		}
+		if reason := checkToken(list, token); reason != "" {
+			rejected = append(rejected, Rejection{Address: token.Address, Symbol: token.Symbol, Reason: reason})
+			continue
+		}
+
+		address := common.HexToAddress(token.Address)
+		if seen[address] {
+			rejected = append(rejected, Rejection{Address: token.Address, Symbol: token.Symbol, Reason: "duplicate address"})
+			continue
+		}
+		seen[address] = true
+
+		candidates = append(candidates, token)
+		addresses = append(addresses, address)
+	}
+
+	metadata, errs, err := tokens.ResolveMetadataBatch(ctx, addresses, nodeURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries := make([]Entry, 0, len(candidates))
+	for i, token := range candidates {
+		if errs[i] != nil {
+			rejected = append(rejected, Rejection{Address: token.Address, Symbol: token.Symbol, Reason: errs[i].Error()})
+			continue
+		}
+
+		onChain := metadata[i]
+		entry := Entry{Token: token, Address: addresses[i], Metadata: onChain}
+
+		// Tokens without decimals() take the list's value
+		if !inferred(onChain, tokens.FieldDecimals) && onChain.Decimals != token.Decimals {
+			reason := fmt.Sprintf("decimals %d does not match on-chain %d", token.Decimals, onChain.Decimals)
+			rejected = append(rejected, Rejection{Address: token.Address, Symbol: token.Symbol, Reason: reason})
+			continue
+		}
+		if !inferred(onChain, tokens.FieldSymbol) && !strings.EqualFold(onChain.Symbol, token.Symbol) {
+			entry.Warnings = append(entry.Warnings, fmt.Sprintf("symbol %s differs from on-chain %s", token.Symbol, onChain.Symbol))
+		}
+		if !inferred(onChain, tokens.FieldName) && !strings.EqualFold(onChain.Name, token.Name) {
+			entry.Warnings = append(entry.Warnings, fmt.Sprintf("name %s differs from on-chain %s", token.Name, onChain.Name))
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rejected, nil
+}
+
+// checkToken returns why a token entry is malformed, or "" if it is valid
+func checkToken(list *List, token Token) string {
+	if !common.IsHexAddress(token.Address) {
+		return "invalid address"
+	}
+	if strings.TrimSpace(token.Name) == "" {
+		return "missing name"
+	}
+	if !symbolPattern.MatchString(token.Symbol) {
+		return "invalid symbol"
+	}
+	for _, tag := range token.Tags {
+		if _, ok := list.Tags[tag]; !ok {
+			return fmt.Sprintf("unknown tag %q", tag)
+		}
+	}
+	return ""
+}
+
+// inferred reports whether field was filled in rather than read from the token
+func inferred(metadata *tokens.Metadata, field string) bool {
+	for _, f := range metadata.Inferred {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// download fetches a list from a URL
+func download(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token list request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download token list: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download token list: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxListSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token list: %v", err)
+	}
+	if len(data) > maxListSize {
+		return nil, fmt.Errorf("token list is larger than %d bytes", maxListSize)
+	}
+	return data, nil
+}
+
+// save writes a list to disk, replacing any existing copy atomically
+func save(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
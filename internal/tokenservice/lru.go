@@ -0,0 +1,96 @@
+package tokenservice
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// lru is a fixed-size in-process cache of token metadata in front of the
+// store. Entries expire after ttl so changes made by other instances are
+// picked up.
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[common.Address]*list.Element
+	order *list.List // Most recently used first
+}
+
+// lruItem is a cached token and when it expires
+type lruItem struct {
+	address   common.Address
+	token     models.TokenMetadata
+	expiresAt time.Time
+}
+
+// newLRU creates a cache of up to size tokens, a size of 0 disables it
+func newLRU(size int, ttl time.Duration) *lru {
+	return &lru{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[common.Address]*list.Element),
+		order: list.New(),
+	}
+}
+
+// get returns a copy of a cached token
+func (c *lru) get(address common.Address) (*models.TokenMetadata, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[address]
+	if !ok {
+		return nil, false
+	}
+	item := element.Value.(*lruItem)
+	if c.ttl > 0 && time.Now().After(item.expiresAt) {
+		c.order.Remove(element)
+		delete(c.items, address)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	token := item.token
+	return &token, true
+}
+
+// add caches a token, evicting the least recently used one if full
+func (c *lru) add(token models.TokenMetadata) {
+	if c.size <= 0 {
+		return
+	}
+
+	address := common.HexToAddress(token.Address)
+	item := &lruItem{address: address, token: token, expiresAt: time.Now().Add(c.ttl)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[address]; ok {
+		element.Value = item
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[address] = c.order.PushFront(item)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruItem).address)
+	}
+}
+
+// remove drops a token from the cache
+func (c *lru) remove(address common.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[address]; ok {
+		c.order.Remove(element)
+		delete(c.items, address)
+	}
+}
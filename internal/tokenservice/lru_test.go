@@ -0,0 +1,95 @@
+package tokenservice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	addressA = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	addressB = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	addressC = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+)
+
+func token(address common.Address, symbol string) models.TokenMetadata {
+	return models.TokenMetadata{Address: address.Hex(), Symbol: symbol}
+}
+
+func TestLRU(t *testing.T) {
+	type op struct {
+		add    *models.TokenMetadata // Added if set, else address is read
+		remove bool
+		addr   common.Address
+		want   string // Symbol read, "" if it isn't cached
+	}
+	add := func(address common.Address, symbol string) op {
+		added := token(address, symbol)
+		return op{add: &added}
+	}
+	get := func(address common.Address, want string) op { return op{addr: address, want: want} }
+	remove := func(address common.Address) op { return op{remove: true, addr: address} }
+
+	tests := []struct {
+		name string
+		size int
+		ops  []op
+	}{
+		{"miss", 2, []op{get(addressA, "")}},
+		{"hit", 2, []op{add(addressA, "A"), get(addressA, "A")}},
+		{"evicts oldest", 2, []op{add(addressA, "A"), add(addressB, "B"), add(addressC, "C"), get(addressA, ""), get(addressB, "B"), get(addressC, "C")}},
+		{"get refreshes", 2, []op{add(addressA, "A"), add(addressB, "B"), get(addressA, "A"), add(addressC, "C"), get(addressA, "A"), get(addressB, "")}},
+		{"add replaces", 2, []op{add(addressA, "A"), add(addressB, "B"), add(addressA, "A2"), add(addressC, "C"), get(addressA, "A2"), get(addressB, "")}},
+		{"remove", 2, []op{add(addressA, "A"), remove(addressA), get(addressA, "")}},
+		{"remove missing", 2, []op{remove(addressA), get(addressA, "")}},
+		{"disabled", 0, []op{add(addressA, "A"), get(addressA, "")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newLRU(tt.size, time.Hour)
+			for i, op := range tt.ops {
+				switch {
+				case op.add != nil:
+					cache.add(*op.add)
+				case op.remove:
+					cache.remove(op.addr)
+				default:
+					got, ok := cache.get(op.addr)
+					if ok != (op.want != "") || ok && got.Symbol != op.want {
+						t.Errorf("op %d: get(%s) = %v, %v, want %q", i, op.addr, got, ok, op.want)
+					}
+				}
+			}
+			if cache.order.Len() != len(cache.items) || len(cache.items) > max(tt.size, 0) {
+				t.Errorf("%d items in order, %d in the map, size %d", cache.order.Len(), len(cache.items), tt.size)
+			}
+		})
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	cache := newLRU(2, 10*time.Millisecond)
+	cache.add(token(addressA, "A"))
+	time.Sleep(20 * time.Millisecond)
+
+	if got, ok := cache.get(addressA); ok {
+		t.Errorf("get after ttl = %v, want a miss", got)
+	}
+	if len(cache.items) != 0 {
+		t.Errorf("expired token kept, %d items", len(cache.items))
+	}
+}
+
+func TestLRUGetReturnsCopy(t *testing.T) {
+	cache := newLRU(2, time.Hour)
+	cache.add(token(addressA, "A"))
+
+	got, _ := cache.get(addressA)
+	got.Symbol = "changed"
+	if again, _ := cache.get(addressA); again.Symbol != "A" {
+		t.Errorf("cached symbol = %q after changing a copy, want A", again.Symbol)
+	}
+}
@@ -0,0 +1,267 @@
+package tokenservice
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/logging"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/metrics"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/risk"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/storage"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var logger = logging.For("tokenservice")
+
+// Where token metadata was found
+const (
+	SourceCache      = "cache"
+	SourceBlockchain = "blockchain"
+)
+
+// Config configures how long token metadata is cached
+type Config struct {
+	TTL     time.Duration // Tokens read from the chain
+	MissTTL time.Duration // Addresses that aren't tokens
+	LRUSize int           // Tokens kept in process, 0 disables the in-process cache
+	LRUTTL  time.Duration // How long a token is kept in process
+}
+
+// Service resolves token metadata through an in-process LRU and the store.
+// Resolved tokens are stored for TTL and addresses that aren't tokens for
+// MissTTL; tokens from a token list never expire.
+type Service struct {
+	store   storage.TokenRepository
+	nodeURL string
+	ttl     time.Duration
+	missTTL time.Duration
+	local   *lru
+}
+
+// NewService creates a new token metadata service
+func NewService(store storage.TokenRepository, nodeURL string, cfg Config) *Service {
+	return &Service{
+		store:   store,
+		nodeURL: nodeURL,
+		ttl:     cfg.TTL,
+		missTTL: cfg.MissTTL,
+		local:   newLRU(cfg.LRUSize, cfg.LRUTTL),
+	}
+}
+
+// Get returns the metadata of a token and where it was found, reading it from
+// the chain and caching it if it isn't cached. Addresses that aren't tokens
+// return an error wrapping tokens.ErrNotToken.
+func (s *Service) Get(ctx context.Context, address common.Address) (*models.TokenMetadata, string, error) {
+	metadata, sources, errs, err := s.GetBatch(ctx, []common.Address{address})
+	if err != nil {
+		return nil, "", err
+	}
+	if errs[0] != nil {
+		return nil, "", errs[0]
+	}
+	return metadata[0], sources[0], nil
+}
+
+// GetBatch returns the metadata of many tokens, reading cache hits in one
+// round trip and the misses from the chain in one JSON-RPC batch. Results,
+// sources and per-token errors are in the same order as addresses; the
+// returned error is only set if the node can't be reached.
+func (s *Service) GetBatch(ctx context.Context, addresses []common.Address) ([]*models.TokenMetadata, []string, []error, error) {
+	metadata := make([]*models.TokenMetadata, len(addresses))
+	sources := make([]string, len(addresses))
+	errs := make([]error, len(addresses))
+
+	// Check the in-process cache first
+	var stored []int
+	for i, address := range addresses {
+		if token, ok := s.local.get(address); ok {
+			metadata[i], sources[i] = token, SourceCache
+			continue
+		}
+		stored = append(stored, i)
+	}
+	metrics.TokenCache(metrics.CacheLocal, len(addresses)-len(stored), len(stored))
+	if len(stored) == 0 {
+		return metadata, sources, errs, nil
+	}
+
+	// A store failure shouldn't fail the lookup, everything is read from the chain
+	storedAddresses := pick(addresses, stored)
+	cached, err := s.store.GetTokens(ctx, storedAddresses)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to read token cache", "error", err)
+		cached = make([]*models.TokenMetadata, len(stored))
+	}
+
+	var misses []int
+	for j, i := range stored {
+		if token := cached[j]; token != nil {
+			s.local.add(*token)
+			metadata[i], sources[i] = token, SourceCache
+			continue
+		}
+		misses = append(misses, i)
+	}
+	metrics.TokenCache(metrics.CacheStore, len(stored)-len(misses), len(misses))
+	if len(misses) == 0 {
+		return metadata, sources, errs, nil
+	}
+
+	// Skip addresses recently found not to be tokens
+	reasons, err := s.store.GetTokenMisses(ctx, pick(addresses, misses))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to read token cache", "error", err)
+		reasons = make([]string, len(misses))
+	}
+
+	var pending []int
+	for j, i := range misses {
+		if reasons[j] != "" {
+			errs[i] = cachedMiss(reasons[j])
+			sources[i] = SourceCache
+			continue
+		}
+		pending = append(pending, i)
+	}
+	metrics.TokenCache(metrics.CacheNegative, len(misses)-len(pending), len(pending))
+	if len(pending) == 0 {
+		return metadata, sources, errs, nil
+	}
+
+	resolved, resolveErrs, err := tokens.ResolveMetadataBatch(ctx, pick(addresses, pending), s.nodeURL)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var fetched []models.TokenMetadata
+	for j, i := range pending {
+		sources[i] = SourceBlockchain
+		if resolveErrs[j] != nil {
+			errs[i] = resolveErrs[j]
+			if errors.Is(resolveErrs[j], tokens.ErrNotToken) {
+				if err := s.store.SaveTokenMiss(ctx, addresses[i], resolveErrs[j].Error(), s.missTTL); err != nil {
+					logger.ErrorContext(ctx, "Failed to cache token miss", "error", err)
+				}
+			}
+			continue
+		}
+
+		token := newTokenMetadata(addresses[i], resolved[j])
+		s.local.add(token)
+		metadata[i] = &token
+		fetched = append(fetched, token)
+	}
+
+	if len(fetched) > 0 {
+		if err := s.store.SaveTokens(ctx, fetched, s.ttl); err != nil {
+			logger.ErrorContext(ctx, "Failed to cache token metadata", "error", err)
+		}
+	}
+
+	return metadata, sources, errs, nil
+}
+
+// Save caches token metadata, e.g. after it has been screened
+func (s *Service) Save(ctx context.Context, token models.TokenMetadata) error {
+	s.local.add(token)
+	return s.store.SaveToken(ctx, token, s.expiry(token))
+}
+
+// SaveScreening caches the behaviour and risk of a token with its metadata
+func (s *Service) SaveScreening(ctx context.Context, address common.Address, behaviour *tokens.Behaviour, report *risk.Report) error {
+	token, _, err := s.Get(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	screened := *token
+	screened.Behaviour, screened.Risk = behaviour, report
+	return s.Save(ctx, screened)
+}
+
+// Refresh reads the metadata of a token from the chain again and caches it.
+// Token list fields are kept, screening results are dropped so the token is
+// screened again.
+func (s *Service) Refresh(ctx context.Context, address common.Address) (*models.TokenMetadata, error) {
+	existing, err := s.store.GetToken(ctx, address)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to read token cache", "error", err)
+	}
+
+	resolved, resolveErr := tokens.ResolveMetadata(ctx, address, s.nodeURL)
+	if resolveErr != nil {
+		s.local.remove(address)
+		if errors.Is(resolveErr, tokens.ErrNotToken) {
+			if err := s.store.DeleteToken(ctx, address); err != nil {
+				logger.ErrorContext(ctx, "Failed to delete token metadata", "token", address.Hex(), "error", err)
+			}
+			if err := s.store.SaveTokenMiss(ctx, address, resolveErr.Error(), s.missTTL); err != nil {
+				logger.ErrorContext(ctx, "Failed to cache token miss", "error", err)
+			}
+		}
+		return nil, resolveErr
+	}
+
+	token := newTokenMetadata(address, resolved)
+	if existing != nil && existing.Curated {
+		token.Name, token.Symbol = existing.Name, existing.Symbol
+		token.LogoURI, token.Tags, token.Warnings, token.Curated = existing.LogoURI, existing.Tags, existing.Warnings, true
+	}
+
+	if err := s.Save(ctx, token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Invalidate removes a token, or the record that it isn't a token, from the
+// cache so the next lookup reads it from the chain. A curated token loses its
+// token list fields until the list is imported again, use Refresh to keep them.
+func (s *Service) Invalidate(ctx context.Context, address common.Address) error {
+	s.local.remove(address)
+	return s.store.DeleteToken(ctx, address)
+}
+
+// pick returns the addresses at the given indexes
+func pick(addresses []common.Address, indexes []int) []common.Address {
+	picked := make([]common.Address, len(indexes))
+	for j, i := range indexes {
+		picked[j] = addresses[i]
+	}
+	return picked
+}
+
+// cachedMiss is the cached error of an address that isn't a token
+type cachedMiss string
+
+func (e cachedMiss) Error() string {
+	return string(e)
+}
+
+// Is matches tokens.ErrNotToken
+func (e cachedMiss) Is(target error) bool {
+	return target == tokens.ErrNotToken
+}
+
+// expiry returns how long a token is cached for
+func (s *Service) expiry(token models.TokenMetadata) time.Duration {
+	if token.Curated {
+		return 0
+	}
+	return s.ttl
+}
+
+// newTokenMetadata creates the API representation of resolved token metadata
+func newTokenMetadata(address common.Address, resolved *tokens.Metadata) models.TokenMetadata {
+	return models.TokenMetadata{
+		Address:  address.String(),
+		Name:     resolved.Name,
+		Symbol:   resolved.Symbol,
+		Decimals: resolved.Decimals,
+		Inferred: resolved.Inferred,
+	}
+}
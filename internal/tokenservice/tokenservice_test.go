@@ -0,0 +1,264 @@
+package tokenservice
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/node/nodetest"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/clients/t"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/models"
+	"github.com/bitcoinbrisbane/defi-aggregator/internal/storage"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lmittmann/w3"
+)
+
+var (
+	chainToken = common.HexToAddress("0x00000000000000000000000000000000000000d1")
+	account    = common.HexToAddress("0x00000000000000000000000000000000000000d2")
+)
+
+// newTestService creates a service over a memory store and a node where
+// every contract is a token named Chain Token and account has no code
+func newTestService(t *testing.T, cfg Config) (*Service, *storage.MemoryStore, *nodetest.Node) {
+	node := nodetest.New(t)
+	node.Handle(nodetest.AnyContract, w3.MustNewFunc("name()", "string"), nodetest.Returns("Chain Token"))
+	node.Handle(nodetest.AnyContract, w3.MustNewFunc("symbol()", "string"), nodetest.Returns("CHAIN"))
+	node.Handle(nodetest.AnyContract, w3.MustNewFunc("decimals()", "uint256"), nodetest.Returns(big.NewInt(18)))
+	node.SetNoCode(account)
+
+	store := storage.NewMemoryStore()
+	return NewService(store, node.URL, cfg), store, node
+}
+
+// requests returns the number of HTTP requests the node has served
+func requests(node *nodetest.Node) int {
+	_, batches := node.Requests()
+	return batches
+}
+
+// get looks up a token and checks where it was found
+func get(t *testing.T, s *Service, address common.Address, wantSource string) *models.TokenMetadata {
+	t.Helper()
+	token, source, err := s.Get(context.Background(), address)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", address.Hex(), err)
+	}
+	if source != wantSource {
+		t.Errorf("Get(%s) source = %s, want %s", address.Hex(), source, wantSource)
+	}
+	return token
+}
+
+// getMiss looks up an address that isn't a token and checks where the miss
+// was found
+func getMiss(t *testing.T, s *Service, address common.Address, wantSource string) {
+	t.Helper()
+	_, sources, errs, err := s.GetBatch(context.Background(), []common.Address{address})
+	if err != nil {
+		t.Fatalf("GetBatch(%s): %v", address.Hex(), err)
+	}
+	if !errors.Is(errs[0], tokens.ErrNotToken) {
+		t.Errorf("GetBatch(%s) error = %v, want %v", address.Hex(), errs[0], tokens.ErrNotToken)
+	}
+	if sources[0] != wantSource {
+		t.Errorf("GetBatch(%s) source = %s, want %s", address.Hex(), sources[0], wantSource)
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	ctx := context.Background()
+	s, store, node := newTestService(t, Config{TTL: time.Hour, MissTTL: time.Hour, LRUSize: 10, LRUTTL: time.Hour})
+
+	// The store is read before the chain
+	stored := models.TokenMetadata{Address: chainToken.Hex(), Name: "Stored Token", Symbol: "STORED", Decimals: 6}
+	if err := store.SaveToken(ctx, stored, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if token := get(t, s, chainToken, SourceCache); token.Symbol != "STORED" {
+		t.Errorf("symbol = %s, want STORED", token.Symbol)
+	}
+
+	// The LRU is read before the store
+	if err := store.DeleteToken(ctx, chainToken); err != nil {
+		t.Fatal(err)
+	}
+	if token := get(t, s, chainToken, SourceCache); token.Symbol != "STORED" {
+		t.Errorf("symbol = %s, want STORED from the LRU", token.Symbol)
+	}
+	if requests(node) != 0 {
+		t.Fatalf("node requests = %d, want 0", requests(node))
+	}
+
+	// The negative cache is read before the chain
+	if err := store.SaveTokenMiss(ctx, account, "not a token", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	getMiss(t, s, account, SourceCache)
+	if requests(node) != 0 {
+		t.Fatalf("node requests = %d, want 0", requests(node))
+	}
+
+	// Misses are read from the chain and saved
+	other := common.HexToAddress("0x00000000000000000000000000000000000000d3")
+	if token := get(t, s, other, SourceBlockchain); token.Symbol != "CHAIN" {
+		t.Errorf("symbol = %s, want CHAIN", token.Symbol)
+	}
+	if saved, _ := store.GetToken(ctx, other); saved == nil || saved.Symbol != "CHAIN" {
+		t.Errorf("saved = %+v, want the chain token", saved)
+	}
+	if requests(node) != 1 {
+		t.Errorf("node requests = %d, want 1", requests(node))
+	}
+}
+
+func TestGetBatchNegativeCache(t *testing.T) {
+	ctx := context.Background()
+	s, store, node := newTestService(t, Config{TTL: time.Hour, MissTTL: time.Hour})
+
+	// The first lookup finds the account isn't a token and caches it
+	_, sources, errs, err := s.GetBatch(ctx, []common.Address{account, chainToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(errs[0], tokens.ErrNotToken) || sources[0] != SourceBlockchain {
+		t.Fatalf("account = %s, %v, want a miss from the chain", sources[0], errs[0])
+	}
+	if errs[1] != nil {
+		t.Fatalf("token: %v", errs[1])
+	}
+	if reasons, _ := store.GetTokenMisses(ctx, []common.Address{account}); reasons[0] == "" {
+		t.Error("miss wasn't cached")
+	}
+
+	// The second is answered from the cache
+	_, sources, errs, err = s.GetBatch(ctx, []common.Address{account, chainToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(errs[0], tokens.ErrNotToken) || sources[0] != SourceCache {
+		t.Errorf("account = %s, %v, want a cached miss", sources[0], errs[0])
+	}
+	if sources[1] != SourceCache {
+		t.Errorf("token source = %s, want %s", sources[1], SourceCache)
+	}
+	if requests(node) != 1 {
+		t.Errorf("node requests = %d, want 1", requests(node))
+	}
+}
+
+func TestGetExpiry(t *testing.T) {
+	const ttl = 50 * time.Millisecond
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		address common.Address
+	}{
+		{"store", Config{TTL: ttl, MissTTL: time.Hour}, chainToken},
+		{"lru", Config{TTL: ttl, MissTTL: time.Hour, LRUSize: 10, LRUTTL: ttl}, chainToken},
+		{"negative cache", Config{TTL: time.Hour, MissTTL: ttl}, account},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, _, node := newTestService(t, test.cfg)
+			lookup := func() string {
+				_, sources, _, err := s.GetBatch(context.Background(), []common.Address{test.address})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return sources[0]
+			}
+
+			if source := lookup(); source != SourceBlockchain {
+				t.Fatalf("first source = %s, want %s", source, SourceBlockchain)
+			}
+			if source := lookup(); source != SourceCache {
+				t.Fatalf("second source = %s, want %s", source, SourceCache)
+			}
+			time.Sleep(2 * ttl)
+			if source := lookup(); source != SourceBlockchain {
+				t.Errorf("source after expiry = %s, want %s", source, SourceBlockchain)
+			}
+			if requests(node) != 2 {
+				t.Errorf("node requests = %d, want 2", requests(node))
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	s, store, _ := newTestService(t, Config{TTL: time.Hour, MissTTL: time.Hour, LRUSize: 10, LRUTTL: time.Hour})
+
+	curated := models.TokenMetadata{
+		Address:   chainToken.Hex(),
+		Name:      "Listed Token",
+		Symbol:    "LISTED",
+		Decimals:  6,
+		LogoURI:   "https://example.com/listed.png",
+		Curated:   true,
+		Behaviour: &models.TokenBehaviour{FeeOnTransfer: true},
+	}
+	if err := s.Save(ctx, curated); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := s.Refresh(ctx, chainToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Name != "Listed Token" || token.Symbol != "LISTED" || token.LogoURI != curated.LogoURI || !token.Curated {
+		t.Errorf("token list fields weren't kept: %+v", token)
+	}
+	if token.Decimals != 18 {
+		t.Errorf("decimals = %d, want 18 from the chain", token.Decimals)
+	}
+	if token.Behaviour != nil {
+		t.Errorf("behaviour = %+v, want it dropped", token.Behaviour)
+	}
+	if cached := get(t, s, chainToken, SourceCache); cached.Decimals != 18 {
+		t.Errorf("cached decimals = %d, want 18", cached.Decimals)
+	}
+
+	// A token that's now an account is removed and cached as a miss
+	if err := s.Save(ctx, models.TokenMetadata{Address: account.Hex(), Symbol: "GONE"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refresh(ctx, account); !errors.Is(err, tokens.ErrNotToken) {
+		t.Fatalf("Refresh(account) = %v, want %v", err, tokens.ErrNotToken)
+	}
+	if stored, _ := store.GetToken(ctx, account); stored != nil {
+		t.Errorf("stored = %+v, want it deleted", stored)
+	}
+	getMiss(t, s, account, SourceCache)
+}
+
+func TestInvalidate(t *testing.T) {
+	ctx := context.Background()
+	s, store, node := newTestService(t, Config{TTL: time.Hour, MissTTL: time.Hour, LRUSize: 10, LRUTTL: time.Hour})
+
+	if err := s.Save(ctx, models.TokenMetadata{Address: chainToken.Hex(), Symbol: "OLD"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveTokenMiss(ctx, account, "not a token", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, address := range []common.Address{chainToken, account} {
+		if err := s.Invalidate(ctx, address); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Both are read from the chain again, the LRU included
+	if token := get(t, s, chainToken, SourceBlockchain); token.Symbol != "CHAIN" {
+		t.Errorf("symbol = %s, want CHAIN", token.Symbol)
+	}
+	getMiss(t, s, account, SourceBlockchain)
+	if requests(node) != 2 {
+		t.Errorf("node requests = %d, want 2", requests(node))
+	}
+}
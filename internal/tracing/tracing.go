@@ -0,0 +1,84 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are exported over OTLP
+// gRPC when a collector endpoint is configured.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is the service spans are reported under, unless OTEL_SERVICE_NAME is set
+const ServiceName = "defi-aggregator"
+
+// instrumentation is the name of the tracer every span is started with
+const instrumentation = "github.com/bitcoinbrisbane/defi-aggregator"
+
+// Init exports spans to the OTLP collector at endpoint, e.g.
+// http://localhost:4317. The sampler is configured with the standard
+// OTEL_TRACES_SAMPLER variables. Without an endpoint spans are still created,
+// so requests get trace IDs, but aren't exported. The returned function
+// flushes and stops the exporter.
+func Init(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	// Attributes from OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME win
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %v", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if endpoint != "" {
+		exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %v", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start starts a span as a child of the span in ctx
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, opts...)
+}
+
+// End ends a span, marking it failed if err is set
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID returns the ID of the trace in ctx, or "" if there isn't one
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
+
+// Traced reports whether ctx is part of a trace, so work done outside of any
+// request doesn't start traces of its own
+func Traced(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode decides what happens to digits beyond an amount's decimals
+type RoundingMode int
+
+const (
+	RoundExact    RoundingMode = iota // Reject amounts that would lose digits
+	RoundDown                         // Towards zero
+	RoundUp                           // Away from zero
+	RoundHalfUp                       // To nearest, ties away from zero
+	RoundHalfEven                     // To nearest, ties to the even neighbour
+)
+
+// maxExponent bounds scientific notation so parsing can't allocate huge numbers
+const maxExponent = 256
+
+var decimalPattern = regexp.MustCompile(`^([+-]?)(\d*)(?:\.(\d*))?(?:[eE]([+-]?\d+))?$`)
+
+// Amount is an exact token amount: an integer in the token's smallest unit
+// and the token's decimals. The zero value is zero with no decimals.
+type Amount struct {
+	raw      *big.Int
+	decimals uint8
+}
+
+// NewAmount creates an amount from an integer in the token's smallest unit
+func NewAmount(raw *big.Int, decimals uint8) Amount {
+	if raw == nil {
+		return Amount{decimals: decimals}
+	}
+	return Amount{raw: new(big.Int).Set(raw), decimals: decimals}
+}
+
+// ParseDecimal parses a decimal such as "1.5", "-0.25" or "1.5e3" into an
+// amount with the given decimals, rounding extra fraction digits with mode
+func ParseDecimal(s string, decimals uint8, mode RoundingMode) (Amount, error) {
+	match := decimalPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil || match[2]+match[3] == "" {
+		return Amount{}, fmt.Errorf("invalid amount: %s", s)
+	}
+	sign, intPart, fracPart, expPart := match[1], match[2], match[3], match[4]
+
+	exponent := 0
+	if expPart != "" {
+		var err error
+		exponent, err = strconv.Atoi(expPart)
+		if err != nil || exponent > maxExponent || exponent < -maxExponent {
+			return Amount{}, fmt.Errorf("invalid amount: exponent out of range: %s", s)
+		}
+	}
+
+	digits, _ := new(big.Int).SetString(intPart+fracPart, 10)
+	if sign == "-" {
+		digits.Neg(digits)
+	}
+
+	// digits * 10^(exponent - len(fracPart)) in units of 10^-decimals
+	shift := int(decimals) + exponent - len(fracPart)
+	if shift >= 0 {
+		return Amount{raw: digits.Mul(digits, Pow10(shift)), decimals: decimals}, nil
+	}
+
+	raw, err := divRound(digits, Pow10(-shift), mode)
+	if err != nil {
+		return Amount{}, fmt.Errorf("amount %s has more than %d decimal places", s, decimals)
+	}
+	return Amount{raw: raw, decimals: decimals}, nil
+}
+
+// Raw returns the amount in the token's smallest unit
+func (a Amount) Raw() *big.Int {
+	if a.raw == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(a.raw)
+}
+
+// Decimals returns the token's decimals
+func (a Amount) Decimals() uint8 {
+	return a.decimals
+}
+
+// Sign returns -1, 0 or 1 for negative, zero and positive amounts
+func (a Amount) Sign() int {
+	if a.raw == nil {
+		return 0
+	}
+	return a.raw.Sign()
+}
+
+// Cmp compares two amounts exactly, even if their decimals differ
+func (a Amount) Cmp(b Amount) int {
+	x, y := a.Raw(), b.Raw()
+	if a.decimals < b.decimals {
+		x.Mul(x, Pow10(int(b.decimals-a.decimals)))
+	} else if b.decimals < a.decimals {
+		y.Mul(y, Pow10(int(a.decimals-b.decimals)))
+	}
+	return x.Cmp(y)
+}
+
+// Rescale converts the amount to other decimals, rounding with mode if
+// digits are dropped
+func (a Amount) Rescale(decimals uint8, mode RoundingMode) (Amount, error) {
+	raw := a.Raw()
+	if decimals >= a.decimals {
+		return Amount{raw: raw.Mul(raw, Pow10(int(decimals-a.decimals))), decimals: decimals}, nil
+	}
+
+	raw, err := divRound(raw, Pow10(int(a.decimals-decimals)), mode)
+	if err != nil {
+		return Amount{}, fmt.Errorf("amount %s has more than %d decimal places", a, decimals)
+	}
+	return Amount{raw: raw, decimals: decimals}, nil
+}
+
+// String returns the amount in whole tokens without trailing zeros, e.g. "1.5"
+func (a Amount) String() string {
+	raw := a.Raw()
+	sign := ""
+	if raw.Sign() < 0 {
+		sign = "-"
+		raw.Abs(raw)
+	}
+
+	intPart, fracPart := new(big.Int).QuoRem(raw, Pow10(int(a.decimals)), new(big.Int))
+	if fracPart.Sign() == 0 {
+		return sign + intPart.String()
+	}
+
+	frac := fmt.Sprintf("%0*s", a.decimals, fracPart.String())
+	return sign + intPart.String() + "." + strings.TrimRight(frac, "0")
+}
+
+// amountJSON is how amounts are encoded
+type amountJSON struct {
+	Raw      string `json:"raw"`
+	Display  string `json:"display"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// MarshalJSON encodes the amount as both its raw integer and display strings
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(amountJSON{Raw: a.Raw().String(), Display: a.String(), Decimals: a.decimals})
+}
+
+// UnmarshalJSON decodes an amount from its raw integer and decimals
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var decoded amountJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	raw, ok := new(big.Int).SetString(decoded.Raw, 10)
+	if !ok {
+		return fmt.Errorf("invalid raw amount: %q", decoded.Raw)
+	}
+	*a = Amount{raw: raw, decimals: decoded.Decimals}
+	return nil
+}
+
+// divRound divides n by d, rounding the quotient with mode
+func divRound(n, d *big.Int, mode RoundingMode) (*big.Int, error) {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() == 0 {
+		return q, nil
+	}
+
+	// Compare the remainder to half the divisor to round to nearest
+	half := new(big.Int).Abs(r)
+	half.Mul(half, big.NewInt(2))
+	cmpHalf := half.Cmp(d)
+
+	away := false
+	switch mode {
+	case RoundExact:
+		return nil, fmt.Errorf("amount can't be represented exactly")
+	case RoundDown:
+	case RoundUp:
+		away = true
+	case RoundHalfUp:
+		away = cmpHalf >= 0
+	case RoundHalfEven:
+		away = cmpHalf > 0 || cmpHalf == 0 && q.Bit(0) == 1
+	default:
+		return nil, fmt.Errorf("unknown rounding mode %d", mode)
+	}
+
+	if away {
+		q.Add(q, big.NewInt(int64(n.Sign())))
+	}
+	return q, nil
+}
+
+// Pow10 returns 10^n
+func Pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseDecimalRounding(t *testing.T) {
+	tests := []struct {
+		amount string
+		mode   RoundingMode
+		want   string // Raw amount with 1 decimal, "" if the amount is rejected
+	}{
+		{"1.25", RoundExact, ""},
+		{"1.25", RoundDown, "12"},
+		{"1.25", RoundUp, "13"},
+		{"1.25", RoundHalfUp, "13"},
+		{"1.25", RoundHalfEven, "12"},
+		{"1.35", RoundHalfEven, "14"},
+		{"1.24", RoundHalfUp, "12"},
+		{"1.26", RoundHalfEven, "13"},
+		{"-1.25", RoundDown, "-12"},
+		{"-1.25", RoundUp, "-13"},
+		{"-1.25", RoundHalfUp, "-13"},
+		{"-1.25", RoundHalfEven, "-12"},
+		{"1.20", RoundExact, "12"},
+	}
+	for _, test := range tests {
+		got, err := ParseDecimal(test.amount, 1, test.mode)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("ParseDecimal(%q, 1, %d) = %s, want an error", test.amount, test.mode, got.Raw())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDecimal(%q, 1, %d): %v", test.amount, test.mode, err)
+			continue
+		}
+		if got.Raw().String() != test.want {
+			t.Errorf("ParseDecimal(%q, 1, %d) = %s, want %s", test.amount, test.mode, got.Raw(), test.want)
+		}
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	tests := []struct {
+		raw      int64
+		decimals uint8
+		want     string
+	}{
+		{1500000, 6, "1.5"},
+		{1, 6, "0.000001"},
+		{1000000, 6, "1"},
+		{-25, 2, "-0.25"},
+		{0, 18, "0"},
+		{42, 0, "42"},
+	}
+	for _, test := range tests {
+		if got := NewAmount(big.NewInt(test.raw), test.decimals).String(); got != test.want {
+			t.Errorf("NewAmount(%d, %d) = %s, want %s", test.raw, test.decimals, got, test.want)
+		}
+	}
+}
@@ -3,88 +3,51 @@ package utils
 import (
 	"fmt"
 	"math/big"
+	"strings"
 )
 
 // FromWei converts a wei amount to a human-readable decimal string based on the token's decimals
 func FromWei(amount *big.Int, decimals uint8) string {
-	if amount == nil {
-		return "0"
-	}
-	
-	// Create a copy of the amount
-	wei := new(big.Int).Set(amount)
-	
-	// Convert to a decimal string based on decimals
-	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
-	
-	// Integer part
-	intPart := new(big.Int).Div(wei, divisor)
-	
-	// Fractional part
-	fracPart := new(big.Int).Mod(wei, divisor)
-	
-	// Format fractional part with leading zeros
-	fracStr := fmt.Sprintf("%0*s", decimals, fracPart.String())
-	
-	// Trim trailing zeros
-	for len(fracStr) > 0 && fracStr[len(fracStr)-1] == '0' {
-		fracStr = fracStr[:len(fracStr)-1]
-	}
-	
-	if len(fracStr) > 0 {
-		return fmt.Sprintf("%s.%s", intPart.String(), fracStr)
-	}
-	
-	return intPart.String()
+	return NewAmount(amount, decimals).String()
 }
 
-// ToWei converts a human-readable decimal string to wei based on the token's decimals
+// ToWei converts a human-readable decimal string to wei based on the token's
+// decimals. Amounts with more fraction digits than decimals are rejected
+// rather than truncated.
 func ToWei(amount string, decimals uint8) (*big.Int, error) {
-	// Parse the decimal amount
-	parts := splitDecimal(amount)
-	intPart, fracPart := parts[0], ""
-	if len(parts) > 1 {
-		fracPart = parts[1]
+	parsed, err := ParseDecimal(amount, decimals, RoundExact)
+	if err != nil {
+		return nil, err
 	}
-	
-	// Parse integer part
-	intValue, ok := new(big.Int).SetString(intPart, 10)
-	if !ok {
-		return nil, fmt.Errorf("invalid integer part: %s", intPart)
+	return parsed.Raw(), nil
+}
+
+// IsUint256 reports whether n fits in a uint256, the type of token amounts on chain
+func IsUint256(n *big.Int) bool {
+	return n.Sign() >= 0 && n.BitLen() <= 256
+}
+
+// ParseAmount converts a human-readable amount such as "1.5" to wei. Unlike
+// ToWei it rejects negative and zero amounts, and amounts that don't fit in a
+// uint256.
+func ParseAmount(amount string, decimals uint8) (*big.Int, error) {
+	amount = strings.TrimSpace(amount)
+	if amount == "" {
+		return nil, fmt.Errorf("amount is empty")
 	}
-	
-	// Multiply by 10^decimals
-	multiplier := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
-	intValue.Mul(intValue, multiplier)
-	
-	// Add fractional part if it exists
-	if fracPart != "" {
-		// Pad or truncate fractional part to match decimals
-		if len(fracPart) > int(decimals) {
-			fracPart = fracPart[:decimals]
-		} else {
-			fracPart = fracPart + "0000000000000000000"[:int(decimals)-len(fracPart)]
-		}
-		
-		// Parse fractional part
-		fracValue, ok := new(big.Int).SetString(fracPart, 10)
-		if !ok {
-			return nil, fmt.Errorf("invalid fractional part: %s", fracPart)
-		}
-		
-		// Add to result
-		intValue.Add(intValue, fracValue)
+	if strings.HasPrefix(amount, "-") {
+		return nil, fmt.Errorf("amount must be positive: %s", amount)
 	}
-	
-	return intValue, nil
-}
 
-// splitDecimal splits a decimal string into integer and fractional parts
-func splitDecimal(s string) []string {
-	for i := 0; i < len(s); i++ {
-		if s[i] == '.' {
-			return []string{s[:i], s[i+1:]}
-		}
+	parsed, err := ParseDecimal(amount, decimals, RoundExact)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Sign() == 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
 	}
-	return []string{s}
-}
\ No newline at end of file
+	if !IsUint256(parsed.Raw()) {
+		return nil, fmt.Errorf("amount is too large: %s", amount)
+	}
+	return parsed.Raw(), nil
+}
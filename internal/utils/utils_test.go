@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+	tests := []struct {
+		name     string
+		amount   string
+		decimals uint8
+		want     string // Raw amount, "" if the amount is rejected
+	}{
+		{"whole", "1", 18, "1000000000000000000"},
+		{"fraction", "1.5", 6, "1500000"},
+		{"padded fraction", "0.000001", 6, "1"},
+		{"trailing zeros", "1.500000000", 6, "1500000"},
+		{"leading zeros", "007.25", 2, "725"},
+		{"spaces", " 2 ", 0, "2"},
+		{"no integer part", ".5", 1, "5"},
+		{"no fraction part", "5.", 1, "50"},
+		{"exponent", "1.5e3", 0, "1500"},
+		{"negative exponent", "15e-1", 1, "15"},
+		{"zero decimals", "42", 0, "42"},
+		{"too many decimals", "1.0000001", 6, ""},
+		{"rounds to nothing", "0.0000001", 6, ""},
+		{"zero", "0", 18, ""},
+		{"zero fraction", "0.000", 18, ""},
+		{"negative", "-1", 18, ""},
+		{"negative zero", "-0", 18, ""},
+		{"empty", "", 18, ""},
+		{"dot", ".", 18, ""},
+		{"not a number", "abc", 18, ""},
+		{"hex", "0x10", 18, ""},
+		{"max uint256", maxUint256.String(), 0, maxUint256.String()},
+		{"over uint256", new(big.Int).Add(maxUint256, big.NewInt(1)).String(), 0, ""},
+		{"scientific overflow", "1e200", 18, ""},
+		{"exponent out of range", "1e1000", 0, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseAmount(test.amount, test.decimals)
+			if test.want == "" {
+				if err == nil {
+					t.Fatalf("ParseAmount(%q, %d) = %s, want an error", test.amount, test.decimals, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAmount(%q, %d): %v", test.amount, test.decimals, err)
+			}
+			if got.String() != test.want {
+				t.Errorf("ParseAmount(%q, %d) = %s, want %s", test.amount, test.decimals, got, test.want)
+			}
+		})
+	}
+}